@@ -37,11 +37,18 @@ var netClient = &http.Client{
 //          construction does not need to be done multiple times.
 type Instance struct {
 	Props                 schematree.IList
+	Absent                schematree.IList // properties known to be absent. Only respected by CalcRecommendations.
 	tree                  *schematree.SchemaTree
 	useOptimisticCache    bool // using cache will make an optimistic assumption that `props` are not altered
 	cachedRecommendations schematree.PropertyRecommendations
+	cachedTypes           schematree.PropertyRecommendations
+	traversal             *schematree.Traversal   // matching tree branches of Props, only kept by incremental updates
+	history               []*schematree.Traversal // previous traversals, reused when an update is undone
 }
 
+// maxHistory is the number of previous traversals an instance keeps for undone updates
+const maxHistory = 16
+
 // NewInstance : constructor method
 func NewInstance(argProps schematree.IList, argTree *schematree.SchemaTree, argUseCache bool) *Instance {
 	return &Instance{
@@ -70,16 +77,111 @@ func (inst *Instance) CalcPropertyLength() int {
 	return len(inst.Props)
 }
 
+// Add : Adds properties (or types) to the assessment, e.g. when they are added in an editing session.
+// The tree branches matching the previous properties are reused to find those of the new ones.
+func (inst *Instance) Add(items schematree.IList) {
+	traversal := inst.currentTraversal()
+	for _, item := range items {
+		traversal = inst.tree.Refine(traversal, item)
+	}
+	inst.update(traversal)
+}
+
+// Remove : Removes properties (or types) from the assessment. If this restores the properties of a
+// previous update, the tree branches found back then are reused.
+func (inst *Instance) Remove(items schematree.IList) {
+	removed := make(map[*schematree.IItem]bool, len(items))
+	for _, item := range items {
+		removed[item] = true
+	}
+	remaining := schematree.IList{}
+	for _, p := range inst.Props {
+		if !removed[p] {
+			remaining = append(remaining, p)
+		}
+	}
+	if len(remaining) == len(inst.Props) {
+		return
+	}
+
+	for i := len(inst.history) - 1; i >= 0; i-- {
+		if sameItems(inst.history[i].Properties(), remaining) {
+			inst.update(inst.history[i])
+			return
+		}
+	}
+	inst.update(inst.tree.Traverse(remaining))
+}
+
+// currentTraversal returns the traversal of the current properties, which is computed on first use
+func (inst *Instance) currentTraversal() *schematree.Traversal {
+	if inst.traversal == nil {
+		inst.traversal = inst.tree.Traverse(inst.Props)
+	}
+	return inst.traversal
+}
+
+// update replaces the properties by those of the traversal and invalidates the cached recommendations
+func (inst *Instance) update(traversal *schematree.Traversal) {
+	if inst.traversal != nil && inst.traversal != traversal {
+		inst.history = append(inst.history, inst.traversal)
+		if len(inst.history) > maxHistory {
+			inst.history = inst.history[1:]
+		}
+	}
+	inst.traversal = traversal
+	inst.Props = append(schematree.IList{}, traversal.Properties()...)
+	inst.cachedRecommendations = nil
+	inst.cachedTypes = nil
+}
+
+// sameItems checks if both lists contain the same items, in any order
+func sameItems(a, b schematree.IList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[*schematree.IItem]bool, len(a))
+	for _, p := range a {
+		set[p] = true
+	}
+	for _, p := range b {
+		if !set[p] {
+			return false
+		}
+	}
+	return true
+}
+
 // CalcRecommendations : Will execute the core schematree recommender on the properties and return
 // the list of recommendations. Cache-enabled operation.
 func (inst *Instance) CalcRecommendations() schematree.PropertyRecommendations {
 	if inst.useOptimisticCache == true {
 		if inst.cachedRecommendations == nil {
-			inst.cachedRecommendations = inst.tree.RecommendProperty(inst.Props)
+			inst.cachedRecommendations = inst.calcRecommendations()
 		}
 		return inst.cachedRecommendations
 	}
-	return inst.tree.RecommendProperty(inst.Props)
+	return inst.calcRecommendations()
+}
+
+// calcRecommendations reuses the traversal of incremental updates if there are no absent properties
+func (inst *Instance) calcRecommendations() schematree.PropertyRecommendations {
+	if inst.traversal != nil && len(inst.Absent) == 0 {
+		return inst.tree.RecommendTraversal(inst.traversal)
+	}
+	return inst.tree.RecommendPropertyExcluding(inst.Props, inst.Absent)
+}
+
+// CalcTypeRecommendations : Will execute the core schematree type recommender on the properties and
+// return the list of recommended types. Cache-enabled operation.
+func (inst *Instance) CalcTypeRecommendations() schematree.PropertyRecommendations {
+	if inst.useOptimisticCache == true {
+		if inst.cachedTypes == nil {
+			inst.cachedTypes = inst.tree.RecommendTypes(inst.Props)
+		}
+		return inst.cachedTypes
+	}
+	return inst.tree.RecommendTypes(inst.Props)
 }
 
 // GetWikiRecs computes recommendations from a local wikidata PropertySuggester
@@ -123,7 +225,7 @@ func (inst *Instance) GetWikiRecs(Properties []string) schematree.PropertyRecomm
 	// }
 	ranked := make([]schematree.RankedPropertyCandidate, 0, len(recs.Search))
 	for _, r := range recs.Search {
-		item, ok := inst.tree.PropMap["http://www.wikidata.org/prop/direct/"+r.ID]
+		item, ok := inst.tree.PropMap.Lookup("http://www.wikidata.org/prop/direct/" + r.ID)
 		// if !ok {
 		// 	item, ok = inst.tree.PropMap["http://www.wikidata.org/prop/"+r.ID]
 		// }
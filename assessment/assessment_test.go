@@ -0,0 +1,69 @@
+package assessment
+
+import (
+	"testing"
+
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/stretchr/testify/assert"
+)
+
+var typedTreepath = "../testdata/10M.nt.gz.schemaTree.typed.bin"
+
+func TestAddRemove(t *testing.T) {
+	tree, err := schematree.Load(typedTreepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := tree.BuildPropertyList(
+		[]string{"http://www.wikidata.org/prop/direct/P31", "http://www.wikidata.org/prop/direct/P17", "http://www.wikidata.org/prop/direct/P625"},
+		[]string{"http://www.wikidata.org/entity/Q515"})
+	assert.Len(t, items, 4)
+
+	// the recommendations are those of a new assessment of the same properties
+	assertSame := func(inst *Instance) {
+		t.Helper()
+		expected := NewInstance(append(schematree.IList{}, inst.Props...), tree, false).CalcRecommendations()
+		recs := inst.CalcRecommendations()
+		assert.Len(t, recs, len(expected))
+		probabilities := make(map[*schematree.IItem]float64)
+		for _, rec := range expected {
+			probabilities[rec.Property] = rec.Probability
+		}
+		for _, rec := range recs {
+			assert.Equal(t, probabilities[rec.Property], rec.Probability, rec.Property.Str())
+		}
+	}
+
+	inst := NewInstance(schematree.IList{}, tree, true)
+	inst.Add(items[:2])
+	assert.ElementsMatch(t, items[:2], inst.Props)
+	assertSame(inst)
+	twoProperties := inst.traversal
+
+	inst.Add(items[2:])
+	assert.ElementsMatch(t, items, inst.Props)
+	assertSame(inst)
+
+	// adding a known property or removing none changes nothing
+	inst.Add(items[:1])
+	inst.Remove(schematree.IList{})
+	assert.Len(t, inst.Props, 4)
+
+	// undoing an update reuses the traversal from before
+	inst.Remove(items[2:])
+	assert.ElementsMatch(t, items[:2], inst.Props)
+	assert.Same(t, twoProperties, inst.traversal)
+	assertSame(inst)
+
+	// removing properties that were never alone traverses the tree again
+	inst.Remove(items[:1])
+	assert.ElementsMatch(t, items[1:2], inst.Props)
+	assertSame(inst)
+
+	// the history is bounded
+	for i := 0; i < 2*maxHistory; i++ {
+		inst.Add(items[2:3])
+		inst.Remove(items[2:3])
+	}
+	assert.Len(t, inst.history, maxHistory)
+}
@@ -146,7 +146,7 @@ func (strat *BackoffDeleteLowFrequencyItems) execRecommender(items ST.IList, rem
 	// OPT: Optimize Runtime here (O(n^2) to O(n*log(n) by first sorting and then efficient compare))
 	for _, r := range removelist {
 		for i, item := range recommendation {
-			if *item.Property.Str == *r.Str { // https://yourbasic.org/golang/delete-element-slice/
+			if item.Property.Str() == r.Str() { // https://yourbasic.org/golang/delete-element-slice/
 				copy(recommendation[i:], recommendation[i+1:])                       // Shift recommendation[i+1:] left one index.
 				recommendation[len(recommendation)-1] = ST.RankedPropertyCandidate{} // Erase last element (write zero value).
 				recommendation = recommendation[:len(recommendation)-1]
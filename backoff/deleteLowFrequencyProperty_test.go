@@ -12,7 +12,10 @@ var treePath = "../testdata/10M.nt.gz.schemaTree.bin"
 
 func i2iItem(i int) *ST.IItem {
 	s := strconv.Itoa(i)
-	return &ST.IItem{Str: &s, TotalCount: uint64(i), SortOrder: uint32(i)}
+	item := ST.NewItem(s)
+	item.TotalCount = uint64(i)
+	item.SortOrder = uint32(i)
+	return item
 }
 
 func orderedList(length int) (ls ST.IList) {
@@ -55,9 +58,9 @@ func TestExecRecommender(t *testing.T) {
 	b.init(schema, 1, StepsizeLinear)
 	c := make(chan chanObject, 1)
 
-	prop1, _ := pMap["http://www.wikidata.org/prop/direct/P31"]
-	prop2, _ := pMap["http://www.wikidata.org/prop/direct/P21"]
-	prop3, _ := pMap["http://www.wikidata.org/prop/direct/P27"]
+	prop1, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P31")
+	prop2, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P21")
+	prop3, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P27")
 	props := ST.IList{prop1, prop2, prop3}
 
 	removed := []*ST.IItem{}
@@ -70,7 +73,7 @@ func TestExecRecommender(t *testing.T) {
 	rec := <-c
 	for _, r := range rec.recommendations {
 		for _, r2 := range removed {
-			if *r.Property.Str == *r2.Str {
+			if r.Property.Str() == r2.Str() {
 				t.Errorf("Deletion of removed item didn't work")
 			}
 		}
@@ -53,9 +53,9 @@ var MaxMerger = func(recommendations []ST.PropertyRecommendations) (merged ST.Pr
 	// compute max probaility per recommendation
 	for _, recList := range recommendations {
 		for _, rec := range recList {
-			rMap := m[*rec.Property.Str]
+			rMap := m[rec.Property.Str()]
 			if rMap.Probability < rec.Probability {
-				m[*rec.Property.Str] = rec
+				m[rec.Property.Str()] = rec
 			}
 		}
 	}
@@ -79,7 +79,7 @@ var AvgMerger = func(recommendations []ST.PropertyRecommendations) (merged ST.Pr
 	// gather the recommendations per property name
 	for _, recList := range recommendations {
 		for _, rec := range recList {
-			m[*(rec.Property.Str)] = append(m[*(rec.Property.Str)], rec)
+			m[rec.Property.Str()] = append(m[rec.Property.Str()], rec)
 		}
 	}
 
@@ -162,7 +162,7 @@ func (strat *BackoffSplitPropertySet) execRecommender(items ST.IList, removelist
 	// OPT: Optimize Runtime here (O(n^2) to O(n*log(n) by first sorting and then efficient compare))
 	for _, r := range removelist {
 		for i, item := range recommendation {
-			if *item.Property.Str == *r.Str { // https://yourbasic.org/golang/delete-element-slice/
+			if item.Property.Str() == r.Str() { // https://yourbasic.org/golang/delete-element-slice/
 				copy(recommendation[i:], recommendation[i+1:])                       // Shift recommendation[i+1:] left one index.
 				recommendation[len(recommendation)-1] = ST.RankedPropertyCandidate{} // Erase last element (write zero value).
 				recommendation = recommendation[:len(recommendation)-1]
@@ -16,9 +16,9 @@ func TestRecommender(t *testing.T) {
 	b := BackoffSplitPropertySet{}
 	b.init(schema, TwoSupportRangesSplitter, DummyMerger)
 
-	prop1, _ := pMap["http://www.wikidata.org/prop/direct/P31"]
-	prop2, _ := pMap["http://www.wikidata.org/prop/direct/P21"]
-	prop3, _ := pMap["http://www.wikidata.org/prop/direct/P27"]
+	prop1, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P31")
+	prop2, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P21")
+	prop3, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P27")
 	props := ST.IList{prop1, prop2, prop3}
 
 	b.Recommend(props)
@@ -36,9 +36,9 @@ func TestAvgMerger(t *testing.T) {
 	b := BackoffSplitPropertySet{}
 	b.init(schema, TwoSupportRangesSplitter, DummyMerger)
 
-	prop1, _ := pMap["http://www.wikidata.org/prop/direct/P31"]
-	prop2, _ := pMap["http://www.wikidata.org/prop/direct/P21"]
-	prop3, _ := pMap["http://www.wikidata.org/prop/direct/P27"]
+	prop1, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P31")
+	prop2, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P21")
+	prop3, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P27")
 
 	rec1 := ST.PropertyRecommendations{ST.RankedPropertyCandidate{Property: prop1, Probability: 0.2}, ST.RankedPropertyCandidate{Property: prop2, Probability: 0.5}}
 	rec2 := ST.PropertyRecommendations{ST.RankedPropertyCandidate{Property: prop1, Probability: 0.8}, ST.RankedPropertyCandidate{Property: prop3, Probability: 0.4}}
@@ -51,11 +51,11 @@ func TestAvgMerger(t *testing.T) {
 
 	for _, r := range res {
 		// Test values
-		if *(r.Property.Str) == *(prop1.Str) && r.Probability != float64(0.25) {
+		if r.Property.Str() == prop1.Str() && r.Probability != float64(0.25) {
 			t.Errorf("Property 1 should have probability 0.25 but has %f", r.Probability)
-		} else if *r.Property.Str == *prop2.Str && r.Probability != float64(0.25) {
+		} else if r.Property.Str() == prop2.Str() && r.Probability != float64(0.25) {
 			t.Errorf("Property 2 should have probability 0.25 but has %f", r.Probability)
-		} else if *r.Property.Str == *prop3.Str && r.Probability != float64(0.1) {
+		} else if r.Property.Str() == prop3.Str() && r.Probability != float64(0.1) {
 			t.Errorf("Property 3 should have probability 0.1 but has %f", r.Probability)
 		}
 	}
@@ -73,9 +73,9 @@ func TestMaxMerger(t *testing.T) {
 	b := BackoffSplitPropertySet{}
 	b.init(schema, TwoSupportRangesSplitter, DummyMerger)
 
-	prop1, _ := pMap["http://www.wikidata.org/prop/direct/P31"]
-	prop2, _ := pMap["http://www.wikidata.org/prop/direct/P21"]
-	prop3, _ := pMap["http://www.wikidata.org/prop/direct/P27"]
+	prop1, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P31")
+	prop2, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P21")
+	prop3, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P27")
 
 	rec1 := ST.PropertyRecommendations{ST.RankedPropertyCandidate{Property: prop1, Probability: 0.2}, ST.RankedPropertyCandidate{Property: prop2, Probability: 0.5}}
 	rec2 := ST.PropertyRecommendations{ST.RankedPropertyCandidate{Property: prop1, Probability: 0.8}, ST.RankedPropertyCandidate{Property: prop3, Probability: 0.4}}
@@ -87,11 +87,11 @@ func TestMaxMerger(t *testing.T) {
 
 	for _, r := range res {
 		// Test values
-		if *(r.Property.Str) == *(prop1.Str) && r.Probability != 0.8 {
+		if r.Property.Str() == prop1.Str() && r.Probability != 0.8 {
 			t.Errorf("Property 1 should have probability 0.8 but has %f", r.Probability)
-		} else if *r.Property.Str == *prop2.Str && r.Probability != 0.5 {
+		} else if r.Property.Str() == prop2.Str() && r.Probability != 0.5 {
 			t.Errorf("Property 2 should have probability 0.5 but has %f", r.Probability)
-		} else if *r.Property.Str == *prop3.Str && r.Probability != 0.4 {
+		} else if r.Property.Str() == prop3.Str() && r.Probability != 0.4 {
 			t.Errorf("Property 3 should have probability 0.4 but has %f", r.Probability)
 		}
 	}
@@ -0,0 +1,141 @@
+package batch
+
+// This package computes recommendations for all subjects of a dataset at once, without a server.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lgleim/SchemaTreeRecommender/assessment"
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/lgleim/SchemaTreeRecommender/strategy"
+	"github.com/pkg/errors"
+)
+
+// Vocabulary of the suggestion statements in the N-Triples output.
+const (
+	SuggestionPredicate  = "https://github.com/lgleim/SchemaTreeRecommender/vocab#suggestion"
+	PropertyPredicate    = "https://github.com/lgleim/SchemaTreeRecommender/vocab#property"
+	ProbabilityPredicate = "https://github.com/lgleim/SchemaTreeRecommender/vocab#probability"
+	xsdDouble            = "http://www.w3.org/2001/XMLSchema#double"
+)
+
+// Format is an output format of the batch recommender.
+type Format int
+
+// Output formats of the batch recommender.
+const (
+	JSONL    Format = iota // one json object per subject and line
+	NTriples               // suggestion statements, see SuggestionPredicate
+)
+
+// ParseFormat returns the output format of the given name: "jsonl" or "nt".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "jsonl":
+		return JSONL, nil
+	case "nt":
+		return NTriples, nil
+	}
+	return JSONL, errors.Errorf("Output format not found: %v", name)
+}
+
+// Extension returns the file extension of the format.
+func (f Format) Extension() string {
+	if f == NTriples {
+		return "nt"
+	}
+	return "jsonl"
+}
+
+// SubjectRecommendations is the data representation of a line of the JSONL output.
+type SubjectRecommendations struct {
+	Subject         string                `json:"subject"`
+	Recommendations []RecommendationEntry `json:"recommendations"`
+}
+
+// RecommendationEntry is each recommendation of a subject in the JSONL output.
+type RecommendationEntry struct {
+	Property    string  `json:"property"`
+	Probability float64 `json:"probability"`
+}
+
+// Recommend reads all subjects of the N-Triples dataset, runs the workflow on the properties (and, for typed
+// models, types) of each subject and writes its top-k recommendations to `out` in the given format.
+// Subjects are processed in parallel, so the order of the output does not follow the dataset.
+// It returns the number of subjects that were read, or an error if k is negative.
+func Recommend(
+	model *schematree.SchemaTree,
+	workflow *strategy.Workflow,
+	dataset string,
+	k int, // number of recommendations per subject
+	format Format,
+	out io.Writer,
+) (subjects uint64, err error) {
+	if k < 0 {
+		return 0, errors.Errorf("Number of recommendations must not be negative: %v", k)
+	}
+
+	var lock sync.Mutex // guards out and err
+	var blankNodes uint64
+
+	handler := func(s *schematree.SubjectSummary) {
+		list := make(schematree.IList, 0, len(s.Properties))
+		for p := range s.Properties {
+			list = append(list, p)
+		}
+
+		recs := workflow.Recommend(assessment.NewInstance(list, model, true))
+		if len(recs) > k {
+			recs = recs[:k]
+		}
+
+		var buf strings.Builder
+		switch format {
+		case JSONL:
+			entries := make([]RecommendationEntry, len(recs))
+			for i, rec := range recs {
+				entries[i] = RecommendationEntry{Property: rec.Property.IRI(), Probability: rec.Probability}
+			}
+			line, _ := json.Marshal(SubjectRecommendations{Subject: s.Str, Recommendations: entries})
+			buf.Write(line)
+			buf.WriteByte('\n')
+		case NTriples:
+			subject := term(s.Str)
+			for _, rec := range recs {
+				node := "_:s" + strconv.FormatUint(atomic.AddUint64(&blankNodes, 1), 10)
+				fmt.Fprintf(&buf, "%v <%v> %v .\n", subject, SuggestionPredicate, node)
+				fmt.Fprintf(&buf, "%v <%v> <%v> .\n", node, PropertyPredicate, rio.EscapeIRI(rec.Property.IRI()))
+				fmt.Fprintf(&buf, "%v <%v> \"%v\"^^<%v> .\n", node, ProbabilityPredicate, rec.Probability, xsdDouble)
+			}
+		}
+
+		lock.Lock()
+		if _, werr := io.WriteString(out, buf.String()); werr != nil && err == nil {
+			err = werr
+		}
+		lock.Unlock()
+	}
+
+	subjects = schematree.SubjectSummaryReader(dataset, model.PropMap, handler, 0, model.Typed, schematree.ReaderOptions{})
+	return
+}
+
+// term writes a subject as N-Triples term. The reader strips the angle brackets and escape sequences of
+// IRIs, so they are escaped again. Blank node labels starting with 's' or 'x' are prefixed with 'x' to keep
+// them apart from the suggestion nodes `_:s<n>`.
+func term(subject string) string {
+	if label := strings.TrimPrefix(subject, "_:"); label != subject {
+		if strings.HasPrefix(label, "s") || strings.HasPrefix(label, "x") {
+			return "_:x" + label
+		}
+		return subject
+	}
+	return "<" + rio.EscapeIRI(subject) + ">"
+}
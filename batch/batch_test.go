@@ -0,0 +1,75 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/lgleim/SchemaTreeRecommender/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+var typedTreepath = "../testdata/10M.nt.gz.schemaTree.typed.bin"
+
+const dataset = `<http://www.wikidata.org/entity/Q1> <http://www.wikidata.org/prop/direct/P31> <http://www.wikidata.org/entity/Q515> .
+<http://www.wikidata.org/entity/Q1> <http://www.wikidata.org/prop/direct/P17> <http://www.wikidata.org/entity/Q183> .
+_:s1 <http://www.wikidata.org/prop/direct/P625> "Point(0 0)" .
+<http://example.org/a\u0020b> <http://www.wikidata.org/prop/direct/P31> <http://www.wikidata.org/entity/Q5> .
+`
+
+func TestRecommend(t *testing.T) {
+	model, err := schematree.Load(typedTreepath)
+	assert.NoError(t, err)
+	workflow := strategy.MakePresetWorkflow("direct", model)
+
+	f, err := ioutil.TempFile("", "batch-*.nt")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString(dataset)
+	f.Close()
+
+	t.Run("jsonl", func(t *testing.T) {
+		var out bytes.Buffer
+		subjects, err := Recommend(model, workflow, f.Name(), 3, JSONL, &out)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, subjects)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		assert.Len(t, lines, 3)
+		for _, line := range lines {
+			var recs SubjectRecommendations
+			assert.NoError(t, json.Unmarshal([]byte(line), &recs))
+			assert.Contains(t, []string{"http://www.wikidata.org/entity/Q1", "_:s1", "http://example.org/a b"}, recs.Subject)
+			assert.Len(t, recs.Recommendations, 3)
+		}
+	})
+
+	t.Run("nt", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := Recommend(model, workflow, f.Name(), 3, NTriples, &out)
+		assert.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		assert.Len(t, lines, 3*3*3)
+		assert.Equal(t, 3, strings.Count(out.String(), "<http://www.wikidata.org/entity/Q1> <"+SuggestionPredicate+">"))
+		assert.Equal(t, 3, strings.Count(out.String(), "_:xs1 <"+SuggestionPredicate+">"), "the label of the dataset is escaped")
+		assert.NotContains(t, out.String(), "_:s1 <"+SuggestionPredicate+">")
+		assert.Equal(t, 3, strings.Count(out.String(), `<http://example.org/a\u0020b> <`+SuggestionPredicate+">"), "the IRI is escaped")
+		for _, line := range lines {
+			_, _, _, err := rio.ParseTriple([]byte(line))
+			assert.NoError(t, err, line)
+		}
+	})
+
+	t.Run("negative k", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := Recommend(model, workflow, f.Name(), -1, JSONL, &out)
+		assert.Error(t, err)
+		assert.Zero(t, out.Len())
+	})
+}
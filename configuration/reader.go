@@ -22,6 +22,11 @@ type Layer struct {
 	Splitter           string  // needed for splitintosubsets backoff everySecondItem, twoSupportRanges
 	Stepsize           string  // needed for deletelowfrequentitmes backoff stepsizeLinear, stepsizeProportional
 	ParallelExecutions int     // needed for deletelowfrequentitmes backoff
+	Estimator          string  // optional re-estimation of the probabilities: mle, laplace, wilson
+	EstimatorParam     float64 // optional for estimators: pseudo-count alpha for laplace, z-value for wilson
+	MaxBranches        int     // needed for standardSampled backoff
+	Diversity          float64 // optional penalty for candidates co-occurring with higher ranked ones, 0 disables re-ranking
+	DiversityWindow    int     // optional number of top candidates to re-rank for diversity (default 20)
 }
 
 //Configuration defines one workflow configuration
@@ -43,6 +48,18 @@ func ReadConfigFile(name *string) (conf *Configuration, err error) {
 	return
 }
 
+//ReadUnknownMapping reads a json file that maps unknown property (or type) IRIs to known replacements
+//e.g. {"http://www.wikidata.org/prop/direct/P9999": "http://www.wikidata.org/prop/direct/P31"}
+func ReadUnknownMapping(name string) (mapping map[string]string, err error) {
+	file, err := ioutil.ReadFile(name)
+	if err != nil {
+		err = errors.Wrap(err, "Read File failed")
+		return
+	}
+	err = errors.Wrapf(json.Unmarshal(file, &mapping), "Invalid mapping in %v", name)
+	return
+}
+
 //ConfigToWorkflow converts a configuration to a workflow
 func ConfigToWorkflow(config *Configuration, tree *schematree.SchemaTree) (wf *strategy.Workflow, err error) {
 	workflow := strategy.Workflow{}
@@ -78,6 +95,14 @@ func ConfigToWorkflow(config *Configuration, tree *schematree.SchemaTree) (wf *s
 			}
 		case "standard":
 			back = strategy.MakeAssessmentAwareDirectProcedure()
+		case "standardTypes":
+			back = strategy.MakeAssessmentAwareTypeProcedure()
+		case "standardSampled":
+			if l.MaxBranches <= 0 {
+				err = errors.Errorf("MaxBranches must be positive for the standardSampled backoff")
+				return
+			}
+			back = strategy.MakeSampledProcedure(tree, l.MaxBranches)
 		case "splitProperty":
 			var merger backoff.MergerFunc
 			var splitter backoff.SplitterFunc
@@ -109,6 +134,23 @@ func ConfigToWorkflow(config *Configuration, tree *schematree.SchemaTree) (wf *s
 			cond = strategy.MakeAlwaysCondition()
 			err = errors.Errorf("Backoff not found: " + l.Backoff)
 		}
+		//switch the estimators
+		switch l.Estimator {
+		case "":
+		case "mle":
+			back = strategy.MakeEstimatedProcedure(back, schematree.MaximumLikelihoodEstimator)
+		case "laplace":
+			back = strategy.MakeEstimatedProcedure(back, schematree.MakeLaplaceEstimator(l.EstimatorParam))
+		case "wilson":
+			back = strategy.MakeEstimatedProcedure(back, schematree.MakeWilsonEstimator(l.EstimatorParam))
+		default:
+			err = errors.Errorf("Estimator not found: " + l.Estimator)
+			return
+		}
+		//re-rank for diversity
+		if l.Diversity > 0 {
+			back = strategy.MakeDiverseProcedure(tree, back, l.Diversity, l.DiversityWindow)
+		}
 		//create the wf layer
 		workflow.Push(cond, back, fmt.Sprintf("layer %v", i))
 	}
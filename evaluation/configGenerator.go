@@ -35,7 +35,7 @@ func createConfigFiles(creater *string) (err error) {
 
 	createrConfig, err := readCreaterConfig(creater)
 
-	fallbackLayer := configuration.Layer{"always", "standard", 0, 0.0, "", "", "", 0}
+	fallbackLayer := configuration.Layer{Condition: "always", Backoff: "standard", Threshold: 0, ThresholdFloat: 0.0, Merger: "", Splitter: "", Stepsize: "", ParallelExecutions: 0}
 	backoffLayers := make([]configuration.Layer, 0, 0)
 
 	// create a bunch of layers
@@ -47,11 +47,11 @@ func createConfigFiles(creater *string) (err error) {
 				for _, s := range createrConfig.Splitter {
 					if con == "tooUnlikelyRecommendationsCondition" {
 						fthresh := (float32(thresh) / float32(createrConfig.MaxThreshold)) * createrConfig.MaxFloat
-						l := configuration.Layer{con, "splitProperty", thresh, fthresh, m, s, "", 0}
+						l := configuration.Layer{Condition: con, Backoff: "splitProperty", Threshold: thresh, ThresholdFloat: fthresh, Merger: m, Splitter: s, Stepsize: "", ParallelExecutions: 0}
 						backoffLayers = append(backoffLayers, l)
 
 					} else {
-						l := configuration.Layer{con, "splitProperty", thresh, 0.0, m, s, "", 0}
+						l := configuration.Layer{Condition: con, Backoff: "splitProperty", Threshold: thresh, ThresholdFloat: 0.0, Merger: m, Splitter: s, Stepsize: "", ParallelExecutions: 0}
 						backoffLayers = append(backoffLayers, l)
 					}
 				}
@@ -61,11 +61,11 @@ func createConfigFiles(creater *string) (err error) {
 				for _, s := range createrConfig.Steps {
 					if con == "tooUnlikelyRecommendationsCondition" {
 						fthresh := (float32(thresh) / float32(createrConfig.MaxThreshold)) * createrConfig.MaxFloat
-						l := configuration.Layer{con, "deleteLowFrequency", thresh, fthresh, "", "", s, parallel}
+						l := configuration.Layer{Condition: con, Backoff: "deleteLowFrequency", Threshold: thresh, ThresholdFloat: fthresh, Merger: "", Splitter: "", Stepsize: s, ParallelExecutions: parallel}
 						backoffLayers = append(backoffLayers, l)
 
 					} else {
-						l := configuration.Layer{con, "deleteLowFrequency", thresh, 0.00, "", "", s, parallel}
+						l := configuration.Layer{Condition: con, Backoff: "deleteLowFrequency", Threshold: thresh, ThresholdFloat: 0.00, Merger: "", Splitter: "", Stepsize: s, ParallelExecutions: parallel}
 						backoffLayers = append(backoffLayers, l)
 					}
 				}
@@ -75,7 +75,7 @@ func createConfigFiles(creater *string) (err error) {
 
 	// create config files from backoff layers
 	for i, l := range backoffLayers {
-		c := configuration.Configuration{"../testdata/10M.nt_1in2_test.gz", []configuration.Layer{l, fallbackLayer}}
+		c := configuration.Configuration{Testset: "../testdata/10M.nt_1in2_test.gz", Layers: []configuration.Layer{l, fallbackLayer}}
 		err = writeConfigFile(&c, fmt.Sprintf("./configs/config_%v.json", i))
 		if err != nil {
 			log.Fatal("could not write config file ", err)
@@ -38,10 +38,10 @@ func HandlerTakeOneButType(
 	for key := range s.Properties {
 		if cnt != len(s.Properties)-1 {
 			reducedSet[cnt] = key
-			// fmt.Println("[RED] " + *reducedSet[cnt].Str)
+			// fmt.Println("[RED] " + reducedSet[cnt].Str())
 		} else {
 			leftoutSet[0] = key
-			// fmt.Println("[LEF] " + *leftoutSet[0].Str)
+			// fmt.Println("[LEF] " + leftoutSet[0].Str())
 		}
 		cnt++
 	}
@@ -50,7 +50,7 @@ func HandlerTakeOneButType(
 	for idx := 0; idx < len(s.Properties); idx++ {
 		if !leftoutSet[0].IsType() { // Only evaluate if leftout property is not a type property.
 			newResult := evaluator(reducedSet, leftoutSet)
-			newResult.note = s.Str + " " + *leftoutSet[0].Str
+			newResult.note = s.Str + " " + leftoutSet[0].Str()
 			results = append(results, newResult)
 		}
 		if idx < len(reducedSet) { // run len(s.Property) times and swap len(s.reducedSet) times
@@ -129,7 +129,7 @@ func HandlerTakeAllButBest(
 	// @debug: Write all the reduced set property names
 	propNames := ""
 	for _, item := range reducedSet {
-		propNames = propNames + *item.Str + " "
+		propNames = propNames + item.Str() + " "
 	}
 	newResult.note = s.Str + " ( " + propNames + ")"
 
@@ -188,7 +188,7 @@ func HandlerTakeMoreButCommon(
 		// @debug: Write all the reduced set property names
 		propNames := ""
 		for _, item := range reducedSet {
-			propNames = propNames + *item.Str + " "
+			propNames = propNames + item.Str() + " "
 		}
 		newResult.note = s.Str + " ( " + propNames + ")"
 
@@ -388,8 +388,8 @@ func buildHistoricHandlerTakeButType() handlerFunc {
 			boxedLeftOut[0] = leftOut
 			newResult := evaluator(reducedEntitySet, boxedLeftOut)
 			newResult.group = currentRoundID
-			if leftOut.Str != nil {
-				newResult.note = *leftOut.Str
+			if leftOut != nil {
+				newResult.note = leftOut.Str()
 			} else {
 				newResult.note = "NIL"
 			}
@@ -127,7 +127,7 @@ func evaluatePair(
 	}
 	numFN = uint32(len(leftoutProps)) - numTP // number of not recovered properties
 	numFP = uint32(len(recs)) - numTP         // number of Recommended but not relevant properties
-	numTN = uint32(len(tree.PropMap)) - numTP - numFN - numFP
+	numTN = uint32(tree.PropMap.Len()) - numTP - numFN - numFP
 
 	// Calculate the rank: the number of non-left out properties that were given before
 	// all left-out properties are recommended, plus 1.
@@ -231,7 +231,7 @@ func evaluateDataset(
 
 	// Start the subject summary reader and collect all results into resultList, using the
 	// process that is managing the resultQueue.
-	schematree.SubjectSummaryReader(filePath, tree.PropMap, subjectCallback, 0, isTyped)
+	schematree.SubjectSummaryReader(filePath, tree.PropMap, subjectCallback, 0, isTyped, schematree.ReaderOptions{})
 	close(resultQueue)     // mark the end of results channel
 	resultWaitGroup.Wait() // wait until the parallel process that manages the queue is terminated
 
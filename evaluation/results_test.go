@@ -7,8 +7,8 @@ import (
 )
 
 func TestReadWriteConfigFile(t *testing.T) {
-	l1 := configuration.Layer{"tooFewRecommendation", "splitProperty", 100, 0.6, "avg", "everySecondItem", "", 0}
-	cOut := configuration.Configuration{"../testdata/10M.nt_1in2_test.gz", []configuration.Layer{l1, l1}}
+	l1 := configuration.Layer{Condition: "tooFewRecommendation", Backoff: "splitProperty", Threshold: 100, ThresholdFloat: 0.6, Merger: "avg", Splitter: "everySecondItem", Stepsize: "", ParallelExecutions: 0}
+	cOut := configuration.Configuration{Testset: "../testdata/10M.nt_1in2_test.gz", Layers: []configuration.Layer{l1, l1}}
 	fileName := "./configs/test.json"
 	writeConfigFile(&cOut, fileName)
 
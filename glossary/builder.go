@@ -3,7 +3,9 @@ package glossary
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
+	goio "io"
 	"os"
 
 	"github.com/lgleim/SchemaTreeRecommender/io"
@@ -32,9 +34,13 @@ type GlossaryStats struct {
 // Glossary holds an entire glossary.
 type Glossary map[Key]*Content // glossary[property,language]
 
-// BuildGlossary from a dataset of N-Triples
+// BuildGlossary from a dataset of N-Triples, N-Quads, Turtle or TriG, using the statements of the graphs selected by the filter (nil selects all).
+// Wikidata JSON dumps (.json) are read by buildWikidataGlossary instead.
 // todo: Should this method receive the filepath, a filehandler, or a tripleparser?
-func BuildGlossary(filePath string) (*Glossary, GlossaryStats, error) {
+func BuildGlossary(filePath string, graphs *recIO.GraphFilter) (*Glossary, GlossaryStats, error) {
+	if recIO.SyntaxOf(filePath) == recIO.WikidataJSON {
+		return buildWikidataGlossary(filePath)
+	}
 	stats := GlossaryStats{0, 0, 0, make(map[string]uint64)}
 
 	// Setup property types of the wikidata ontology
@@ -47,6 +53,7 @@ func BuildGlossary(filePath string) (*Glossary, GlossaryStats, error) {
 		return nil, stats, err
 	}
 	defer tParser.Close()
+	tParser.Graphs = graphs
 
 	// Initialize the glossary that is to be returned.
 	glos := make(Glossary)
@@ -60,7 +67,8 @@ func BuildGlossary(filePath string) (*Glossary, GlossaryStats, error) {
 
 	// Go through each triple and add it to the glossary, while also creating entries
 	// on-the-fly if they don't exist.
-	for trip, err := tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
+	var trip *recIO.Triple
+	for trip, err = tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
 		// Get the predicate and make sure its either a label or description.
 		thisType := miscType
 		if bytes.Equal(trip.Predicate, wdLabelPredicate) {
@@ -71,19 +79,20 @@ func BuildGlossary(filePath string) (*Glossary, GlossaryStats, error) {
 			continue
 		}
 
-		// Get the text and language of the triple object.
-		text, lang := io.InterpreteLangLiteral(trip.Object)
+		// Get the text and language of the triple object, with decoded escape sequences.
+		object := io.ParseTerm(trip.Object)
+		text, lang := object.Value, object.Lang
 
-		if len(text) == 0 || len(lang) == 0 { // Only accept entries where both text and lang exist.
+		if object.Kind != io.Literal || len(text) == 0 || len(lang) == 0 { // Only accept entries where both text and lang exist.
 			continue
 		}
 
 		// IRIREFs get stripped of their enclosing '< >' when they are stored.
 		// TODO: See if the entire system (schematree as well) works with or without enclosing tags.
-		iri := io.InterpreteIriRef(trip.Subject)
+		iri := io.IRIValue(trip.Subject)
 
 		// Create the entry if it doesn't exist yet.
-		thisKey := &Key{string(iri), string(lang)}
+		thisKey := &Key{string(iri), lang}
 		thisContent, thisContentOk := glos[*thisKey]
 		if !thisContentOk {
 			thisContent = &Content{}
@@ -94,10 +103,10 @@ func BuildGlossary(filePath string) (*Glossary, GlossaryStats, error) {
 
 		// Add the information of this triple to the glossary.
 		if thisType == labelType {
-			thisContent.Label = string(text)
+			thisContent.Label = text
 			stats.TotalLabelCount += 1
 		} else if thisType == descriptionType {
-			thisContent.Description = string(text)
+			thisContent.Description = text
 			stats.TotalDescriptionCount += 1
 		}
 
@@ -109,6 +118,70 @@ func BuildGlossary(filePath string) (*Glossary, GlossaryStats, error) {
 	return &glos, stats, nil
 }
 
+// buildWikidataGlossary from the labels and descriptions of the property entities of a Wikidata JSON dump.
+// The glossary uses the IRIs of the truthy properties (wdt:P...), which the schematree is built with, so
+// the dump does not have to be split and altered first.
+func buildWikidataGlossary(filePath string) (*Glossary, GlossaryStats, error) {
+	stats := GlossaryStats{0, 0, 0, make(map[string]uint64)}
+
+	reader, err := recIO.NewEntityReader(filePath)
+	if err != nil {
+		return nil, stats, err
+	}
+	defer reader.Close()
+
+	glos := make(Glossary)
+
+	// Get the content of an entry, creating it if it doesn't exist.
+	content := func(key Key) *Content {
+		thisContent, ok := glos[key]
+		if !ok {
+			thisContent = &Content{}
+			glos[key] = thisContent
+			stats.TotalPropertyCount += 1
+			stats.PropertiesPerLanguage[key.Lang] = stats.PropertiesPerLanguage[key.Lang] + 1
+		}
+		return thisContent
+	}
+
+	var line []byte
+	for line, err = reader.NextJSON(); err == nil; line, err = reader.NextJSON() {
+		// Items are the vast majority of the dump, so check the type before decoding everything.
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err = json.Unmarshal(line, &header); err != nil {
+			return nil, stats, err
+		}
+		if header.Type != "property" {
+			continue
+		}
+
+		entity, err := recIO.DecodeEntity(line)
+		if err != nil {
+			return nil, stats, err
+		}
+		iri := recIO.WikidataPropertyPrefix + entity.ID
+		for _, label := range entity.Labels {
+			if label.Value != "" {
+				content(Key{iri, label.Language}).Label = label.Value
+				stats.TotalLabelCount += 1
+			}
+		}
+		for _, description := range entity.Descriptions {
+			if description.Value != "" {
+				content(Key{iri, description.Language}).Description = description.Value
+				stats.TotalDescriptionCount += 1
+			}
+		}
+	}
+	if err != goio.EOF {
+		return nil, stats, err
+	}
+
+	return &glos, stats, nil
+}
+
 // OutputStats of the glossary to stdout.
 func (glos *Glossary) OutputStats() {
 	fmt.Printf("Glossary: numEntries = %d\n", len(*glos))
@@ -0,0 +1,28 @@
+package glossary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wikidataDump is a dump with an item and a property, in the format of the Wikidata JSON dumps
+var wikidataDump = "../testdata/wikidata.json"
+
+func TestBuildWikidataGlossary(t *testing.T) {
+	glos, stats, err := BuildGlossary(wikidataDump, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the property is in the glossary, with the IRI of its truthy statements
+	iri := "http://www.wikidata.org/prop/direct/P31"
+	assert.Len(t, *glos, 2)
+	assert.Equal(t, Content{"instance of", "that class of which this subject is a particular example"}, *(*glos)[Key{iri, "en"}])
+	assert.Equal(t, Content{"ist ein(e)", ""}, *(*glos)[Key{iri, "de"}])
+
+	assert.Equal(t, uint64(2), stats.TotalPropertyCount)
+	assert.Equal(t, uint64(2), stats.TotalLabelCount)
+	assert.Equal(t, uint64(1), stats.TotalDescriptionCount)
+	assert.Equal(t, map[string]uint64{"en": 1, "de": 1}, stats.PropertiesPerLanguage)
+}
@@ -17,9 +17,10 @@ func TranslateRecommendations(glossary *Glossary, language string, recommendatio
 	for i, candidate := range recommendations {
 
 		property := candidate.Property
-		content, ok := (*glossary)[Key{*property.Str, language}]
+		iri := property.IRI() // types are looked up without their type prefix
+		content, ok := (*glossary)[Key{iri, language}]
 		if !ok { // no reference in given language -> try english
-			content, ok = (*glossary)[Key{*property.Str, "en"}]
+			content, ok = (*glossary)[Key{iri, "en"}]
 			if !ok { //no english reference -> use template
 				content = &Content{"", ""}
 			}
@@ -27,7 +28,7 @@ func TranslateRecommendations(glossary *Glossary, language string, recommendatio
 
 		// Whenever the label does not exist, use the actual property url
 		if content.Label == "" {
-			content.Label = *property.Str
+			content.Label = iri
 		}
 
 		labeledRecommendations[i] = LabeledRecommendation{property, content, candidate.Probability}
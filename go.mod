@@ -4,10 +4,14 @@ go 1.18
 
 require (
 	github.com/biogo/hts v1.4.3
+	github.com/chzyer/readline v1.5.1
+	github.com/klauspost/compress v1.15.2
 	github.com/klauspost/pgzip v1.2.5
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/cobra v1.4.0
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.7.1
+	github.com/ulikunitz/xz v0.5.11
 	gopkg.in/cheggaaa/pb.v1 v1.0.28
 )
 
@@ -15,12 +19,10 @@ require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/fatih/color v1.13.0 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
-	github.com/klauspost/compress v1.15.2 // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.0.0-20210927094055-39ccf1dd6fa6 // indirect
+	golang.org/x/sys v0.0.0-20220310020820-b874c991c1a5 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -0,0 +1,223 @@
+package io
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pb "gopkg.in/cheggaaa/pb.v1"
+)
+
+// Datasets that are split into many files can be read as one, by the name of their directory or by a glob
+// pattern of their files (e.g. `dump/part-*.nt.gz`). The files are read one after another in the order of
+// their names, so the statements of a subject stay together if they were in one file.
+
+// DatasetFiles returns the files of the dataset: the file itself, the files of a directory that have a data
+// format or compression extension, or the files that match a glob pattern, see filepath.Match. The files of
+// directories and patterns are sorted by name; hidden files and subdirectories are left out.
+func DatasetFiles(name string) ([]string, error) {
+	stat, err := os.Stat(name)
+	if err == nil && !stat.IsDir() {
+		return []string{name}, nil
+	}
+
+	var candidates []string
+	if err == nil {
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			fileName := entry.Name()
+			ext := filepath.Ext(fileName)
+			if _, isData := syntaxExtensions[ext]; isData || isCompressionExtension(ext) {
+				candidates = append(candidates, filepath.Join(name, fileName))
+			}
+		}
+	} else if isPattern(name) {
+		if candidates, err = filepath.Glob(name); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	var files []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(filepath.Base(candidate), ".") {
+			continue
+		}
+		if stat, err := os.Stat(candidate); err == nil && !stat.IsDir() {
+			files = append(files, candidate)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no dataset files found in %v", name)
+	}
+	return files, nil
+}
+
+// DatasetName returns the name from which the names of output files are derived for the dataset: the name
+// without trailing path separators, and for glob patterns the part before the first wildcard without the
+// separators at its end. For example, the outputs of `dump/` and `dump/*.nt.gz` are written next to the
+// directory, those of `dump/part-*.nt.gz` to `dump/part...`. The standard input is called "stdin".
+func DatasetName(name string) string {
+	if IsStdio(name) {
+		return "stdin"
+	}
+	cutset := `/\`
+	if i := strings.IndexAny(name, "*?["); i >= 0 && isPattern(name) {
+		name, cutset = name[:i], `/\-_.`
+	}
+	if trimmed := strings.TrimRight(name, cutset); trimmed != "" {
+		return trimmed
+	}
+	return name
+}
+
+// SpoolStdin copies the standard input to a temporary file, for readers that read the dataset more than
+// once. It returns the name of the file, with the extensions of the Stdio name, and a function that removes
+// it. Other names are returned as they are.
+func SpoolStdin(name string) (string, func(), error) {
+	if !IsStdio(name) {
+		return name, func() {}, nil
+	}
+	file, err := os.CreateTemp("", "stdin-*"+strings.TrimPrefix(name, Stdio))
+	if err != nil {
+		return "", nil, err
+	}
+	fmt.Printf("Copying stdin to %v, as the dataset is read more than once\n", file.Name())
+	if _, err = io.Copy(file, os.Stdin); err == nil {
+		err = file.Close()
+	} else {
+		file.Close()
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", nil, err
+	}
+	return file.Name(), func() { os.Remove(file.Name()) }, nil
+}
+
+// isPattern checks whether the name is a glob pattern instead of the name of an existing file
+func isPattern(name string) bool {
+	if !strings.ContainsAny(name, "*?[") {
+		return false
+	}
+	_, err := os.Stat(name)
+	return os.IsNotExist(err)
+}
+
+// openDatasetFile opens the file with its bytes counted by the progress bar, and decompresses it if its
+// extension is of a compression.
+func openDatasetFile(fileName string, bar *pb.ProgressBar) (io.ReadCloser, *os.File, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var reader io.ReadCloser = bar.NewProxyReader(file)
+	if compression, ok := compressions[filepath.Ext(fileName)]; ok {
+		if reader, err = compression.NewReader(reader); err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("%v: %v", fileName, err)
+		}
+	}
+	return reader, file, nil
+}
+
+// newProgressBar starts a progress bar over the given number of bytes
+func newProgressBar(size int64, output io.Writer) *pb.ProgressBar {
+	bar := pb.New64(size).SetUnits(pb.U_BYTES).SetRefreshRate(500 * time.Millisecond)
+	bar.Output = output
+	bar.ShowElapsedTime = true
+	bar.ShowSpeed = true
+	return bar.Start()
+}
+
+// multiFileReader reads the files of a dataset one after another, with a progress bar over all of them.
+// Each file is opened when the one before is read. A newline is added after files without a newline at
+// the end, so that their last line does not run into the first one of the next file. With eachFile, the
+// files are not joined: the end of each file is returned as io.EOF, and nextFile continues with the next.
+type multiFileReader struct {
+	files    []string
+	bar      *pb.ProgressBar
+	current  io.ReadCloser
+	file     *os.File
+	lastByte byte
+	eachFile bool // return io.EOF at the end of each file
+	atEnd    bool // the current file has ended, with eachFile
+}
+
+func newMultiFileReader(name string, files []string, messages io.Writer) (*multiFileReader, error) {
+	var size int64
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		size += stat.Size()
+	}
+	fmt.Fprintf(messages, "Reading data from %v files of '%v'. Progress w.r.t. on-disk-size: \n", len(files), name)
+	return &multiFileReader{files: files, bar: newProgressBar(size, messages), lastByte: '\n'}, nil
+}
+
+func (r *multiFileReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.current == nil {
+			if len(r.files) == 0 || r.atEnd {
+				return 0, io.EOF
+			}
+			if r.lastByte != '\n' && len(p) > 0 && !r.eachFile {
+				p[0], r.lastByte = '\n', '\n'
+				return 1, nil
+			}
+			if r.current, r.file, err = openDatasetFile(r.files[0], r.bar); err != nil {
+				return 0, err
+			}
+			r.files = r.files[1:]
+		}
+
+		n, err = r.current.Read(p)
+		if n > 0 {
+			r.lastByte = p[n-1]
+		}
+		if err == io.EOF {
+			r.atEnd = r.eachFile
+			if err = r.closeCurrent(); n == 0 && err == nil {
+				continue
+			}
+		}
+		return n, err
+	}
+}
+
+// nextFile continues with the next file once the current one has ended, with eachFile. It returns the name
+// of the next file, or false if there is none.
+func (r *multiFileReader) nextFile() (string, bool) {
+	if len(r.files) == 0 {
+		return "", false
+	}
+	r.atEnd = false
+	return r.files[0], true
+}
+
+// closeCurrent closes the reader of the current file and the file, which the reader has closed already
+// if the file is not compressed
+func (r *multiFileReader) closeCurrent() error {
+	err := r.current.Close()
+	r.file.Close()
+	r.current, r.file = nil, nil
+	return err
+}
+
+func (r *multiFileReader) Close() (err error) {
+	r.bar.Finish()
+	if r.current != nil {
+		err = r.closeCurrent()
+	}
+	r.files = nil
+	return
+}
@@ -0,0 +1,79 @@
+package io
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dump")
+	os.Mkdir(dir, 0755)
+	os.Mkdir(filepath.Join(dir, "sub.nt"), 0755)
+	files := map[string]string{
+		"part-2.nt":    "<http://example/s2> <http://example/p> <http://example/o> .", // no newline at the end
+		"part-1.nt":    "<http://example/s1> <http://example/p> <http://example/o> .\n",
+		".hidden.nt":   "<http://example/hidden> <http://example/p> <http://example/o> .\n",
+		"README.md":    "not a dataset",
+		"part-3.nt.gz": "<http://example/s3> <http://example/p> <http://example/o> .\n",
+	}
+	for name, data := range files {
+		writer, err := CreateWriter(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write([]byte(data))
+		writer.Close()
+	}
+
+	found, err := DatasetFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "part-1.nt"), filepath.Join(dir, "part-2.nt"), filepath.Join(dir, "part-3.nt.gz")}, found)
+
+	found, err = DatasetFiles(filepath.Join(dir, "part-[12].nt"))
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	_, err = DatasetFiles(filepath.Join(dir, "*.nq"))
+	assert.Error(t, err)
+
+	// the files are read one after another, with a newline between them
+	reader, err := UniversalReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, files["part-1.nt"]+files["part-2.nt"]+"\n"+files["part-3.nt.gz"], string(data))
+
+	parser, err := NewTripleParser(filepath.Join(dir, "part-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subjects []string
+	for trip, err := parser.NextTriple(1); trip != nil; trip, err = parser.NextTriple(1) {
+		assert.NoError(t, err)
+		subjects = append(subjects, string(trip.Subject))
+	}
+	parser.Close()
+	assert.Equal(t, []string{"<http://example/s1>", "<http://example/s2>", "<http://example/s3>"}, subjects)
+
+	// the syntax of the files must be the same
+	os.WriteFile(filepath.Join(dir, "part-4.nq"), []byte{}, 0644)
+	assert.Equal(t, NTriples, SyntaxOf(dir))
+	_, err = NewTripleParser(dir)
+	assert.Error(t, err)
+}
+
+func TestDatasetName(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, "dump.nt.gz", DatasetName("dump.nt.gz"))
+	assert.Equal(t, filepath.Join(dir, "dump"), DatasetName(filepath.Join(dir, "dump")+"/"))
+	assert.Equal(t, dir, DatasetName(filepath.Join(dir, "*.nt.gz")))
+	assert.Equal(t, filepath.Join(dir, "part"), DatasetName(filepath.Join(dir, "part-*.nt.gz")))
+	assert.Equal(t, filepath.Join(dir, "part"), TrimExtensions(filepath.Join(dir, "part-*.nt.gz")))
+}
@@ -1,65 +1,138 @@
 package io
 
 import (
+	"bufio"
 	"compress/bzip2"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/biogo/hts/bgzf"
+	"github.com/klauspost/compress/zstd"
 	gzip "github.com/klauspost/pgzip"
+	"github.com/ulikunitz/xz"
 	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
-// UniversalReader opens a file for read mode. It is able to automatically decompress
-// files that use either GZ, BGZ or BZ2. It will also display a loading bar on stdout.
+// Compression reads and writes files of a compression format.
+type Compression struct {
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	NewWriter func(w io.Writer) (io.WriteCloser, error) // nil if the format can only be read
+}
+
+// compressions maps the compression extensions to their formats
+var compressions = map[string]Compression{
+	".gz": {
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReaderN(r, 8*1024*1024, 48) }, // readahead
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriterLevel(w, gzip.BestCompression) },
+	},
+	".bgz": {
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return bgzf.NewReader(r, 0) },
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return bgzf.NewWriter(w, 0), nil },
+	},
+	".bz2": {
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return ioutil.NopCloser(bzip2.NewReader(r)), nil },
+	},
+	".zst": {
+		NewReader: newZstdReader,
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+	},
+	".zstd": {
+		NewReader: newZstdReader,
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+	},
+	".xz": {
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			reader, err := xz.NewReader(r)
+			return ioutil.NopCloser(reader), err
+		},
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) },
+	},
+}
+
+func newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
+
+// RegisterCompression makes UniversalReader and CreateWriter use the compression for files with the
+// extension, e.g. ".lz4". It replaces the compression registered before for the extension.
+func RegisterCompression(ext string, compression Compression) {
+	compressions[ext] = compression
+}
+
+// CheckCompression returns an error unless CreateWriter can compress files with the extension, e.g. ".zst".
+func CheckCompression(ext string) error {
+	if compression, ok := compressions[ext]; ok && compression.NewWriter != nil {
+		return nil
+	}
+	var known []string
+	for knownExt, compression := range compressions {
+		if compression.NewWriter != nil {
+			known = append(known, strings.TrimPrefix(knownExt, "."))
+		}
+	}
+	sort.Strings(known)
+	return fmt.Errorf("cannot write files compressed as %v, only as %v", strings.TrimPrefix(ext, "."), strings.Join(known, ", "))
+}
+
+// Stdio is the file name that stands for the standard input of UniversalReader and the standard output of
+// CreateWriter. Extensions after it give the syntax and compression of the stream, e.g. "-.nq.gz".
+const Stdio = "-"
+
+// IsStdio checks whether the file name stands for the standard input or output, see Stdio.
+func IsStdio(fileName string) bool {
+	return fileName == Stdio || strings.HasPrefix(fileName, Stdio+".")
+}
+
+// UniversalReader opens a file for read mode. It is able to automatically decompress files that use
+// either GZ, BGZ, BZ2, ZST or XZ, or a compression added by RegisterCompression. It will also display a
+// loading bar on stdout. Directories and glob patterns are read as the concatenation of their files, see
+// DatasetFiles, and Stdio reads the standard input.
 func UniversalReader(fileName string) (reader io.ReadCloser, err error) {
-	if stat, _ := os.Stdin.Stat(); (stat.Mode() & os.ModeCharDevice) == 0 {
-		fmt.Println("Reading data from stdin")
-		reader = os.Stdin
+	return UniversalReaderWithMessages(fileName, os.Stdout)
+}
+
+// UniversalReaderWithMessages opens a file like UniversalReader, but displays the messages and the loading
+// bar on `messages`, e.g. on stderr if the data is written to stdout.
+func UniversalReaderWithMessages(fileName string, messages io.Writer) (reader io.ReadCloser, err error) {
+	if IsStdio(fileName) {
+		fmt.Fprintln(messages, "Reading data from stdin")
+		reader = ioutil.NopCloser(os.Stdin)
+		if compression, ok := compressions[filepath.Ext(fileName)]; ok {
+			reader, err = compression.NewReader(reader)
+		}
 	} else {
-		var file *os.File
-		file, err = os.Open(fileName)
+		var files []string
+		files, err = DatasetFiles(fileName)
 		if err != nil {
 			return
 		}
+		if len(files) > 1 || files[0] != fileName {
+			return newMultiFileReader(fileName, files, messages)
+		}
 
 		var stat os.FileInfo
-		stat, err = file.Stat()
+		stat, err = os.Stat(fileName)
 		if err != nil {
 			return
 		}
-		if stat.IsDir() {
-			err = errors.New("Reading entire directories is not yet possible")
-			return
-		}
-		fmt.Printf("Reading data from file '%v'. Progress w.r.t. on-disk-size: \n", fileName)
-
-		// create and start progress bar
-		bar := pb.New(int(stat.Size())).SetUnits(pb.U_BYTES).SetRefreshRate(500 * time.Millisecond).Start()
-		bar.ShowElapsedTime = true
-		bar.ShowSpeed = true
-		reader = bar.NewProxyReader(file)
-
-		// decompress stream if applicable
-		switch ext := filepath.Ext(fileName); ext {
-		case ".bz2":
-			reader = ioutil.NopCloser(bzip2.NewReader(reader)) // Decompression
-		case ".gz":
-			reader, err = gzip.NewReaderN(reader, 8*1024*1024, 48) // readahead
-		case ".bgz":
-			reader, err = bgzf.NewReader(reader, 0)
-			//case ".zst", ".zstd":
-			//reader = releaseCloser{gozstd.NewReader(reader)}
-		}
+		fmt.Fprintf(messages, "Reading data from file '%v'. Progress w.r.t. on-disk-size: \n", fileName)
 
+		// create and start progress bar, and decompress stream if applicable
+		bar := newProgressBar(stat.Size(), messages)
+		var file *os.File
+		reader, file, err = openDatasetFile(fileName, bar)
 		if err != nil {
+			bar.Finish()
 			return
 		}
 
@@ -126,23 +199,130 @@ func CreateAndOpenWithGzip(filePath string) io.WriteCloser {
 	return GZipWriteCloser{gzipHandle: gzipw, fileHandle: fileh}
 }
 
+// CreateWriter creates the file and returns a writer that compresses the data according to the extension
+// of the file, see RegisterCompression. Files without a compression extension are written as they are.
+// Closing the writer closes the file. The name Stdio writes to the standard output instead, which is not
+// closed.
+func CreateWriter(filePath string) (io.WriteCloser, error) {
+	var newWriter func(w io.Writer) (io.WriteCloser, error)
+	ext := filepath.Ext(filePath)
+	if compression, ok := compressions[ext]; ok {
+		if compression.NewWriter == nil {
+			return nil, fmt.Errorf("cannot write files compressed as %v", ext)
+		}
+		newWriter = compression.NewWriter
+	} else {
+		newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return flushCloser{bufio.NewWriterSize(w, 4*1024*1024)}, nil
+		}
+	}
+
+	if IsStdio(filePath) {
+		return newWriter(os.Stdout)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := newWriter(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return fileWriteCloser{writer, file}, nil
+}
+
+// fileWriteCloser closes the file after the writer
+type fileWriteCloser struct {
+	io.WriteCloser
+	file *os.File
+}
+
+func (w fileWriteCloser) Close() error {
+	err := w.WriteCloser.Close()
+	if fileErr := w.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
+
+// flushCloser flushes a buffered writer on Close
+type flushCloser struct {
+	*bufio.Writer
+}
+
+func (w flushCloser) Close() error {
+	return w.Flush()
+}
+
+// Syntax is the RDF serialization of a document.
+type Syntax int
+
+// RDF serializations, recognized by their data format extension.
+const (
+	NTriples     Syntax = iota // .nt, and files without a known data format extension
+	NQuads                     // .nq
+	Turtle                     // .ttl
+	TriG                       // .trig
+	WikidataJSON               // .json, read by EntityReader
+	HDT                        // .hdt
+)
+
+// syntaxExtensions maps the data format extensions to the syntaxes
+var syntaxExtensions = map[string]Syntax{".nt": NTriples, ".nq": NQuads, ".ttl": Turtle, ".trig": TriG, ".json": WikidataJSON, ".hdt": HDT}
+
 // TrimExtensions will remove the extension of a fileName if it resembles an extension used by compression
-// algorithms (.gz, .bz2, .gbz). Then it will remove the Data format extension (.nt) if it follows next.
+// algorithms (.gz, .bz2, .bgz, .zst, .xz, ...). Then it will remove the Data format extension (.nt, .nq, .ttl, .trig, .json or .hdt)
+// if it follows next. Directories and glob patterns are reduced to their DatasetName first.
 func TrimExtensions(fileName string) (fileBase string) {
 	var base, ext string
-	base = fileName
+	base = DatasetName(fileName)
 
 	// Try to remove a compression extension.
 	ext = filepath.Ext(base)
-	if ext == ".bz2" || ext == ".gz" || ext == ".gbz" {
+	if isCompressionExtension(ext) {
 		base = strings.TrimSuffix(base, ext)
 	}
 
 	// Try to remove a data format extension.
 	ext = filepath.Ext(base)
-	if ext == ".nt" {
+	if _, ok := syntaxExtensions[ext]; ok {
 		base = strings.TrimSuffix(base, ext)
 	}
 
 	return base
 }
+
+// SyntaxOf returns the syntax of the file by its data format extension. A compression extension after it
+// is skipped. Directories and glob patterns without a data format extension have the syntax of their first
+// file.
+func SyntaxOf(fileName string) Syntax {
+	ext := filepath.Ext(fileName)
+	if isCompressionExtension(ext) {
+		ext = filepath.Ext(strings.TrimSuffix(fileName, ext))
+	}
+	if syntax, ok := syntaxExtensions[ext]; ok {
+		return syntax
+	}
+	if files, err := DatasetFiles(fileName); err == nil && files[0] != fileName {
+		return SyntaxOf(files[0])
+	}
+	return NTriples
+}
+
+// DataExtension returns the extension of line-based files derived from the file: ".nq" for documents
+// with graphs (N-Quads and TriG) and ".nt" otherwise. TripleParser returns the statements of all
+// syntaxes as lines of these formats.
+func DataExtension(fileName string) string {
+	if syntax := SyntaxOf(fileName); syntax == NQuads || syntax == TriG {
+		return ".nq"
+	}
+	return ".nt"
+}
+
+// isCompressionExtension checks whether the extension is one of a compression algorithm
+func isCompressionExtension(ext string) bool {
+	_, ok := compressions[ext]
+	return ok || ext == ".gbz"
+}
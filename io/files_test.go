@@ -0,0 +1,97 @@
+package io
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressions(t *testing.T) {
+	data := "<http://example/s> <http://example/p> <http://example/o> .\n"
+	for _, ext := range []string{"", ".gz", ".bgz", ".zst", ".zstd", ".xz"} {
+		path := filepath.Join(t.TempDir(), "test.nt"+ext)
+		writer, err := CreateWriter(path)
+		if !assert.NoError(t, err, ext) {
+			continue
+		}
+		writer.Write([]byte(data))
+		assert.NoError(t, writer.Close(), ext)
+
+		reader, err := UniversalReader(path)
+		if !assert.NoError(t, err, ext) {
+			continue
+		}
+		read, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err, ext)
+		assert.Equal(t, data, string(read), ext)
+		reader.Close()
+
+		assert.Equal(t, NTriples, SyntaxOf(path), ext)
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "test"), TrimExtensions(path), ext)
+	}
+
+	_, err := CreateWriter(filepath.Join(t.TempDir(), "test.nt.bz2"))
+	assert.Error(t, err)
+
+	assert.NoError(t, CheckCompression(".zst"))
+	assert.Error(t, CheckCompression(".bz2"))
+	assert.EqualError(t, CheckCompression(".lz4"), "cannot write files compressed as lz4, only as bgz, gz, xz, zst, zstd")
+}
+
+func TestStdio(t *testing.T) {
+	assert.True(t, IsStdio("-"))
+	assert.True(t, IsStdio("-.nq.gz"))
+	assert.False(t, IsStdio("-dump.nt"))
+	assert.Equal(t, NQuads, SyntaxOf("-.nq.gz"))
+	assert.Equal(t, "stdin", TrimExtensions("-.nq.gz"))
+
+	// write compressed data to stdout
+	path := filepath.Join(t.TempDir(), "stdio")
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+	os.Stdout, _ = os.Create(path)
+	data := "<http://example/s> <http://example/p> <http://example/o> .\n"
+	writer, err := CreateWriter("-.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write([]byte(data))
+	assert.NoError(t, writer.Close())
+	os.Stdout.Close()
+	os.Stdout = stdout
+
+	// read it from stdin
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	os.Stdin, _ = os.Open(path)
+	reader, err := UniversalReader("-.nt.zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(read))
+	reader.Close()
+	os.Stdin.Close()
+
+	// a copy of stdin can be read again
+	os.Stdin, _ = os.Open(path)
+	spooled, remove, err := SpoolStdin("-.nt.zst")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(spooled, ".nt.zst"))
+	parser, err := NewTripleParser(spooled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trip, err := parser.NextTriple()
+	assert.NoError(t, err)
+	assert.Equal(t, "<http://example/s>", string(trip.Subject))
+	parser.Close()
+	remove()
+	_, err = os.Stat(spooled)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,45 @@
+package io
+
+// DefaultGraph is the name by which a GraphFilter refers to the default graph, i.e. to N-Quads statements
+// without graph term and to all statements of N-Triples documents. It cannot clash with IRIs, which always
+// have a scheme.
+const DefaultGraph = "default"
+
+// GraphFilter selects the statements of an N-Quads document by their graph. Graphs are given as IRIs
+// without angle brackets, blank nodes as `_:label` and the default graph as DefaultGraph.
+// A nil filter selects all statements.
+type GraphFilter struct {
+	include map[string]bool // if not empty, only these graphs are selected
+	exclude map[string]bool
+}
+
+// NewGraphFilter returns a filter that selects the statements of the included graphs, or of all graphs
+// if none are included, except those of the excluded graphs. Without any graphs it returns nil.
+func NewGraphFilter(include, exclude []string) *GraphFilter {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+
+	f := &GraphFilter{include: map[string]bool{}, exclude: map[string]bool{}}
+	for _, graph := range include {
+		f.include[graph] = true
+	}
+	for _, graph := range exclude {
+		f.exclude[graph] = true
+	}
+	return f
+}
+
+// Matches checks whether the statements of a graph are selected. The graph is a term as returned by
+// ParseQuadTerms, nil for the default graph.
+func (f *GraphFilter) Matches(graph []byte) bool {
+	if f == nil {
+		return true
+	}
+
+	name := DefaultGraph
+	if graph != nil {
+		name = string(IRIValue(graph))
+	}
+	return (len(f.include) == 0 || f.include[name]) && !f.exclude[name]
+}
@@ -0,0 +1,579 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "gopkg.in/cheggaaa/pb.v1"
+)
+
+// This file implements a reader for HDT files (http://www.rdfhdt.org/hdt-binary-format/), the binary format
+// in which large datasets like LOD-a-lot are distributed. It supports the default HDT layout: a dictionary
+// of four plain front coded sections and bitmap triples in SPO order. The triples are iterated in that
+// order, i.e. grouped by subject, without decompressing the file.
+//
+// The subjects are decoded sequentially and the predicates are held in memory. Objects are looked up in
+// the file by their ID, so they are only decoded when requested, and optionally only for some predicates,
+// see decodeObjectsOf. The checksums of the file are not verified.
+
+// formats and types of the parts of an HDT file
+const (
+	hdtCookie           = "$HDT"
+	hdtGlobal           = 1
+	hdtHeader           = 2
+	hdtDictionary       = 3
+	hdtTriples          = 4
+	hdtDictionaryFour   = "<http://purl.org/HDT/hdt#dictionaryFour>"
+	hdtTriplesBitmap    = "<http://purl.org/HDT/hdt#triplesBitmap>"
+	hdtOrderSPO         = "1"
+	hdtSectionPFC       = 2
+	hdtSequenceLog      = 1
+	hdtBitmapPlain      = 1
+	hdtObjectCacheLimit = 1 << 16
+)
+
+// hdtReader iterates the triples of an HDT file in SPO order.
+type hdtReader struct {
+	file       *os.File
+	bar        *pb.ProgressBar
+	shared     *pfcSection // terms that are subjects and objects, IDs 1 to shared.numStrings
+	objects    *pfcSection // terms that are only objects
+	predicates [][]byte    // predicate terms by ID - 1
+	objectTerm map[uint64][]byte
+	objectsOf  []bool // whether the objects of a predicate, by ID - 1, are decoded; nil decodes all
+
+	// iteration state
+	subjects     *pfcIterator // shared terms, followed by the terms that are only subjects
+	bitmapY      *bitReader   // 1 marks the last predicate of a subject
+	bitmapZ      *bitReader   // 1 marks the last object of a subject and predicate
+	sequenceY    *bitReader   // predicate IDs
+	sequenceZ    *bitReader   // object IDs
+	bitsY, bitsZ uint
+	subject      []byte // current subject term
+	predicate    []byte // current predicate term
+	predicateID  uint64
+	nextSubject  bool // the next triple starts a new subject
+	nextPred     bool // the next triple starts a new predicate
+	line         []byte
+	numTriples   uint64
+	read         uint64 // number of triples read
+}
+
+// newHDTReader opens an HDT file and reads its dictionary and triples sections. The progress is displayed
+// on `messages`.
+func newHDTReader(fileName string, messages io.Writer) (*hdtReader, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	r := &hdtReader{file: file, objectTerm: map[uint64][]byte{}, nextSubject: true, nextPred: true}
+	if err = r.open(messages); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("invalid HDT file %v: %v", fileName, err)
+	}
+	return r, nil
+}
+
+// open reads the structure of the file. The data of the sections is read later.
+func (r *hdtReader) open(messages io.Writer) (err error) {
+	in := &hdtInput{file: r.file}
+	in.skip(0)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			failure, ok := recovered.(hdtFailure)
+			if !ok {
+				panic(recovered)
+			}
+			err = failure.err
+		}
+	}()
+
+	// global information and header, which is skipped
+	in.controlInformation(hdtGlobal)
+	_, properties := in.controlInformation(hdtHeader)
+	length, _ := strconv.ParseInt(properties["length"], 10, 64)
+	in.skip(length)
+
+	// dictionary
+	format, _ := in.controlInformation(hdtDictionary)
+	if format != hdtDictionaryFour {
+		in.fail("unsupported dictionary %v", format)
+	}
+	r.shared = in.pfcSection()
+	subjects := in.pfcSection()
+	predicates := in.pfcSection()
+	r.objects = in.pfcSection()
+
+	// triples
+	format, properties = in.controlInformation(hdtTriples)
+	if format != hdtTriplesBitmap || properties["order"] != hdtOrderSPO {
+		in.fail("unsupported triples %v in order %v, only bitmap triples in SPO order are supported", format, properties["order"])
+	}
+	bitmapY, _ := in.bitmap()
+	bitmapZ, numTriples := in.bitmap()
+	sequenceY, bitsY := in.sequence()
+	sequenceZ, bitsZ := in.sequence()
+
+	// load the predicates and start the iterators
+	it := predicates.iterator(r.file, nil)
+	for i := uint64(0); i < predicates.numStrings; i++ {
+		term, err := it.next()
+		if err != nil {
+			return err
+		}
+		r.predicates = append(r.predicates, hdtTerm(nil, term))
+	}
+	r.subjects = r.shared.iterator(r.file, subjects)
+	r.bitmapY, r.bitmapZ = r.bitReader(bitmapY), r.bitReader(bitmapZ)
+	r.sequenceY, r.sequenceZ = r.bitReader(sequenceY), r.bitReader(sequenceZ)
+	r.bitsY, r.bitsZ = bitsY, bitsZ
+	r.numTriples = numTriples
+
+	fmt.Fprintf(messages, "Reading %v triples from HDT file '%v':\n", numTriples, r.file.Name())
+	r.bar = pb.New64(int64(numTriples)).SetRefreshRate(500 * time.Millisecond)
+	r.bar.Output = messages
+	r.bar.ShowElapsedTime = true
+	r.bar.ShowSpeed = true
+	r.bar.Start()
+	return nil
+}
+
+// next returns the next triple, or io.EOF after the last one. The object is only decoded if numTerms is 3
+// and decodeObjectsOf selects the predicate; otherwise the object and the line are nil.
+// The terms and the line are in N-Triples syntax and only valid until the next call.
+func (r *hdtReader) next(numTerms int) (terms [4][]byte, line []byte, err error) {
+	if r.read == r.numTriples {
+		r.bar.Finish()
+		return terms, nil, io.EOF
+	}
+
+	if r.nextPred {
+		var id, last uint64
+		if id, err = r.sequenceY.read(r.bitsY); err != nil {
+			return terms, nil, unexpected(err)
+		}
+		if id == 0 || id > uint64(len(r.predicates)) {
+			return terms, nil, fmt.Errorf("invalid predicate ID %v in HDT file", id)
+		}
+		r.predicate, r.predicateID = r.predicates[id-1], id
+		if r.nextSubject {
+			var subject []byte
+			if subject, err = r.subjects.next(); err != nil {
+				return terms, nil, fmt.Errorf("invalid subject in HDT file: %v", err)
+			}
+			r.subject = hdtTerm(r.subject[:0], subject)
+		}
+		if last, err = r.bitmapY.read(1); err != nil {
+			return terms, nil, unexpected(err)
+		}
+		r.nextSubject = last == 1
+	}
+
+	objectID, err := r.sequenceZ.read(r.bitsZ)
+	if err != nil {
+		return terms, nil, unexpected(err)
+	}
+	last, err := r.bitmapZ.read(1)
+	if err != nil {
+		return terms, nil, unexpected(err)
+	}
+	r.nextPred = last == 1
+	r.read++
+	r.bar.Increment()
+
+	terms[0], terms[1] = r.subject, r.predicate
+	if numTerms < 3 || r.objectsOf != nil && !r.objectsOf[r.predicateID-1] {
+		return terms, nil, nil
+	}
+	if terms[2], err = r.object(objectID); err != nil {
+		return
+	}
+
+	r.line = append(r.line[:0], terms[0]...)
+	r.line = append(append(r.line, ' '), terms[1]...)
+	r.line = append(append(r.line, ' '), terms[2]...)
+	r.line = append(r.line, " ."...)
+	return terms, r.line, nil
+}
+
+// decodeObjectsOf restricts the decoded objects to the statements with the given predicates, in N-Triples
+// syntax. Looking up objects by their ID takes a random read each, which is not worth it for objects that are
+// not used, e.g. those of other statements than type statements.
+func (r *hdtReader) decodeObjectsOf(predicates [][]byte) {
+	r.objectsOf = make([]bool, len(r.predicates))
+	for i, term := range r.predicates {
+		for _, predicate := range predicates {
+			if bytes.Equal(term, predicate) {
+				r.objectsOf[i] = true
+			}
+		}
+	}
+}
+
+// object returns the term of an object ID. The terms of recent objects are cached, as types and other
+// frequent objects are looked up many times.
+func (r *hdtReader) object(id uint64) ([]byte, error) {
+	if term, ok := r.objectTerm[id]; ok {
+		return term, nil
+	}
+
+	var term []byte
+	var err error
+	if id <= r.shared.numStrings {
+		term, err = r.shared.get(r.file, id)
+	} else {
+		term, err = r.objects.get(r.file, id-r.shared.numStrings)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid object ID %v in HDT file: %v", id, err)
+	}
+
+	if len(r.objectTerm) >= hdtObjectCacheLimit {
+		r.objectTerm = map[uint64][]byte{}
+	}
+	term = hdtTerm(nil, term)
+	r.objectTerm[id] = term
+	return term, nil
+}
+
+// Close the file.
+func (r *hdtReader) Close() error {
+	return r.file.Close()
+}
+
+func (r *hdtReader) bitReader(data hdtData) *bitReader {
+	return &bitReader{reader: bufio.NewReaderSize(io.NewSectionReader(r.file, data.offset, data.length), 1024*1024)}
+}
+
+// unexpected reports the end of a section before the end of the triples
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// hdtTerm appends the term in N-Triples syntax: HDT stores IRIs without angle brackets and literals with
+// unescaped lexical forms.
+func hdtTerm(buffer []byte, term []byte) []byte {
+	switch {
+	case bytes.HasPrefix(term, []byte("_:")):
+		return append(buffer, term...)
+	case bytes.HasPrefix(term, []byte(`"`)):
+		end := bytes.LastIndexByte(term, '"')
+		if end == 0 { // malformed, keep everything as lexical form
+			end = len(term)
+		}
+		buffer = append(buffer, '"')
+		buffer = append(buffer, escapeLiteral(string(term[1:end]))...)
+		buffer = append(buffer, '"')
+		if end < len(term) {
+			buffer = append(buffer, term[end+1:]...)
+		}
+		return buffer
+	default:
+		buffer = append(buffer, '<')
+		buffer = append(buffer, EscapeIRI(string(term))...)
+		return append(buffer, '>')
+	}
+}
+
+// hdtInput reads the structure of an HDT file sequentially, skipping the data of the sections
+type hdtInput struct {
+	file   *os.File
+	reader *bufio.Reader
+	offset int64
+}
+
+// hdtFailure carries errors while reading the structure of an HDT file
+type hdtFailure struct{ err error }
+
+// hdtData is the position of a section in the file
+type hdtData struct {
+	offset, length int64
+}
+
+func (in *hdtInput) fail(format string, args ...interface{}) {
+	panic(hdtFailure{fmt.Errorf(format, args...)})
+}
+
+func (in *hdtInput) byte() byte {
+	c, err := in.reader.ReadByte()
+	if err != nil {
+		panic(hdtFailure{unexpected(err)})
+	}
+	in.offset++
+	return c
+}
+
+// skip continues reading n bytes further
+func (in *hdtInput) skip(n int64) {
+	in.offset += n
+	in.reader = bufio.NewReader(io.NewSectionReader(in.file, in.offset, 1<<62))
+}
+
+// vbyte reads a variable-length number, of which the last byte has the highest bit set
+func (in *hdtInput) vbyte() uint64 {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		c := in.byte()
+		value |= uint64(c&0x7F) << shift
+		if c&0x80 != 0 {
+			return value
+		}
+	}
+	in.fail("invalid number at offset %v", in.offset)
+	return 0
+}
+
+func (in *hdtInput) cString() string {
+	s, err := in.reader.ReadString(0)
+	if err != nil {
+		panic(hdtFailure{unexpected(err)})
+	}
+	in.offset += int64(len(s))
+	return s[:len(s)-1]
+}
+
+// controlInformation reads the control information in front of each part of the file
+func (in *hdtInput) controlInformation(expectedType byte) (format string, properties map[string]string) {
+	cookie := string([]byte{in.byte(), in.byte(), in.byte(), in.byte()})
+	if cookie != hdtCookie {
+		in.fail("missing control information at offset %v", in.offset-4)
+	}
+	if typ := in.byte(); typ != expectedType {
+		in.fail("expected control information of type %v, found %v", expectedType, typ)
+	}
+	format = in.cString()
+	properties = map[string]string{}
+	for _, property := range strings.Split(in.cString(), ";") {
+		if key, value, ok := strings.Cut(property, "="); ok {
+			properties[key] = value
+		}
+	}
+	in.skip(2) // CRC16
+	return
+}
+
+// pfcSection reads a dictionary section of plain front coded strings
+func (in *hdtInput) pfcSection() *pfcSection {
+	if typ := in.byte(); typ != hdtSectionPFC {
+		in.fail("unsupported dictionary section type %v", typ)
+	}
+	section := &pfcSection{numStrings: in.vbyte()}
+	length := int64(in.vbyte())
+	section.blockSize = in.vbyte()
+	in.skip(1) // CRC8
+	section.blocks = in.logArray()
+	section.text = hdtData{in.offset, length}
+	in.skip(length + 4) // text and CRC32
+	if section.blockSize == 0 {
+		in.fail("invalid block size 0")
+	}
+	return section
+}
+
+// sequence reads a log array and returns the position of its data and the number of bits per entry
+func (in *hdtInput) sequence() (hdtData, uint) {
+	array := in.logArray()
+	return array.data, array.numBits
+}
+
+// logArray reads the header of a log array, an array of integers with the same number of bits each
+func (in *hdtInput) logArray() logArray {
+	if typ := in.byte(); typ != hdtSequenceLog {
+		in.fail("unsupported sequence type %v", typ)
+	}
+	array := logArray{file: in.file, numBits: uint(in.byte())}
+	entries := in.vbyte()
+	in.skip(1) // CRC8
+	if array.numBits > 64 {
+		in.fail("invalid number of bits %v", array.numBits)
+	}
+	array.data = hdtData{in.offset, int64((uint64(array.numBits)*entries + 7) / 8)}
+	in.skip(array.data.length + 4) // data and CRC32
+	return array
+}
+
+// bitmap reads the header of a bitmap and returns the position of its data and the number of bits
+func (in *hdtInput) bitmap() (hdtData, uint64) {
+	if typ := in.byte(); typ != hdtBitmapPlain {
+		in.fail("unsupported bitmap type %v", typ)
+	}
+	numBits := in.vbyte()
+	in.skip(1) // CRC8
+	data := hdtData{in.offset, int64((numBits + 7) / 8)}
+	in.skip(data.length + 4) // data and CRC32
+	return data, numBits
+}
+
+// logArray allows random access to the entries of a log array in the file
+type logArray struct {
+	file    *os.File
+	data    hdtData
+	numBits uint
+}
+
+// get returns the entry with the index, starting at 0
+func (a logArray) get(index uint64) (uint64, error) {
+	position := index * uint64(a.numBits)
+	var buffer [16]byte
+	n, err := a.file.ReadAt(buffer[:9], a.data.offset+int64(position/8))
+	if n < int((uint64(a.numBits)+position%8+7)/8) {
+		return 0, unexpected(err)
+	}
+
+	low, high := uint64(0), uint64(buffer[8])
+	for i := 7; i >= 0; i-- {
+		low = low<<8 | uint64(buffer[i])
+	}
+	shift := position % 8
+	value := low>>shift | high<<(64-shift)
+	if a.numBits < 64 {
+		value &= 1<<a.numBits - 1
+	}
+	return value, nil
+}
+
+// bitReader reads the entries of a log array or the bits of a bitmap sequentially, least significant first
+type bitReader struct {
+	reader  *bufio.Reader
+	current byte
+	left    uint // bits left in current
+}
+
+func (b *bitReader) read(numBits uint) (value uint64, err error) {
+	for read := uint(0); read < numBits; {
+		if b.left == 0 {
+			if b.current, err = b.reader.ReadByte(); err != nil {
+				if read > 0 {
+					err = io.ErrUnexpectedEOF
+				}
+				return
+			}
+			b.left = 8
+		}
+		take := numBits - read
+		if take > b.left {
+			take = b.left
+		}
+		value |= uint64(b.current&byte(1<<take-1)) << read
+		b.current >>= take
+		b.left -= take
+		read += take
+	}
+	return
+}
+
+// pfcSection is a dictionary section of plain front coded strings: the strings are sorted and split into
+// blocks; the first string of each block is stored completely, the others as the length of the prefix they
+// share with the previous string and the rest.
+type pfcSection struct {
+	numStrings uint64
+	blockSize  uint64
+	blocks     logArray // offsets of the blocks in the text
+	text       hdtData
+}
+
+// get returns the string with the ID, starting at 1
+func (s *pfcSection) get(file *os.File, id uint64) ([]byte, error) {
+	if id == 0 || id > s.numStrings {
+		return nil, errors.New("ID out of range")
+	}
+	block := (id - 1) / s.blockSize
+	offset, err := s.blocks.get(block)
+	if err != nil {
+		return nil, err
+	}
+	if int64(offset) >= s.text.length {
+		return nil, errors.New("invalid block offset")
+	}
+
+	it := &pfcIterator{
+		section: s,
+		reader:  bufio.NewReaderSize(io.NewSectionReader(file, s.text.offset+int64(offset), s.text.length-int64(offset)), 4096),
+		index:   block * s.blockSize,
+	}
+	var str []byte
+	for i := block * s.blockSize; i < id; i++ {
+		if str, err = it.next(); err != nil {
+			return nil, err
+		}
+	}
+	return append([]byte(nil), str...), nil
+}
+
+// iterator returns an iterator over the strings of the section, followed by those of another section
+func (s *pfcSection) iterator(file *os.File, then *pfcSection) *pfcIterator {
+	it := &pfcIterator{
+		section: s,
+		reader:  bufio.NewReaderSize(io.NewSectionReader(file, s.text.offset, s.text.length), 1024*1024),
+	}
+	if then != nil {
+		it.then = then.iterator(file, nil)
+	}
+	return it
+}
+
+// pfcIterator decodes the strings of a section in order
+type pfcIterator struct {
+	section *pfcSection
+	reader  *bufio.Reader
+	index   uint64 // index of the next string in the section
+	last    []byte
+	then    *pfcIterator
+}
+
+// next returns the next string, which is only valid until the next call
+func (it *pfcIterator) next() ([]byte, error) {
+	if it.index >= it.section.numStrings {
+		if it.then != nil {
+			return it.then.next()
+		}
+		return nil, io.EOF
+	}
+
+	prefix := 0
+	if it.index%it.section.blockSize != 0 {
+		var value uint64
+		for shift := uint(0); ; shift += 7 {
+			c, err := it.reader.ReadByte()
+			if err != nil {
+				return nil, unexpected(err)
+			}
+			value |= uint64(c&0x7F) << shift
+			if c&0x80 != 0 {
+				break
+			} else if shift >= 63 {
+				return nil, errors.New("invalid prefix length")
+			}
+		}
+		if value > uint64(len(it.last)) {
+			return nil, errors.New("invalid prefix length")
+		}
+		prefix = int(value)
+	}
+
+	// the rest is terminated by a zero byte
+	it.last = it.last[:prefix]
+	for {
+		suffix, err := it.reader.ReadSlice(0)
+		if err == bufio.ErrBufferFull {
+			it.last = append(it.last, suffix...)
+			continue
+		} else if err != nil {
+			return nil, unexpected(err)
+		}
+		it.last = append(it.last, suffix[:len(suffix)-1]...)
+		break
+	}
+	it.index++
+	return it.last, nil
+}
@@ -0,0 +1,242 @@
+package io
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hdtWriter writes HDT files for the tests, with empty checksums
+type hdtWriter struct {
+	bytes.Buffer
+}
+
+func (w *hdtWriter) vbyte(value uint64) {
+	for value > 0x7F {
+		w.WriteByte(byte(value & 0x7F))
+		value >>= 7
+	}
+	w.WriteByte(byte(value | 0x80))
+}
+
+func (w *hdtWriter) controlInformation(typ byte, format, properties string) {
+	w.WriteString(hdtCookie)
+	w.WriteByte(typ)
+	w.WriteString(format + "\x00" + properties + "\x00")
+	w.Write([]byte{0, 0}) // CRC16
+}
+
+// bits packs the values with the same number of bits each, least significant first
+func (w *hdtWriter) bits(values []uint64, numBits uint) {
+	data := make([]byte, (uint(len(values))*numBits+7)/8)
+	for i, value := range values {
+		for b := uint(0); b < numBits; b++ {
+			if value&(1<<b) != 0 {
+				position := uint(i)*numBits + b
+				data[position/8] |= 1 << (position % 8)
+			}
+		}
+	}
+	w.Write(data)
+	w.Write([]byte{0, 0, 0, 0}) // CRC32
+}
+
+func (w *hdtWriter) logArray(values []uint64) {
+	numBits := uint(1)
+	for _, value := range values {
+		for value >= 1<<numBits {
+			numBits++
+		}
+	}
+	w.WriteByte(hdtSequenceLog)
+	w.WriteByte(byte(numBits))
+	w.vbyte(uint64(len(values)))
+	w.WriteByte(0) // CRC8
+	w.bits(values, numBits)
+}
+
+func (w *hdtWriter) bitmap(values []uint64) {
+	w.WriteByte(hdtBitmapPlain)
+	w.vbyte(uint64(len(values)))
+	w.WriteByte(0) // CRC8
+	w.bits(values, 1)
+}
+
+func (w *hdtWriter) pfcSection(strings []string, blockSize int) {
+	var text hdtWriter
+	var blocks []uint64
+	for i, s := range strings {
+		if i%blockSize == 0 {
+			blocks = append(blocks, uint64(text.Len()))
+			text.WriteString(s + "\x00")
+			continue
+		}
+		prefix := 0
+		for prefix < len(s) && prefix < len(strings[i-1]) && s[prefix] == strings[i-1][prefix] {
+			prefix++
+		}
+		text.vbyte(uint64(prefix))
+		text.WriteString(s[prefix:] + "\x00")
+	}
+	blocks = append(blocks, uint64(text.Len()))
+
+	w.WriteByte(hdtSectionPFC)
+	w.vbyte(uint64(len(strings)))
+	w.vbyte(uint64(text.Len()))
+	w.vbyte(uint64(blockSize))
+	w.WriteByte(0) // CRC8
+	w.logArray(blocks)
+	w.Write(text.Bytes())
+	w.Write([]byte{0, 0, 0, 0}) // CRC32
+}
+
+// writeHDT writes the triples, given as HDT strings, as HDT file with bitmap triples
+func writeHDT(t *testing.T, path string, triples [][3]string) {
+	subjects, predicates, objects := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, triple := range triples {
+		subjects[triple[0]], predicates[triple[1]], objects[triple[2]] = true, true, true
+	}
+	sorted := func(set map[string]bool, include func(string) bool) (list []string) {
+		for s := range set {
+			if include(s) {
+				list = append(list, s)
+			}
+		}
+		sort.Strings(list)
+		return
+	}
+	shared := sorted(subjects, func(s string) bool { return objects[s] })
+	onlySubjects := sorted(subjects, func(s string) bool { return !objects[s] })
+	onlyObjects := sorted(objects, func(s string) bool { return !subjects[s] })
+	predicateList := sorted(predicates, func(string) bool { return true })
+
+	ids := func(lists ...[]string) map[string]uint64 {
+		m := map[string]uint64{}
+		for _, list := range lists {
+			for _, s := range list {
+				m[s] = uint64(len(m) + 1)
+			}
+		}
+		return m
+	}
+	subjectIDs, predicateIDs, objectIDs := ids(shared, onlySubjects), ids(predicateList), ids(shared, onlyObjects)
+	sort.Slice(triples, func(i, j int) bool {
+		a, b := triples[i], triples[j]
+		if subjectIDs[a[0]] != subjectIDs[b[0]] {
+			return subjectIDs[a[0]] < subjectIDs[b[0]]
+		} else if predicateIDs[a[1]] != predicateIDs[b[1]] {
+			return predicateIDs[a[1]] < predicateIDs[b[1]]
+		}
+		return objectIDs[a[2]] < objectIDs[b[2]]
+	})
+
+	var seqY, seqZ, bitY, bitZ []uint64
+	for i, triple := range triples {
+		last := i == len(triples)-1
+		if i == 0 || triple[0] != triples[i-1][0] || triple[1] != triples[i-1][1] {
+			seqY = append(seqY, predicateIDs[triple[1]])
+			bitY = append(bitY, 0)
+		}
+		seqZ = append(seqZ, objectIDs[triple[2]])
+		if last || triple[0] != triples[i+1][0] || triple[1] != triples[i+1][1] {
+			bitZ = append(bitZ, 1)
+		} else {
+			bitZ = append(bitZ, 0)
+		}
+		if last || triple[0] != triples[i+1][0] {
+			bitY[len(bitY)-1] = 1
+		}
+	}
+
+	w := &hdtWriter{}
+	header := "<file:///data> <http://purl.org/HDT/hdt#triplesnumTriples> \"" + strconv.Itoa(len(triples)) + "\" .\n"
+	w.controlInformation(hdtGlobal, "<http://purl.org/HDT/hdt#HDTv1>", "")
+	w.controlInformation(hdtHeader, "ntriples", "length="+strconv.Itoa(len(header))+";")
+	w.WriteString(header)
+	w.controlInformation(hdtDictionary, hdtDictionaryFour, "mapping=1;")
+	w.pfcSection(shared, 2)
+	w.pfcSection(onlySubjects, 2)
+	w.pfcSection(predicateList, 2)
+	w.pfcSection(onlyObjects, 2)
+	w.controlInformation(hdtTriples, hdtTriplesBitmap, "order=1;")
+	w.bitmap(bitY)
+	w.bitmap(bitZ)
+	w.logArray(seqY)
+	w.logArray(seqZ)
+	if err := os.WriteFile(path, w.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHDT(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.hdt")
+	writeHDT(t, path, [][3]string{
+		{"http://ex/a", "http://ex/knows", "http://ex/b"},
+		{"http://ex/a", "http://ex/knows", "http://ex/c"},
+		{"http://ex/a", "http://www.w3.org/1999/02/22-rdf-syntax-ns#type", "http://ex/Person"},
+		{"http://ex/b", "http://ex/name", `"say "hi""@en`},
+		{"http://ex/b", "http://ex/age", `"42"^^<http://www.w3.org/2001/XMLSchema#integer>`},
+		{"_:b1", "http://ex/knows", "http://ex/a"},
+		{"http://ex/d", "http://ex/knows", "_:b1"},
+	})
+
+	parser, err := NewTripleParser(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for trip, err := parser.NextTriple(1); trip != nil; trip, err = parser.NextTriple(1) {
+		assert.NoError(t, err)
+		lines = append(lines, string(trip.Line))
+	}
+	parser.Close()
+	assert.Equal(t, []string{ // shared terms (subjects that are also objects) first
+		"_:b1 <http://ex/knows> <http://ex/a> .",
+		"<http://ex/a> <http://ex/knows> <http://ex/b> .",
+		"<http://ex/a> <http://ex/knows> <http://ex/c> .",
+		"<http://ex/a> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://ex/Person> .",
+		`<http://ex/b> <http://ex/age> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`<http://ex/b> <http://ex/name> "say \"hi\""@en .`,
+		"<http://ex/d> <http://ex/knows> _:b1 .",
+	}, lines)
+
+	// subjects and predicates only
+	parser, _ = NewTripleParser(path)
+	defer parser.Close()
+	var subjects []string
+	terms, err := parser.NextStatement(2)
+	for ; err == nil; terms, err = parser.NextStatement(2) {
+		assert.Nil(t, terms[2])
+		subjects = append(subjects, string(terms[0]))
+	}
+	assert.Equal(t, io.EOF, err)
+	assert.Len(t, subjects, 7)
+
+	// only the objects of type statements
+	parser, _ = NewTripleParser(path)
+	defer parser.Close()
+	parser.DecodeObjectsOf([]byte("<http://www.w3.org/1999/02/22-rdf-syntax-ns#type>"))
+	var objects []string
+	for terms, err = parser.NextStatement(3); err == nil; terms, err = parser.NextStatement(3) {
+		if terms[2] != nil {
+			objects = append(objects, string(terms[0])+" "+string(terms[2]))
+		}
+	}
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"<http://ex/a> <http://ex/Person>"}, objects)
+}
+
+func TestHDTInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.hdt")
+	if err := os.WriteFile(path, []byte("<http://ex/s> <http://ex/p> <http://ex/o> .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewTripleParser(path)
+	assert.Error(t, err)
+}
@@ -4,7 +4,8 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"unicode"
+	"os"
+	"strconv"
 	"unicode/utf8"
 )
 
@@ -15,40 +16,109 @@ type Triple struct { // TODO: Maybe I can use string instead of byte[]
 	Subject   []byte
 	Predicate []byte
 	Object    []byte
+	Graph     []byte // Graph term of N-Quads statements, nil for the default graph
 	Line      []byte // Holds the entire line including terminating dot (but no newline)
 }
 
 // TripleParser reads an internal file and produces triples from it.
+//
+// The syntax of the file is chosen by its extension, see SyntaxOf. Lines of N-Triples files are parsed
+// according to the N-Triples 1.1 grammar, and those of .nq files according to the N-Quads grammar. Invalid
+// lines are reported and skipped, unless the parser is Strict, in which case NextTriple returns a
+// *SyntaxError for them. Turtle and TriG documents are converted to N-Triples and N-Quads statements, see
+// turtle.go; syntax errors in them are always returned, as the parser cannot recover from them. The
+// triples of HDT files are read in SPO order, see hdt.go.
 type TripleParser struct {
-	reader  io.ReadCloser
-	scanner *bufio.Reader
+	Strict    bool         // return syntax errors instead of skipping invalid lines
+	Graphs    *GraphFilter // only return the statements of these graphs, nil returns all
+	reader    io.ReadCloser
+	scanner   *bufio.Reader
+	syntax    Syntax
+	turtle    *turtleParser    // parser of Turtle and TriG documents
+	documents *multiFileReader // the files of a Turtle or TriG dataset in several files, see nextDocument
+	numDocs   int              // number of the current document of them
+	hdt       *hdtReader       // reader of HDT files
+	line      int64            // number of the last line read
+	invalid   int64            // number of invalid lines skipped
+	messages  io.Writer        // where the progress and the skipped lines are reported
 }
 
+// maxReportedErrors is the number of skipped invalid lines that are reported individually
+const maxReportedErrors = 10
+
 // NewTripleParser opens a file and returns the relevant triple parser that will produce triples.
 func NewTripleParser(filePath string) (*TripleParser, error) {
+	return NewTripleParserWithMessages(filePath, os.Stdout)
+}
+
+// NewTripleParserWithMessages returns a triple parser like NewTripleParser, which reports the progress and the
+// skipped lines on `messages` instead of stdout.
+func NewTripleParserWithMessages(filePath string, messages io.Writer) (*TripleParser, error) {
+
+	syntax := SyntaxOf(filePath)
+	if syntax == WikidataJSON {
+		return nil, fmt.Errorf("%v is a Wikidata JSON dump, which consists of entities instead of statements", filePath)
+	}
+
+	// the files of directories and glob patterns are read as one stream, so they need the same syntax
+	files, _ := DatasetFiles(filePath) // missing files are reported by UniversalReader
+	for _, file := range files {
+		if SyntaxOf(file) != syntax {
+			return nil, fmt.Errorf("%v contains files of different syntaxes: %v and %v", filePath, files[0], file)
+		}
+	}
+
+	// HDT files are not read as a stream
+	if syntax == HDT {
+		if IsStdio(filePath) {
+			return nil, fmt.Errorf("HDT files cannot be read from stdin")
+		}
+		if len(files) > 1 || (len(files) == 1 && files[0] != filePath) {
+			return nil, fmt.Errorf("%v: HDT files can only be read one by one", filePath)
+		}
+		hdt, err := newHDTReader(filePath, messages)
+		if err != nil {
+			return nil, err
+		}
+		return &TripleParser{syntax: syntax, hdt: hdt, messages: messages}, nil
+	}
 
 	// IO setup
-	reader, err := UniversalReader(filePath)
+	reader, err := UniversalReaderWithMessages(filePath, messages)
 	if err != nil {
 		return nil, err
 	}
-	scanner := bufio.NewReaderSize(reader, 4*1024*1024) // 4MB line Buffer
 
-	return &TripleParser{reader, scanner}, nil
+	tp := &TripleParser{reader: reader, syntax: syntax, messages: messages}
+	if tp.syntax == Turtle || tp.syntax == TriG {
+		tp.turtle = newTurtleParser(reader, filePath, tp.syntax == TriG)
+		if documents, ok := reader.(*multiFileReader); ok {
+			documents.eachFile = true
+			tp.documents = documents
+			tp.nextDocument()
+		}
+	} else {
+		tp.scanner = bufio.NewReaderSize(reader, 4*1024*1024) // 4MB line Buffer
+	}
+	return tp, nil
 }
 
 // NextTriple returns the next triple that is read from the internal file.
+// At the end of the file, it returns nil and no error.
 //
 // Arguments:
 //   numTokens int : by adding the optional argument you can decide how many tokens
 //                   should be parsed. With 0, no tokens are parsed. With 1, 2 or 3
 //                   all tokens up to including Subject, Predicate and Object are
-//                   parsed. Non-parsed tokens are nil slices.
+//                   parsed. Non-parsed tokens are nil slices. Only the parsed tokens
+//                   are validated, which makes partial parsing faster. With a graph
+//                   filter, the graph term is always parsed. Statements of Turtle and
+//                   TriG documents, and triples of HDT files are always parsed completely.
 //
 // Example:
 //    triple, err := tripleParser.NextTriple(2)  // consume line and parse subject and predicate.
 //
-// TODO: The parsing is a subset of the actual N-Triple syntax.
+// The tokens are the terms as written in the file, e.g. IRIs with angle brackets, see ParseTerm to decode them.
 func (tp *TripleParser) NextTriple(argNumTokens ...int) (*Triple, error) {
 
 	// parse the optional arguments
@@ -58,61 +128,136 @@ func (tp *TripleParser) NextTriple(argNumTokens ...int) (*Triple, error) {
 	} else {
 		numTokens = 3 // per default, all tokens are parsed
 	}
+	if tp.hdt != nil {
+		numTokens = 3 // the line needs the object
+	}
 
-	// read one line from the file
-	origLine, isPrefix, err := tp.scanner.ReadLine()
-	if err != nil && err != io.EOF { // misc error
-		return nil, err
-	} else if err == io.EOF { // file has ended
+	terms, line, err := tp.next(numTokens)
+	if err == io.EOF {
 		return nil, nil
+	} else if err != nil {
+		return nil, err
 	}
+	return &Triple{terms[0], terms[1], terms[2], terms[3], line}, nil
+}
 
-	// whenever a line is to be skipped, it needs to deliver the next line instead
-
-	// skip because line too big
-	if isPrefix {
-		fmt.Printf("Line Buffer too small!!! Line prefix: %v\n", string(origLine[:200]))
-		return tp.NextTriple()
+// DecodeObjectsOf restricts the objects that are read to the statements with the given predicates, in
+// N-Triples syntax (e.g. `<http://www.w3.org/1999/02/22-rdf-syntax-ns#type>`). It only matters for HDT
+// files, of which each object is looked up by its ID: the objects of other statements, and the Line of
+// their triples, are nil. Other syntaxes parse all objects anyway.
+func (tp *TripleParser) DecodeObjectsOf(predicates ...[]byte) {
+	if tp.hdt != nil {
+		tp.hdt.decodeObjectsOf(predicates)
 	}
+}
 
-	// early termination if no tokens are to be parsed.
-	if numTokens == 0 {
-		return &Triple{nil, nil, nil, origLine}, nil
+// NextStatement returns the subject, predicate, object and graph term of the next statement, of which the
+// first numTerms are parsed, c.f. NextTriple. Unlike NextTriple, it returns io.EOF at the end of the file.
+// The terms are only valid until the next call.
+func (tp *TripleParser) NextStatement(numTerms int) (terms [4][]byte, err error) {
+	terms, _, err = tp.next(numTerms)
+	return
+}
+
+// next returns the terms and the line of the next statement of the requested graphs, or io.EOF
+func (tp *TripleParser) next(numTokens int) (terms [4][]byte, line []byte, err error) {
+	if tp.Graphs != nil {
+		numTokens = 3 // the graph term follows the object
 	}
 
-	// process subject
-	subjectBytes, subjectToken := retrieveToken(origLine)
-	line := origLine[subjectBytes:]
+	// whenever a statement is to be skipped, the next one is read instead
+	for {
+		if tp.hdt != nil {
+			if terms, line, err = tp.hdt.next(numTokens); err != nil {
+				return
+			}
+		} else if tp.turtle != nil {
+			var st statement
+			if st, err = tp.turtle.next(); err == io.EOF && tp.nextDocument() {
+				continue
+			} else if err != nil {
+				return
+			}
+			terms, line = st.terms, st.line
+		} else if terms, line, err = tp.nextLine(numTokens); err != nil {
+			return
+		} else if terms[0] == nil && numTokens > 0 { // empty line or comment
+			continue
+		}
 
-	// skip if line is empty of a comment
-	if len(subjectToken) == 0 || subjectToken[0] == '#' {
-		return tp.NextTriple()
+		// skip if the statement belongs to a filtered graph
+		if tp.Graphs.Matches(terms[3]) {
+			return
+		}
 	}
+}
 
-	// early termination if only one token should be parsed
-	if numTokens == 1 {
-		return &Triple{subjectToken, nil, nil, origLine}, nil
+// nextDocument starts a new Turtle or TriG parser for the next file of a dataset in several files, so that
+// prefixes, the base and blank node labels of a file do not carry over to the next one. It returns false
+// after the last file.
+func (tp *TripleParser) nextDocument() bool {
+	if tp.documents == nil {
+		return false
 	}
+	fileName, ok := tp.documents.nextFile()
+	if ok {
+		tp.numDocs++
+		tp.turtle = tp.turtle.nextDocument(tp.documents, fileName, "f"+strconv.Itoa(tp.numDocs)+"_")
+	}
+	return ok
+}
 
-	// process predicate
-	predicateBytes, predicateToken := retrieveToken(line)
-	line = line[predicateBytes:]
+// nextLine reads the next line of an N-Triples or N-Quads file and parses the first numTokens terms.
+// Invalid lines are skipped, unless the parser is Strict.
+func (tp *TripleParser) nextLine(numTokens int) (terms [4][]byte, origLine []byte, err error) {
+
+	// whenever a line is to be skipped, the next line is read instead
+	for {
+		var isPrefix bool
+		origLine, isPrefix, err = tp.scanner.ReadLine()
+		if err == io.EOF { // file has ended
+			if tp.invalid > maxReportedErrors {
+				fmt.Fprintf(tp.messages, "Skipped %v invalid lines in total\n", tp.invalid)
+			}
+			return
+		} else if err != nil { // misc error
+			return
+		}
+		tp.line++
 
-	// early termination if only two tokens should be parsed
-	if numTokens == 2 {
-		return &Triple{subjectToken, predicateToken, nil, origLine}, nil
-	}
+		// skip because line too big
+		if isPrefix {
+			fmt.Fprintf(tp.messages, "Line Buffer too small!!! Line prefix: %v\n", string(origLine[:200]))
+			for isPrefix && err == nil {
+				_, isPrefix, err = tp.scanner.ReadLine()
+			}
+			continue
+		}
 
-	// process object
-	// TODO: Check if strings with spaces work, like:  "New York"@gb
-	_, objectToken := retrieveToken(line)
+		// early termination if no tokens are to be parsed.
+		if numTokens == 0 {
+			return
+		}
 
-	// return the triple with all information
-	return &Triple{subjectToken, predicateToken, objectToken, origLine}, nil
+		terms, err = ParseStatement(origLine, numTokens, tp.syntax == NQuads)
+		if err == nil {
+			return
+		}
+		err.(*SyntaxError).Line = tp.line
+		if tp.Strict {
+			return
+		}
+		if tp.invalid++; tp.invalid <= maxReportedErrors {
+			fmt.Fprintf(tp.messages, "Skipping invalid line: %v\n", err)
+		}
+	}
 }
 
 // Close the handlers for the scanner and underlying file.
 func (tp *TripleParser) Close() error {
+	if tp.hdt != nil {
+		return tp.hdt.Close()
+	}
 	defer tp.reader.Close()
 	return nil
 }
@@ -183,66 +328,6 @@ func InterpreteIriRef(token []byte) (text []byte) {
 	return // naked return
 }
 
-// Retrieves a token from a N-Triple entry.
-// TODO: Test multiple escaping cases
-// TODO: This could be improved by allowing an argument to specify the max number of tokens to parse.
-//       If only the subject is needed than parsing the predicate and object becomes unnecessary.
-func retrieveToken(data []byte) (advance int, token []byte) {
-	var sigil rune        // current rune that is being checked
-	var width int         // width of sigil
-	var start, length int // delimiters for the token
-
-	// Skip leading spaces.
-	for start < len(data) {
-		sigil, width = identifyRune(data[start:])
-		if unicode.IsSpace(sigil) { // Could also use the isSpaceOrBracket method (without the brackets)
-			start += width
-		} else {
-			break
-		}
-	}
-
-	// Catch the entire token, while paying attention to IRIREF brackets and literal quotes.
-	var nesting rune
-	var escaping bool
-	for ; start+length < len(data); length += width {
-		sigil, width = identifyRune(data[start+length:])
-
-		// if escaping, just jump to next rune
-		if escaping == true {
-			escaping = false
-			continue
-		}
-
-		// a backslash will start escaping, no parsing will ever be done with this
-		if sigil == '\\' {
-			escaping = true
-			continue
-		}
-
-		// non-nesting state: can enter a nesting state and spaces will terminate token
-		if nesting == 0 {
-			if sigil == '<' || sigil == '"' {
-				nesting = sigil
-			} else if escaping == false && unicode.IsSpace(sigil) {
-				break
-			}
-		} else { // nesting state: can exit nesting state
-			if (nesting == '<' && sigil == '>') || (nesting == '"' && sigil == '"') {
-				nesting = 0
-			}
-		}
-	}
-
-	// if we're at EOL, and we have a final, non-empty, non-terminated word. Return it.
-	if start+length >= len(data) {
-		return len(data), data[start:]
-	}
-
-	// return the token
-	return start + length, data[start : start+length]
-}
-
 // Identify rune and width.
 // Uses a special procedure to check for 1-width ASCII runes.
 // TODO: Check if this special procedure is really faster.
@@ -0,0 +1,451 @@
+package io
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// This file implements the N-Triples 1.1 grammar (https://www.w3.org/TR/n-triples/#n-triples-grammar)
+// and its extension by graph terms, the N-Quads grammar (https://www.w3.org/TR/n-quads/#sec-grammar).
+//
+// Parsing is split into two steps: ParseTerms only validates a line and returns its terms as slices of the
+// line, without copying or decoding anything. This is the fast path used when reading large dumps. The terms
+// are decoded (escape sequences, brackets, quotes, datatypes and language tags) by ParseTerm or IRIValue only
+// when needed, and without allocations beyond the resulting string if they contain no escape sequences.
+
+// SyntaxError describes a line that is not valid N-Triples, or the position of an error in a Turtle document.
+type SyntaxError struct {
+	Line   int64 // line number in the document, zero if unknown
+	Column int   // byte offset in the line at which the error was detected
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("syntax error in line %v, column %v: %v", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("syntax error at column %v: %v", e.Column, e.Msg)
+}
+
+// ParseTriple splits a line of an N-Triples document into its subject, predicate and object terms.
+// Empty lines and comment lines return nil terms and no error.
+func ParseTriple(line []byte) (subject, predicate, object []byte, err error) {
+	terms, err := ParseTerms(line, 3)
+	return terms[0], terms[1], terms[2], err
+}
+
+// ParseTerms parses the first `numTerms` terms of a line of an N-Triples document. The rest of the line is
+// only validated if all three terms are parsed, which allows readers that only need the subject or the
+// predicate to skip over long literals. Empty lines and comment lines return nil terms and no error.
+func ParseTerms(line []byte, numTerms int) (terms [3][]byte, err error) {
+	statement, err := ParseStatement(line, numTerms, false)
+	copy(terms[:], statement[:3])
+	return
+}
+
+// ParseStatement parses a line of an N-Triples document, or of an N-Quads document if `quads` is set, like
+// ParseTerms. For N-Quads, the graph term is parsed together with the object and returned as fourth term;
+// it is nil for statements of the default graph.
+func ParseStatement(line []byte, numTerms int, quads bool) (terms [4][]byte, err error) {
+	l := lexer{data: line}
+	l.skipWhitespace()
+	if l.done() || l.peek() == '#' {
+		return
+	}
+
+	for i := 0; i < numTerms && i < 3; i++ {
+		if i > 0 {
+			l.skipWhitespace()
+		}
+		if l.done() {
+			return terms, l.errorf("missing %v", positionNames[i])
+		}
+
+		start := l.pos
+		switch c := l.peek(); {
+		case c == '<':
+			err = l.iriRef()
+		case c == '_' && i != 1:
+			err = l.blankNode()
+		case c == '"' && i == 2:
+			err = l.literal()
+		default:
+			err = l.errorf("unexpected %q at the start of the %v", c, positionNames[i])
+		}
+		if err != nil {
+			return
+		}
+		terms[i] = line[start:l.pos]
+	}
+
+	if numTerms >= 3 {
+		l.skipWhitespace()
+		if quads && !l.done() && (l.peek() == '<' || l.peek() == '_') {
+			start := l.pos
+			if l.peek() == '<' {
+				err = l.iriRef()
+			} else {
+				err = l.blankNode()
+			}
+			if err != nil {
+				return
+			}
+			terms[3] = line[start:l.pos]
+			l.skipWhitespace()
+		}
+		if l.done() || l.peek() != '.' {
+			return terms, l.errorf("missing '.' at the end of the statement")
+		}
+		l.pos++
+		l.skipWhitespace()
+		if !l.done() && l.peek() != '#' {
+			return terms, l.errorf("unexpected %q after the end of the statement", l.peek())
+		}
+	}
+	return
+}
+
+var positionNames = [3]string{"subject", "predicate", "object"}
+
+// lexer holds the position in a line while it is parsed
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *lexer) done() bool { return l.pos >= len(l.data) }
+func (l *lexer) peek() byte { return l.data[l.pos] }
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Column: l.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipWhitespace skips spaces and tabs, the only whitespace N-Triples allows within a line
+func (l *lexer) skipWhitespace() {
+	for !l.done() && (l.peek() == ' ' || l.peek() == '\t') {
+		l.pos++
+	}
+}
+
+// iriRef consumes an IRIREF: '<' ([^#x00-#x20<>"{}|^`\] | UCHAR)* '>' and checks that the IRI is absolute
+func (l *lexer) iriRef() error {
+	start := l.pos
+	l.pos++ // '<'
+	escaped := false
+	for {
+		if l.done() {
+			return l.errorf("unterminated IRI")
+		}
+		c := l.peek()
+		switch {
+		case c == '>':
+			l.pos++
+			iri := l.data[start+1 : l.pos-1]
+			if escaped {
+				iri = unescape(iri)
+			}
+			if !isAbsoluteIRI(iri) {
+				return &SyntaxError{Column: start, Msg: fmt.Sprintf("relative IRI <%s>", iri)}
+			}
+			return nil
+		case c == '\\':
+			if err := l.uchar(); err != nil {
+				return err
+			}
+			escaped = true
+		case c >= utf8.RuneSelf:
+			if err := l.utf8Rune(); err != nil {
+				return err
+			}
+		case iriForbidden[c]:
+			return l.errorf("character %q is not allowed in an IRI", c)
+		default:
+			l.pos++
+		}
+	}
+}
+
+// iriForbidden marks the ASCII characters that may not appear unescaped in an IRIREF
+var iriForbidden = func() (forbidden [utf8.RuneSelf]bool) {
+	for c := 0; c <= 0x20; c++ {
+		forbidden[c] = true
+	}
+	for _, c := range "<>\"{}|^`\\" {
+		forbidden[c] = true
+	}
+	return
+}()
+
+// isAbsoluteIRI checks that the IRI starts with a scheme: ALPHA *( ALPHA / DIGIT / "+" / "-" / "." ) ":"
+func isAbsoluteIRI(iri []byte) bool {
+	for i, c := range iri {
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		case i > 0 && c == ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// blankNode consumes a BLANK_NODE_LABEL: '_:' (PN_CHARS_U | [0-9]) ((PN_CHARS | '.')* PN_CHARS)?
+func (l *lexer) blankNode() error {
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("_:")) {
+		return l.errorf("blank node labels must start with '_:'")
+	}
+	l.pos += 2
+
+	r, width := l.rune()
+	if !(isPNCharsU(r) || r >= '0' && r <= '9') {
+		return l.errorf("invalid first character %q of a blank node label", r)
+	}
+	l.pos += width
+
+	lastNonDot := l.pos
+	for !l.done() {
+		r, width = l.rune()
+		if r != '.' && !isPNChars(r) {
+			break
+		}
+		l.pos += width
+		if r != '.' {
+			lastNonDot = l.pos
+		}
+	}
+	l.pos = lastNonDot // a label may not end with '.', which then terminates the triple
+	return nil
+}
+
+// literal consumes a literal: STRING_LITERAL_QUOTE ('^^' IRIREF | LANGTAG)?
+func (l *lexer) literal() error {
+	l.pos++ // '"'
+	for {
+		if l.done() {
+			return l.errorf("unterminated literal")
+		}
+		c := l.peek()
+		if c == '"' {
+			l.pos++
+			break
+		}
+		switch {
+		case c == '\\':
+			if l.pos+1 < len(l.data) && bytes.IndexByte([]byte("tbnrf\"'\\"), l.data[l.pos+1]) >= 0 {
+				l.pos += 2
+			} else if err := l.uchar(); err != nil {
+				return err
+			}
+		case c == '\n' || c == '\r':
+			return l.errorf("line breaks have to be escaped in literals")
+		case c >= utf8.RuneSelf:
+			if err := l.utf8Rune(); err != nil {
+				return err
+			}
+		default:
+			l.pos++
+		}
+	}
+
+	if l.done() {
+		return nil
+	}
+	switch l.peek() {
+	case '^':
+		if !bytes.HasPrefix(l.data[l.pos:], []byte("^^<")) {
+			return l.errorf("datatypes are written as ^^<IRI>")
+		}
+		l.pos += 2
+		return l.iriRef()
+	case '@':
+		return l.langTag()
+	}
+	return nil
+}
+
+// langTag consumes a LANGTAG: '@' [a-zA-Z]+ ('-' [a-zA-Z0-9]+)*
+func (l *lexer) langTag() error {
+	l.pos++ // '@'
+	start := l.pos
+	for !l.done() && isAlpha(l.peek()) {
+		l.pos++
+	}
+	if l.pos == start {
+		return l.errorf("empty language tag")
+	}
+	for !l.done() && l.peek() == '-' {
+		l.pos++
+		start = l.pos
+		for !l.done() && (isAlpha(l.peek()) || l.peek() >= '0' && l.peek() <= '9') {
+			l.pos++
+		}
+		if l.pos == start {
+			return l.errorf("empty subtag in language tag")
+		}
+	}
+	return nil
+}
+
+// uchar consumes an UCHAR: '\u' HEX{4} | '\U' HEX{8}
+func (l *lexer) uchar() error {
+	digits := 0
+	if l.pos+1 < len(l.data) {
+		switch l.data[l.pos+1] {
+		case 'u':
+			digits = 4
+		case 'U':
+			digits = 8
+		}
+	}
+	if digits == 0 || l.pos+2+digits > len(l.data) {
+		return l.errorf("invalid escape sequence")
+	}
+	for _, c := range l.data[l.pos+2 : l.pos+2+digits] {
+		if !isHex(c) {
+			return l.errorf("invalid hex digit %q in escape sequence", c)
+		}
+	}
+	l.pos += 2 + digits
+	return nil
+}
+
+// utf8Rune consumes a multi-byte UTF-8 encoded rune
+func (l *lexer) utf8Rune() error {
+	r, width := utf8.DecodeRune(l.data[l.pos:])
+	if r == utf8.RuneError && width <= 1 {
+		return l.errorf("invalid UTF-8 encoding")
+	}
+	l.pos += width
+	return nil
+}
+
+// rune returns the rune at the current position, or utf8.RuneError at the end of the line
+func (l *lexer) rune() (rune, int) {
+	if l.done() {
+		return utf8.RuneError, 0
+	}
+	if c := l.peek(); c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+	return utf8.DecodeRune(l.data[l.pos:])
+}
+
+func isAlpha(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isHex(c byte) bool   { return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' }
+
+// isPNCharsBase checks for PN_CHARS_BASE
+func isPNCharsBase(r rune) bool {
+	switch {
+	case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		return true
+	case r >= 0xC0 && r <= 0xD6, r >= 0xD8 && r <= 0xF6, r >= 0xF8 && r <= 0x2FF,
+		r >= 0x370 && r <= 0x37D, r >= 0x37F && r <= 0x1FFF, r >= 0x200C && r <= 0x200D,
+		r >= 0x2070 && r <= 0x218F, r >= 0x2C00 && r <= 0x2FEF, r >= 0x3001 && r <= 0xD7FF,
+		r >= 0xF900 && r <= 0xFDCF, r >= 0xFDF0 && r <= 0xFFFD, r >= 0x10000 && r <= 0xEFFFF:
+		return r != utf8.RuneError
+	}
+	return false
+}
+
+// isPNCharsExtra checks for the characters PN_CHARS adds to PN_CHARS_U: '-' | [0-9] | #x00B7 | [#x0300-#x036F] | [#x203F-#x2040]
+func isPNCharsExtra(r rune) bool {
+	return r == '-' || r >= '0' && r <= '9' || r == 0xB7 || r >= 0x300 && r <= 0x36F || r >= 0x203F && r <= 0x2040
+}
+
+// isPNCharsU checks for PN_CHARS_U of N-Triples: PN_CHARS_BASE | '_' | ':'
+func isPNCharsU(r rune) bool {
+	return isPNCharsBase(r) || r == '_' || r == ':'
+}
+
+// isPNChars checks for PN_CHARS of N-Triples: PN_CHARS_U | '-' | [0-9] | #x00B7 | [#x0300-#x036F] | [#x203F-#x2040]
+func isPNChars(r rune) bool {
+	return isPNCharsU(r) || isPNCharsExtra(r)
+}
+
+// TermKind distinguishes the kinds of RDF terms.
+type TermKind int
+
+// Kinds of RDF terms.
+const (
+	IRI TermKind = iota + 1
+	BlankNode
+	Literal
+)
+
+// Term is a decoded RDF term.
+type Term struct {
+	Kind     TermKind
+	Value    string // the IRI, the blank node label (without '_:') or the lexical form of the literal
+	Datatype string // datatype IRI of a literal, empty for simple and language-tagged literals
+	Lang     string // language tag of a literal
+}
+
+// ParseTerm decodes a term as returned by ParseTerms. The term is assumed to be valid.
+func ParseTerm(token []byte) Term {
+	if len(token) == 0 {
+		return Term{}
+	}
+	switch token[0] {
+	case '<':
+		return Term{Kind: IRI, Value: string(IRIValue(token))}
+	case '_':
+		return Term{Kind: BlankNode, Value: string(token[2:])}
+	}
+
+	end := bytes.LastIndexByte(token, '"')
+	t := Term{Kind: Literal, Value: string(unescape(token[1:end]))}
+	if suffix := token[end+1:]; len(suffix) > 0 {
+		if suffix[0] == '@' {
+			t.Lang = string(suffix[1:])
+		} else {
+			t.Datatype = string(IRIValue(suffix[2:]))
+		}
+	}
+	return t
+}
+
+// IRIValue returns the IRI of an IRIREF term without its angle brackets and with decoded escape
+// sequences. Without escape sequences, the result shares the memory of the term.
+// Other terms are returned as they are.
+func IRIValue(token []byte) []byte {
+	if len(token) < 2 || token[0] != '<' || token[len(token)-1] != '>' {
+		return token
+	}
+	return unescape(token[1 : len(token)-1])
+}
+
+// unescape decodes the ECHAR and UCHAR escape sequences of valid N-Triples text
+func unescape(text []byte) []byte {
+	i := bytes.IndexByte(text, '\\')
+	if i < 0 {
+		return text // fast path
+	}
+
+	decoded := make([]byte, 0, len(text))
+	for i >= 0 {
+		decoded = append(decoded, text[:i]...)
+		text = text[i:]
+		switch text[1] {
+		case 'u', 'U':
+			digits := 4
+			if text[1] == 'U' {
+				digits = 8
+			}
+			code, _ := strconv.ParseUint(string(text[2:2+digits]), 16, 32)
+			decoded = utf8.AppendRune(decoded, rune(code))
+			text = text[2+digits:]
+		default:
+			decoded = append(decoded, echars[text[1]])
+			text = text[2:]
+		}
+		i = bytes.IndexByte(text, '\\')
+	}
+	return append(decoded, text...)
+}
+
+// echars maps the character after a backslash to the escaped character
+var echars = map[byte]byte{'t': '\t', 'b': '\b', 'n': '\n', 'r': '\r', 'f': '\f', '"': '"', '\'': '\'', '\\': '\\'}
@@ -0,0 +1,303 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// w3cSuitePath is the directory of the W3C N-Triples test suite (rdf-tests/rdf/rdf11/rdf-n-triples of
+// https://github.com/w3c/rdf-tests)
+const w3cSuitePath = "../testdata/rdf-n-triples"
+
+// syntaxCases cover the productions of the grammar, following the cases of the W3C suite.
+var syntaxCases = []struct {
+	line  string
+	valid bool
+}{
+	// empty lines and comments
+	{"", true},
+	{"   \t", true},
+	{"#comment", true},
+	{"  # comment <a> <b> <c> .", true},
+
+	// IRIs
+	{"<http://example/s> <http://example/p> <http://example/o> .", true},
+	{"<scheme:!$%25&'()*+,-./0123456789:/@ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz~?#> <http://example/p> <http://example/o> .", true},
+	{"<http://example/\\u00E9> <http://example/p> <http://example/\\U000000E9> .", true},
+	{"<http://example/é> <http://example/p> <http://example/o> .", true},
+	{"<http://example/s> <http://example/p> <s> .", false},                       // relative IRI
+	{"<http://www.w3.org/wo rk> <http://example/p> <http://example/o> .", false}, // space in IRI
+	{"<http://example/\\u00ZZ11> <http://example/p> <http://example/o> .", false},
+	{"<http://example/\\U00ZZ1111> <http://example/p> <http://example/o> .", false},
+	{"<http://example/\\n> <http://example/p> <http://example/o> .", false},
+	{"<http://example/\\/> <http://example/p> <http://example/o> .", false},
+	{"<http://example/{abc}> <http://example/p> <http://example/o> .", false},
+	{"<http://example/s> <http://example/p> <http://example/o", false},
+	{"<://example/s> <http://example/p> <http://example/o> .", false},
+
+	// blank nodes
+	{"_:a <http://example/p> _:b .", true},
+	{"_:1a <http://example/p> _:a.2.b .", true},
+	{"_:a-b_c·d <http://example/p> _:é .", true},
+	{"<http://example/s> <http://example/p> _:o.", true},
+	{"_:a <http://example/p> _:-b .", false},
+	{"_:.a <http://example/p> <http://example/o> .", false},
+	{"<http://example/s> _:p <http://example/o> .", false}, // blank node predicate
+	{"_a <http://example/p> <http://example/o> .", false},
+
+	// literals
+	{`<http://example/s> <http://example/p> "string" .`, true},
+	{`<http://example/s> <http://example/p> "" .`, true},
+	{`<http://example/s> <http://example/p> "chat"@en .`, true},
+	{`<http://example/s> <http://example/p> "chat"@en-us-1 .`, true},
+	{`<http://example/s> <http://example/p> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`, true},
+	{`<http://example/s> <http://example/p> "\t\b\n\r\f\"\'\\ é \U0001F600" .`, true},
+	{"<http://example/s> <http://example/p> \"tab\there\" .", true},
+	{`<http://example/s> <http://example/p> "\x" .`, false},
+	{`<http://example/s> <http://example/p> "\u00E" .`, false},
+	{`<http://example/s> <http://example/p> "abc' .`, false},
+	{`<http://example/s> <http://example/p> """abc""" .`, false},
+	{`<http://example/s> <http://example/p> 'abc' .`, false},
+	{`<http://example/s> <http://example/p> "abc"@1 .`, false},
+	{`<http://example/s> <http://example/p> "abc"@en- .`, false},
+	{`<http://example/s> <http://example/p> "abc"^^xsd:string .`, false},
+	{`<http://example/s> <http://example/p> "abc"^^<string> .`, false},
+	{`<http://example/s> <http://example/p> 1 .`, false},
+	{`<http://example/s> <http://example/p> true .`, false},
+	{`"s" <http://example/p> <http://example/o> .`, false},
+	{"<http://example/s> <http://example/p> \"\xff\" .", false}, // invalid UTF-8
+
+	// structure
+	{"<http://example/s>\t<http://example/p>\t<http://example/o>\t.\t", true},
+	{`<http://example/s><http://example/p>"o".`, true},
+	{"_:s<http://example/p><http://example/o>.", true},
+	{"<http://example/s> <http://example/p> <http://example/o> . # comment", true},
+	{"<http://example/s> <http://example/p> <http://example/o> .#comment", true},
+	{"<http://example/s> <http://example/p> <http://example/o>", false},
+	{"<http://example/s> <http://example/p> <http://example/o> ;", false},
+	{"<http://example/s> <http://example/p> <http://example/o> . .", false},
+	{"<http://example/s> <http://example/p> <http://example/o> <http://example/o2> .", false},
+	{"<http://example/s> <http://example/p> .", false},
+	{"@prefix : <http://example/> .", false},
+	{"PREFIX : <http://example/>", false},
+	{"@base <http://example/> .", false},
+	{"<http://example/s> a <http://example/o> .", false},
+	{"<http://example/s> <http://example/p> <http://example/o>, <http://example/o2> .", false},
+	{"<http://example/s> <http://example/p> [] .", false},
+	{"<http://example/s> <http://example/p> ( ) .", false},
+}
+
+func TestParseTriple(t *testing.T) {
+	for _, c := range syntaxCases {
+		_, _, _, err := ParseTriple([]byte(c.line))
+		if c.valid && err != nil {
+			t.Errorf("Valid line rejected: %v\n  %v", c.line, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("Invalid line accepted: %v", c.line)
+		}
+	}
+}
+
+func TestParseTerm(t *testing.T) {
+	s, p, o, err := ParseTriple([]byte(`<http://example/é> <http://example/p> "a\tbé"@en-us .`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if term := ParseTerm(s); term.Kind != IRI || term.Value != "http://example/é" {
+		t.Errorf("Wrong subject: %+v", term)
+	}
+	if iri := string(IRIValue(p)); iri != "http://example/p" {
+		t.Errorf("Wrong predicate: %v", iri)
+	}
+	if term := ParseTerm(o); term.Kind != Literal || term.Value != "a\tbé" || term.Lang != "en-us" || term.Datatype != "" {
+		t.Errorf("Wrong object: %+v", term)
+	}
+
+	_, _, o, _ = ParseTriple([]byte(`_:s <http://example/p> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`))
+	if term := ParseTerm(o); term.Value != "1" || term.Datatype != "http://www.w3.org/2001/XMLSchema#integer" {
+		t.Errorf("Wrong datatype: %+v", term)
+	}
+	if term := ParseTerm([]byte("_:b1")); term.Kind != BlankNode || term.Value != "b1" {
+		t.Errorf("Wrong blank node: %+v", term)
+	}
+}
+
+func TestParseTermsPartial(t *testing.T) {
+	// only the requested terms are validated
+	terms, err := ParseTerms([]byte(`<http://example/s> <http://example/p> not a term`), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(terms[0]) != "<http://example/s>" || string(terms[1]) != "<http://example/p>" || terms[2] != nil {
+		t.Errorf("Wrong terms: %q", terms)
+	}
+}
+
+func TestTripleParserStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.nt")
+	data := "# comment\n<http://example/s> <http://example/p> <http://example/o> .\nbroken\n_:s <http://example/p> \"o\" .\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// skipping invalid lines, which are reported on the messages writer
+	var messages bytes.Buffer
+	parser, err := NewTripleParserWithMessages(path, &messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for trip, err := parser.NextTriple(); trip != nil && err == nil; trip, err = parser.NextTriple() {
+		count++
+	}
+	parser.Close()
+	if count != 2 {
+		t.Errorf("Expected 2 triples, got %v", count)
+	}
+	if !bytes.Contains(messages.Bytes(), []byte("Skipping invalid line: syntax error in line 3")) {
+		t.Errorf("The invalid line was not reported: %q", messages.String())
+	}
+
+	// reporting invalid lines
+	parser, _ = NewTripleParser(path)
+	parser.Strict = true
+	defer parser.Close()
+	parser.NextTriple()
+	_, err = parser.NextTriple()
+	if syntaxErr, ok := err.(*SyntaxError); !ok || syntaxErr.Line != 3 {
+		t.Errorf("Expected a syntax error in line 3, got %v", err)
+	}
+}
+
+// TestW3CSuite runs the positive and negative syntax tests of the manifest of the W3C N-Triples test suite.
+func TestW3CSuite(t *testing.T) {
+	manifest, err := os.ReadFile(filepath.Join(w3cSuitePath, "manifest.ttl"))
+	if err != nil {
+		t.Fatalf("W3C test suite not found in %v: %v", w3cSuitePath, err)
+	}
+
+	entry := regexp.MustCompile(`(?s)rdf:type\s+rdft:TestNTriples(Positive|Negative)Syntax\s*;.*?mf:action\s+<([^>]+)>`)
+	tests := entry.FindAllSubmatch(manifest, -1)
+	if len(tests) == 0 {
+		t.Fatalf("No syntax tests found in the manifest")
+	}
+
+	for _, test := range tests {
+		positive, file := string(test[1]) == "Positive", string(test[2])
+		valid, err := validFile(filepath.Join(w3cSuitePath, file))
+		if err != nil {
+			t.Errorf("%v: %v", file, err)
+		} else if positive && !valid {
+			t.Errorf("%v: valid document rejected", file)
+		} else if !positive && valid {
+			t.Errorf("%v: invalid document accepted", file)
+		}
+	}
+}
+
+// validFile checks whether all lines of the file are valid N-Triples. Lines end with any sequence of
+// carriage returns and line feeds, like the EOL of the grammar.
+func validFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		if _, _, _, err := ParseTriple(scanner.Bytes()); err != nil {
+			return false, nil
+		}
+	}
+	return true, scanner.Err()
+}
+
+func TestParseStatement(t *testing.T) {
+	line := []byte(`<http://example/s> <http://example/p> "o"@en <http://example/g> .`)
+	terms, err := ParseStatement(line, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(terms[3]) != "<http://example/g>" {
+		t.Errorf("Wrong graph: %s", terms[3])
+	}
+	if _, err := ParseStatement(line, 3, false); err == nil {
+		t.Errorf("Graph term accepted in N-Triples")
+	}
+
+	cases := []struct {
+		line  string
+		valid bool
+	}{
+		{"<http://example/s> <http://example/p> <http://example/o> .", true},
+		{"_:s <http://example/p> _:o _:g .", true},
+		{"<http://example/s><http://example/p><http://example/o><http://example/g>.", true},
+		{`<http://example/s> <http://example/p> "o" "g" .`, false},
+		{"<http://example/s> <http://example/p> <http://example/o> <g> .", false},
+		{"<http://example/s> <http://example/p> <http://example/o> <http://example/g> <http://example/h> .", false},
+	}
+	for _, c := range cases {
+		terms, err := ParseStatement([]byte(c.line), 3, true)
+		if c.valid && err != nil {
+			t.Errorf("Valid line rejected: %v\n  %v", c.line, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("Invalid line accepted: %v (graph %s)", c.line, terms[3])
+		}
+	}
+}
+
+func TestGraphFilter(t *testing.T) {
+	var all *GraphFilter
+	if !all.Matches(nil) || !all.Matches([]byte("<http://example/g>")) {
+		t.Errorf("A nil filter should select all graphs")
+	}
+
+	included := NewGraphFilter([]string{"http://example/g", DefaultGraph}, nil)
+	if !included.Matches([]byte("<http://example/g>")) || !included.Matches(nil) || included.Matches([]byte("<http://example/h>")) {
+		t.Errorf("Wrong selection of included graphs")
+	}
+
+	excluded := NewGraphFilter(nil, []string{"_:b"})
+	if excluded.Matches([]byte("_:b")) || !excluded.Matches(nil) || !excluded.Matches([]byte("<http://example/h>")) {
+		t.Errorf("Wrong selection of excluded graphs")
+	}
+}
+
+func TestTripleParserQuads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.nq")
+	data := "<http://example/s> <http://example/p> <http://example/o> <http://example/g> .\n" +
+		"<http://example/s> <http://example/p> <http://example/o2> .\n" +
+		"<http://example/s> <http://example/p> <http://example/o3> <http://example/h> .\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := NewTripleParser(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+	parser.Graphs = NewGraphFilter(nil, []string{"http://example/h"})
+
+	var graphs []string
+	for trip, err := parser.NextTriple(1); trip != nil && err == nil; trip, err = parser.NextTriple(1) {
+		graphs = append(graphs, string(trip.Graph))
+	}
+	if len(graphs) != 2 || graphs[0] != "<http://example/g>" || graphs[1] != "" {
+		t.Errorf("Wrong graphs of the statements: %q", graphs)
+	}
+}
@@ -0,0 +1,130 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PrefixRegistry maps prefix names to namespace IRIs, such that IRIs can be written as compact
+// CURIEs like `wdt:P31` instead of `http://www.wikidata.org/prop/direct/P31`. A nil registry has no prefixes.
+type PrefixRegistry struct {
+	namespaces map[string]string // prefix name -> namespace IRI
+	byLength   []string          // prefix names sorted by descending namespace length, for compaction
+}
+
+// NewPrefixRegistry returns an empty prefix registry.
+func NewPrefixRegistry() *PrefixRegistry {
+	return &PrefixRegistry{namespaces: make(map[string]string)}
+}
+
+// DefaultPrefixes returns a prefix registry with the prefixes commonly used by Wikidata and RDF vocabularies.
+// c.f. https://www.mediawiki.org/wiki/Wikibase/Indexing/RDF_Dump_Format#Prefixes_used
+func DefaultPrefixes() *PrefixRegistry {
+	r := NewPrefixRegistry()
+	r.Add("wd", "http://www.wikidata.org/entity/")
+	r.Add("wdt", "http://www.wikidata.org/prop/direct/")
+	r.Add("p", "http://www.wikidata.org/prop/")
+	r.Add("rdf", "http://www.w3.org/1999/02/22-rdf-syntax-ns#")
+	r.Add("rdfs", "http://www.w3.org/2000/01/rdf-schema#")
+	r.Add("xsd", "http://www.w3.org/2001/XMLSchema#")
+	r.Add("owl", "http://www.w3.org/2002/07/owl#")
+	r.Add("skos", "http://www.w3.org/2004/02/skos/core#")
+	r.Add("schema", "http://schema.org/")
+	r.Add("dbo", "http://dbpedia.org/ontology/")
+	return r
+}
+
+// ReadPrefixFile reads a prefix registry from a file with Turtle-style `@prefix wdt: <http://...> .` or
+// SPARQL-style `PREFIX wdt: <http://...>` declarations, one per line. Empty lines and comments are skipped.
+func ReadPrefixFile(filePath string) (*PrefixRegistry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := NewPrefixRegistry()
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		prefix, namespace, ok := parsePrefixDeclaration(line)
+		if !ok {
+			return nil, fmt.Errorf("malformed prefix declaration in line %v of %v: %v", lineNo, filePath, line)
+		}
+		r.Add(prefix, namespace)
+	}
+	return r, scanner.Err()
+}
+
+// parsePrefixDeclaration interprets a single `@prefix name: <iri> .` or `PREFIX name: <iri>` line.
+func parsePrefixDeclaration(line string) (prefix string, namespace string, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) > 0 && fields[len(fields)-1] == "." {
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) != 3 || (fields[0] != "@prefix" && !strings.EqualFold(fields[0], "prefix")) {
+		return
+	}
+	if !strings.HasSuffix(fields[1], ":") {
+		return
+	}
+	iri := strings.TrimSuffix(fields[2], ".") // tolerate a missing space before the terminating dot
+	if !strings.HasPrefix(iri, "<") || !strings.HasSuffix(iri, ">") {
+		return
+	}
+	return strings.TrimSuffix(fields[1], ":"), iri[1 : len(iri)-1], true
+}
+
+// Add registers a prefix name for a namespace. An existing prefix with the same name is replaced.
+func (r *PrefixRegistry) Add(prefix string, namespace string) {
+	if _, exists := r.namespaces[prefix]; !exists {
+		r.byLength = append(r.byLength, prefix)
+	}
+	r.namespaces[prefix] = namespace
+	sort.SliceStable(r.byLength, func(i, j int) bool {
+		return len(r.namespaces[r.byLength[i]]) > len(r.namespaces[r.byLength[j]])
+	})
+}
+
+// Expand returns the full IRI of a CURIE with a registered prefix. Everything else, including full IRIs
+// and CURIEs with unknown prefixes, is returned unchanged. Enclosing angle brackets are removed.
+func (r *PrefixRegistry) Expand(curie string) string {
+	if strings.HasPrefix(curie, "<") && strings.HasSuffix(curie, ">") {
+		return curie[1 : len(curie)-1]
+	}
+	if i := strings.IndexByte(curie, ':'); i >= 0 && r != nil {
+		if namespace, ok := r.namespaces[curie[:i]]; ok {
+			return namespace + curie[i+1:]
+		}
+	}
+	return curie
+}
+
+// ExpandAll expands all given CURIEs, see Expand.
+func (r *PrefixRegistry) ExpandAll(curies []string) []string {
+	iris := make([]string, len(curies))
+	for i, curie := range curies {
+		iris[i] = r.Expand(curie)
+	}
+	return iris
+}
+
+// Compact returns the CURIE for an IRI using the registered prefix with the longest matching namespace.
+// If no namespace matches, the IRI is returned unchanged.
+func (r *PrefixRegistry) Compact(iri string) string {
+	if r == nil {
+		return iri
+	}
+	for _, prefix := range r.byLength {
+		if namespace := r.namespaces[prefix]; strings.HasPrefix(iri, namespace) {
+			return prefix + ":" + iri[len(namespace):]
+		}
+	}
+	return iri
+}
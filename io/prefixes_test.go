@@ -0,0 +1,92 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixRegistry(t *testing.T) {
+	r := DefaultPrefixes()
+	r.Add("ex", "http://example.org/")
+	r.Add("exv", "http://example.org/vocab#")
+
+	expand := []struct{ curie, iri string }{
+		{"wdt:P31", "http://www.wikidata.org/prop/direct/P31"},
+		{"wd:Q5", "http://www.wikidata.org/entity/Q5"},
+		{"rdf:type", "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"},
+		{"ex:", "http://example.org/"},
+		{"<wdt:P31>", "wdt:P31"},
+		{"http://www.wikidata.org/prop/direct/P31", "http://www.wikidata.org/prop/direct/P31"},
+		{"unknown:P31", "unknown:P31"},
+		{"P31", "P31"},
+	}
+	for _, c := range expand {
+		assert.Equal(t, c.iri, r.Expand(c.curie), "Expand(%v)", c.curie)
+	}
+	assert.Equal(t, []string{"http://example.org/a", "b"}, r.ExpandAll([]string{"ex:a", "b"}))
+
+	compact := []struct{ iri, curie string }{
+		{"http://www.wikidata.org/prop/direct/P31", "wdt:P31"}, // not p:direct/P31
+		{"http://www.wikidata.org/prop/P31", "p:P31"},
+		{"http://example.org/vocab#term", "exv:term"},
+		{"http://example.org/a", "ex:a"},
+		{"http://unknown.org/a", "http://unknown.org/a"},
+	}
+	for _, c := range compact {
+		assert.Equal(t, c.curie, r.Compact(c.iri), "Compact(%v)", c.iri)
+		assert.Equal(t, c.iri, r.Expand(r.Compact(c.iri)), "round trip of %v", c.iri)
+	}
+
+	// prefixes are replaced by name
+	r.Add("ex", "http://example.com/")
+	assert.Equal(t, "http://example.com/a", r.Expand("ex:a"))
+	assert.Equal(t, "http://example.org/a", r.Compact("http://example.org/a"))
+}
+
+func TestNilPrefixRegistry(t *testing.T) {
+	var r *PrefixRegistry
+	assert.Equal(t, "wdt:P31", r.Expand("wdt:P31"))
+	assert.Equal(t, "http://example.org/a", r.Expand("<http://example.org/a>"))
+	assert.Equal(t, []string{"wdt:P31"}, r.ExpandAll([]string{"wdt:P31"}))
+	assert.Equal(t, "http://www.wikidata.org/prop/direct/P31", r.Compact("http://www.wikidata.org/prop/direct/P31"))
+}
+
+func TestReadPrefixFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected map[string]string
+	}{
+		{"turtle", "@prefix ex: <http://example.org/> .\n@prefix exv: <http://example.org/vocab#>.\n",
+			map[string]string{"ex": "http://example.org/", "exv": "http://example.org/vocab#"}},
+		{"sparql", "PREFIX ex: <http://example.org/>\nprefix : <http://default.org/>\n",
+			map[string]string{"ex": "http://example.org/", "": "http://default.org/"}},
+		{"comments and empty lines", "# prefixes\n\n  @prefix ex: <http://example.org/> .  \n",
+			map[string]string{"ex": "http://example.org/"}},
+		{"missing colon", "@prefix ex <http://example.org/> .\n", nil},
+		{"missing brackets", "@prefix ex: http://example.org/ .\n", nil},
+		{"unknown keyword", "@base <http://example.org/> .\n", nil},
+	}
+	dir := t.TempDir()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(dir, "prefixes.ttl")
+			if err := os.WriteFile(path, []byte(c.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			r, err := ReadPrefixFile(path)
+			if c.expected == nil {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, r.namespaces)
+		})
+	}
+
+	_, err := ReadPrefixFile(filepath.Join(dir, "missing.ttl"))
+	assert.Error(t, err)
+}
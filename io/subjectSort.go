@@ -0,0 +1,385 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// DefaultSortMemory is the memory in bytes that a SubjectSorter uses for the statements, unless another
+// limit is given.
+const DefaultSortMemory = 1 << 30
+
+const (
+	sortBlockSize = 1 << 20 // size of the blocks that hold the statements in memory
+	sortEntrySize = 64      // memory used per statement in addition to its terms, approximately
+)
+
+// sortMaxRuns is the number of sorted runs that are merged at once
+var sortMaxRuns = 128
+
+// SubjectSorter groups the statements of a TripleParser by subject, for files whose statements are not
+// grouped yet. It is an external merge sort: the statements are sorted in memory until they use the given
+// amount of memory, then written as a sorted run to a temporary file, and the runs are merged when the
+// statements are read. Files that fit into memory are never written to disk.
+//
+// The subjects are returned in byte order of their terms, and the statements of a subject in the order of
+// the file. The whole file is read by the first call to NextStatement.
+type SubjectSorter struct {
+	parser    *TripleParser
+	numTerms  int
+	maxMemory int
+	tempDir   string
+
+	started bool
+	err     error
+	entries []sortEntry // statements in memory
+	blocks  [][]byte    // memory of the statements
+	memory  int
+	runs    []string // paths of the sorted runs on disk, in the order of the file
+	levels  []int    // how often the statements of each run were merged, which decreases along the runs
+	merger  *runMerger
+	next    int // index of the next entry, if no run was written
+}
+
+// sortEntry is a statement in memory, encoded like in the runs on disk
+type sortEntry struct {
+	subject []byte
+	record  []byte
+}
+
+// NewSubjectSorter sorts the statements of the parser, decoding numTerms terms of each statement like
+// TripleParser.NextStatement. It uses maxMemory bytes for the statements, or DefaultSortMemory if it is
+// zero, and writes the temporary files to tempDir, or to the default directory of the system if it is empty.
+func NewSubjectSorter(parser *TripleParser, numTerms int, maxMemory int, tempDir string) *SubjectSorter {
+	if maxMemory <= 0 {
+		maxMemory = DefaultSortMemory
+	}
+	return &SubjectSorter{parser: parser, numTerms: numTerms, maxMemory: maxMemory, tempDir: tempDir}
+}
+
+// NextStatement returns the terms of the next statement in subject order, or io.EOF after the last one.
+// The terms are valid until the next call.
+func (s *SubjectSorter) NextStatement() (terms [4][]byte, err error) {
+	if !s.started {
+		s.started = true
+		s.err = s.sort()
+	}
+	if s.err != nil {
+		return terms, s.err
+	}
+	if s.merger != nil {
+		return s.merger.next()
+	}
+	if s.next == len(s.entries) {
+		return terms, io.EOF
+	}
+	terms = decodeStatement(s.entries[s.next].record)
+	s.next++
+	return terms, nil
+}
+
+// Close removes the temporary files. It does not close the parser.
+func (s *SubjectSorter) Close() (err error) {
+	if s.merger != nil {
+		err = s.merger.close()
+	}
+	for _, run := range s.runs {
+		if removeErr := os.Remove(run); err == nil {
+			err = removeErr
+		}
+	}
+	s.runs = nil
+	return
+}
+
+// sort reads all statements of the parser and sorts them in memory or into runs
+func (s *SubjectSorter) sort() error {
+	for {
+		terms, err := s.parser.NextStatement(s.numTerms)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		s.add(terms)
+		if s.memory >= s.maxMemory {
+			if err := s.spill(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(s.runs) == 0 {
+		s.sortEntries()
+		return nil
+	}
+	if len(s.entries) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+	for len(s.runs) > sortMaxRuns {
+		if err := s.mergeRuns(len(s.runs) - sortMaxRuns); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Merging %v sorted runs of statements\n", len(s.runs))
+	merger, err := openRuns(s.runs)
+	s.merger = merger
+	return err
+}
+
+// add copies the statement into the blocks
+func (s *SubjectSorter) add(terms [4][]byte) {
+	size := statementSize(terms)
+	if len(s.blocks) == 0 || cap(s.blocks[len(s.blocks)-1])-len(s.blocks[len(s.blocks)-1]) < size {
+		blockSize := sortBlockSize
+		if size > blockSize {
+			blockSize = size
+		}
+		s.blocks = append(s.blocks, make([]byte, 0, blockSize))
+		s.memory += blockSize
+	}
+	block := s.blocks[len(s.blocks)-1]
+	record := appendStatement(block[len(block):len(block)], terms)
+	s.blocks[len(s.blocks)-1] = block[:len(block)+len(record)]
+
+	s.entries = append(s.entries, sortEntry{subject: decodeStatement(record)[0], record: record})
+	s.memory += sortEntrySize
+}
+
+func (s *SubjectSorter) sortEntries() {
+	sort.SliceStable(s.entries, func(i, j int) bool {
+		return bytes.Compare(s.entries[i].subject, s.entries[j].subject) < 0
+	})
+}
+
+// spill writes the statements in memory as sorted run to a temporary file. The runs are merged in tiers:
+// once there are sortMaxRuns runs of the same level, they are merged into a run of the next level, so that
+// each statement is rewritten once per level rather than once per sortMaxRuns spills.
+func (s *SubjectSorter) spill() error {
+	s.sortEntries()
+	file, err := os.CreateTemp(s.tempDir, "statements-*.run")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, file.Name())
+	s.levels = append(s.levels, 0)
+	writer := bufio.NewWriterSize(file, 4*1024*1024)
+	for _, entry := range s.entries {
+		if _, err := writer.Write(entry.record); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %v statements sorted by subject to %v\n", len(s.entries), file.Name())
+	s.entries, s.blocks, s.memory = nil, nil, 0
+
+	for n := len(s.runs); n >= sortMaxRuns && s.levels[n-sortMaxRuns] == s.levels[n-1]; n = len(s.runs) {
+		if err := s.mergeRuns(n - sortMaxRuns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeRuns merges the runs from the given index on into a single run of the next level
+func (s *SubjectSorter) mergeRuns(first int) error {
+	merger, err := openRuns(s.runs[first:])
+	if err != nil {
+		return err
+	}
+	defer merger.close()
+	file, err := os.CreateTemp(s.tempDir, "statements-*.run")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriterSize(file, 4*1024*1024)
+	var record []byte
+	for {
+		terms, err := merger.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			os.Remove(file.Name())
+			return err
+		}
+		record = appendStatement(record[:0], terms)
+		if _, err := writer.Write(record); err != nil {
+			os.Remove(file.Name())
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	for _, run := range s.runs[first:] {
+		os.Remove(run)
+	}
+	s.runs = append(s.runs[:first], file.Name())
+	s.levels = append(s.levels[:first], s.levels[first]+1)
+	return nil
+}
+
+// The statements are encoded as the length of the terms followed by the terms, each length as uvarint of
+// the length plus one, so that zero stands for a missing term.
+
+func statementSize(terms [4][]byte) (size int) {
+	var buffer [binary.MaxVarintLen64]byte
+	for _, term := range terms {
+		if term == nil {
+			size++
+		} else {
+			size += binary.PutUvarint(buffer[:], uint64(len(term)+1)) + len(term)
+		}
+	}
+	return
+}
+
+func appendStatement(record []byte, terms [4][]byte) []byte {
+	var buffer [binary.MaxVarintLen64]byte
+	for _, term := range terms {
+		if term == nil {
+			record = append(record, 0)
+		} else {
+			record = append(record, buffer[:binary.PutUvarint(buffer[:], uint64(len(term)+1))]...)
+			record = append(record, term...)
+		}
+	}
+	return record
+}
+
+func decodeStatement(record []byte) (terms [4][]byte) {
+	for i := range terms {
+		length, n := binary.Uvarint(record)
+		record = record[n:]
+		if length > 0 {
+			terms[i] = record[: length-1 : length-1]
+			record = record[length-1:]
+		}
+	}
+	return
+}
+
+// sortRun reads the statements of a sorted run
+type sortRun struct {
+	file   *os.File
+	reader *bufio.Reader
+	index  int // position of the run, which keeps the order of the statements of a subject
+	record []byte
+	terms  [4][]byte
+}
+
+// advance reads the next statement of the run
+func (r *sortRun) advance() error {
+	var buffer [binary.MaxVarintLen64]byte
+	r.record = r.record[:0]
+	for i := range r.terms {
+		length, err := binary.ReadUvarint(r.reader)
+		if err == io.EOF && i > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		r.record = append(r.record, buffer[:binary.PutUvarint(buffer[:], length)]...)
+		if length > 1 {
+			start := len(r.record)
+			r.record = append(r.record, make([]byte, length-1)...)
+			if _, err := io.ReadFull(r.reader, r.record[start:]); err != nil {
+				return err
+			}
+		}
+	}
+	r.terms = decodeStatement(r.record)
+	return nil
+}
+
+// runMerger merges sorted runs, it is a heap of the runs by their next statement
+type runMerger struct {
+	runs    []*sortRun
+	started bool
+}
+
+func openRuns(paths []string) (*runMerger, error) {
+	m := &runMerger{}
+	for i, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			m.close()
+			return nil, err
+		}
+		run := &sortRun{file: file, reader: bufio.NewReaderSize(file, 1024*1024), index: i}
+		if err := run.advance(); err == io.EOF {
+			file.Close()
+			continue
+		} else if err != nil {
+			file.Close()
+			m.close()
+			return nil, err
+		}
+		m.runs = append(m.runs, run)
+	}
+	heap.Init(m)
+	return m, nil
+}
+
+// next returns the next statement of the runs, which is valid until the next call
+func (m *runMerger) next() (terms [4][]byte, err error) {
+	if m.started && len(m.runs) > 0 {
+		// advance the run of the last statement
+		if err := m.runs[0].advance(); err == io.EOF {
+			heap.Pop(m).(*sortRun).file.Close()
+		} else if err != nil {
+			return terms, err
+		} else {
+			heap.Fix(m, 0)
+		}
+	}
+	m.started = true
+	if len(m.runs) == 0 {
+		return terms, io.EOF
+	}
+	return m.runs[0].terms, nil
+}
+
+func (m *runMerger) close() (err error) {
+	for _, run := range m.runs {
+		if closeErr := run.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	m.runs = nil
+	return
+}
+
+func (m *runMerger) Len() int { return len(m.runs) }
+func (m *runMerger) Less(i, j int) bool {
+	if c := bytes.Compare(m.runs[i].terms[0], m.runs[j].terms[0]); c != 0 {
+		return c < 0
+	}
+	return m.runs[i].index < m.runs[j].index
+}
+func (m *runMerger) Swap(i, j int)      { m.runs[i], m.runs[j] = m.runs[j], m.runs[i] }
+func (m *runMerger) Push(x interface{}) { m.runs = append(m.runs, x.(*sortRun)) }
+func (m *runMerger) Pop() interface{} {
+	run := m.runs[len(m.runs)-1]
+	m.runs = m.runs[:len(m.runs)-1]
+	return run
+}
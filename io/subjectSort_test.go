@@ -0,0 +1,89 @@
+package io
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sortSubjects reads the file sorted by subject and returns the subject and object of each statement
+func sortSubjects(t *testing.T, path string, maxMemory int, tempDir string) (statements []string) {
+	parser, err := NewTripleParser(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+	sorter := NewSubjectSorter(parser, 3, maxMemory, tempDir)
+	defer sorter.Close()
+
+	terms, err := sorter.NextStatement()
+	for ; err == nil; terms, err = sorter.NextStatement() {
+		assert.Nil(t, terms[3])
+		statements = append(statements, string(terms[0])+" "+string(terms[2]))
+	}
+	assert.Equal(t, io.EOF, err)
+	return
+}
+
+func TestSubjectSorter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "unsorted.nt")
+	var data strings.Builder
+	var expected []string
+	for i := 0; i < 200; i++ { // the objects count up per subject
+		fmt.Fprintf(&data, "<http://ex/s%v> <http://ex/p> \"%v\" .\n", i%7, i/7)
+	}
+	for s := 0; s < 7; s++ {
+		for o := 0; 7*o+s < 200; o++ {
+			expected = append(expected, fmt.Sprintf("<http://ex/s%v> \"%v\"", s, o))
+		}
+	}
+	if err := os.WriteFile(path, []byte(data.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// in memory
+	runs := filepath.Join(dir, "runs")
+	os.Mkdir(runs, 0755)
+	assert.Equal(t, expected, sortSubjects(t, path, 0, runs))
+	files, _ := os.ReadDir(runs)
+	assert.Empty(t, files)
+
+	// a run per statement, which are merged after sortMaxRuns runs
+	assert.Equal(t, expected, sortSubjects(t, path, 1, runs))
+	files, _ = os.ReadDir(runs)
+	assert.Empty(t, files, "the runs are removed")
+
+	// the runs are merged in tiers, and at most sortMaxRuns of them at the end
+	defer func(maxRuns int) { sortMaxRuns = maxRuns }(sortMaxRuns)
+	sortMaxRuns = 3
+	assert.Equal(t, expected, sortSubjects(t, path, 1, runs))
+
+	parser, err := NewTripleParser(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+	sorter := NewSubjectSorter(parser, 3, 1, runs)
+	defer sorter.Close()
+	sorter.NextStatement()
+	// 200 runs are 21102 in base 3, i.e. runs of the levels 4, 4, 3, 2, 0, 0, whose last three runs are
+	// merged twice at the end
+	assert.Equal(t, []int{4, 5}, sorter.levels)
+}
+
+func TestStatementEncoding(t *testing.T) {
+	terms := [4][]byte{[]byte("<http://ex/s>"), []byte("<http://ex/p>"), {}, nil}
+	record := appendStatement(nil, terms)
+	assert.Len(t, record, statementSize(terms))
+	decoded := decodeStatement(record)
+	assert.Equal(t, terms[:2], decoded[:2])
+	assert.NotNil(t, decoded[2])
+	assert.Empty(t, decoded[2])
+	assert.Nil(t, decoded[3])
+}
@@ -0,0 +1,985 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file implements a streaming parser for Turtle (https://www.w3.org/TR/turtle/) and its extension by
+// graphs, TriG (https://www.w3.org/TR/trig/). Each statement is converted into a line of N-Triples, or of
+// N-Quads for statements in named graphs, so that the rest of the code can treat all syntaxes the same.
+//
+// The document is parsed one directive, triples statement or graph block at a time. The statements of a
+// triples statement are returned grouped by subject, such that the statements of nested blank nodes do not
+// interrupt those of the enclosing subject.
+
+const (
+	rdfType  = "<http://www.w3.org/1999/02/22-rdf-syntax-ns#type>"
+	rdfFirst = "<http://www.w3.org/1999/02/22-rdf-syntax-ns#first>"
+	rdfRest  = "<http://www.w3.org/1999/02/22-rdf-syntax-ns#rest>"
+	rdfNil   = "<http://www.w3.org/1999/02/22-rdf-syntax-ns#nil>"
+	xsd      = "http://www.w3.org/2001/XMLSchema#"
+)
+
+const eof rune = -1
+
+// statement is a statement of a Turtle or TriG document as N-Triples or N-Quads line
+type statement struct {
+	terms [4][]byte // subject, predicate, object and graph; slices of line
+	line  []byte
+}
+
+// turtleParser reads the statements of a Turtle or TriG document.
+type turtleParser struct {
+	reader     *bufio.Reader
+	trig       bool
+	pending    []rune // runes pushed back, read again from the end
+	line       int64  // current line, starting at 1
+	col, prev  int    // byte offset in the current and the previous line
+	base       string // base IRI to resolve relative IRIs
+	prefixes   map[string]string
+	graph      string   // graph term of the current block, empty for the default graph
+	blankNodes uint64   // number of generated blank nodes
+	scope      string   // prepended to the blank node labels of the document, see nextDocument
+	created    []string // blank nodes generated for the current triples statement
+	queue      []statement
+	head       int   // index of the next statement in the queue
+	err        error // the error that stopped the parser
+}
+
+// turtleFailure carries the error that stops the parser through the recursive descent
+type turtleFailure struct{ err error }
+
+// newTurtleParser creates a parser for the document. Relative IRIs are resolved against the location of
+// the file until a base is declared.
+func newTurtleParser(reader io.Reader, fileName string, trig bool) *turtleParser {
+	base := ""
+	if path, err := filepath.Abs(fileName); err == nil {
+		base = "file://" + filepath.ToSlash(path)
+	}
+	return &turtleParser{
+		reader:   bufio.NewReaderSize(reader, 1024*1024),
+		trig:     trig,
+		line:     1,
+		base:     base,
+		prefixes: map[string]string{},
+	}
+}
+
+// nextDocument returns a parser for the next document of a dataset in several files, which is read from
+// the reader. The document has its own base and prefixes. The scope, e.g. "f2_", is prepended to its blank
+// node labels to keep them apart from the labels of the other documents, which get other scopes; the
+// numbers of the generated blank nodes continue.
+func (p *turtleParser) nextDocument(reader io.Reader, fileName string, scope string) *turtleParser {
+	next := newTurtleParser(reader, fileName, p.trig)
+	next.blankNodes, next.scope = p.blankNodes, scope
+	return next
+}
+
+// next returns the next statement of the document, or io.EOF at its end. Syntax errors cannot be skipped,
+// the parser returns the same error again afterwards.
+func (p *turtleParser) next() (st statement, err error) {
+	if p.err != nil {
+		return st, p.err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			failure, ok := r.(turtleFailure)
+			if !ok {
+				panic(r)
+			}
+			p.err, err = failure.err, failure.err
+		}
+	}()
+
+	for p.head == len(p.queue) {
+		p.queue, p.head = p.queue[:0], 0
+		if !p.parseStatement() {
+			p.err = io.EOF
+			return st, io.EOF
+		}
+	}
+	st = p.queue[p.head]
+	p.head++
+	return st, nil
+}
+
+// fail stops the parser with a syntax error at the current position
+func (p *turtleParser) fail(format string, args ...interface{}) {
+	panic(turtleFailure{&SyntaxError{Line: p.line, Column: p.col, Msg: fmt.Sprintf(format, args...)}})
+}
+
+// read returns the next rune, or eof at the end of the document
+func (p *turtleParser) read() (r rune) {
+	if n := len(p.pending); n > 0 {
+		r, p.pending = p.pending[n-1], p.pending[:n-1]
+	} else {
+		var size int
+		var err error
+		r, size, err = p.reader.ReadRune()
+		if err == io.EOF {
+			return eof
+		} else if err != nil {
+			panic(turtleFailure{err})
+		} else if r == utf8.RuneError && size == 1 {
+			p.fail("invalid UTF-8 encoding")
+		}
+	}
+
+	if r == '\n' {
+		p.line, p.prev, p.col = p.line+1, p.col, 0
+	} else if r != eof {
+		p.col += utf8.RuneLen(r)
+	}
+	return r
+}
+
+// unread pushes a rune back, such that it is read again
+func (p *turtleParser) unread(r rune) {
+	p.pending = append(p.pending, r)
+	if r == '\n' {
+		p.line, p.col = p.line-1, p.prev
+	} else if r != eof {
+		p.col -= utf8.RuneLen(r)
+	}
+}
+
+func (p *turtleParser) peek() rune {
+	r := p.read()
+	p.unread(r)
+	return r
+}
+
+// skipSpace skips whitespace and comments
+func (p *turtleParser) skipSpace() {
+	for {
+		switch r := p.read(); r {
+		case ' ', '\t', '\n', '\r':
+		case '#':
+			for r != '\n' && r != eof {
+				r = p.read()
+			}
+		default:
+			p.unread(r)
+			return
+		}
+	}
+}
+
+// expect skips whitespace and reads the given rune
+func (p *turtleParser) expect(expected rune) {
+	p.skipSpace()
+	if r := p.read(); r != expected {
+		p.fail("expected %q, found %v", expected, describe(r))
+	}
+}
+
+func describe(r rune) string {
+	if r == eof {
+		return "end of document"
+	}
+	return strconv.QuoteRune(r)
+}
+
+// parseStatement parses the next directive, triples statement or graph block and queues its statements.
+// It returns false at the end of the document.
+func (p *turtleParser) parseStatement() bool {
+	p.skipSpace()
+	var subject string
+	kind := labelSubject
+	switch r := p.peek(); {
+	case r == eof:
+		return false
+	case r == '@':
+		p.read()
+		switch keyword := p.keyword(); keyword {
+		case "prefix":
+			p.prefixDirective()
+		case "base":
+			p.baseDirective()
+		default:
+			p.fail("unknown directive @%v", keyword)
+		}
+		p.expect('.')
+		return true
+	case r == '{' && p.trig:
+		p.read()
+		p.block("")
+		return true
+	case isPNCharsBase(r):
+		// SPARQL style directives and the GRAPH keyword are case-insensitive
+		var keyword string
+		if subject, keyword = p.prefixedNameOrKeyword(); subject == "" {
+			switch strings.ToLower(keyword) {
+			case "prefix":
+				p.prefixDirective()
+			case "base":
+				p.baseDirective()
+			case "graph":
+				if !p.trig {
+					p.fail("graphs are only allowed in TriG")
+				}
+				label, kind := p.subject()
+				if kind != labelSubject {
+					p.fail("invalid graph label")
+				}
+				p.expect('{')
+				p.block(label)
+			default:
+				p.fail("unexpected keyword %v", keyword)
+			}
+			return true
+		}
+	default:
+		subject, kind = p.subject()
+	}
+
+	p.skipSpace()
+	if p.trig && p.peek() == '{' {
+		if kind != labelSubject {
+			p.fail("invalid graph label")
+		}
+		p.read()
+		p.block(subject)
+		return true
+	}
+	p.triples(subject, kind, eof)
+	return true
+}
+
+// block parses the triples statements of a graph block after its opening brace
+func (p *turtleParser) block(graph string) {
+	p.graph = graph
+	defer func() { p.graph = "" }()
+
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.read()
+			return
+		}
+
+		subject, kind := p.subject()
+		p.triples(subject, kind, '}')
+	}
+}
+
+// triples parses the predicates and objects of a triples statement and its terminating '.', which may be
+// left out before the closing rune of a graph block
+func (p *turtleParser) triples(subject string, kind subjectKind, closing rune) {
+	start := len(p.queue)
+
+	// a blank node property list may stand alone, other subjects need predicates
+	p.skipSpace()
+	if r := p.peek(); !(r == '.' || r == closing) || kind != propertyListSubject {
+		p.predicateObjectList(subject)
+	}
+
+	p.skipSpace()
+	if r := p.peek(); r == '.' {
+		p.read()
+	} else if r != closing || closing == eof {
+		p.fail("expected '.', found %v", describe(r))
+	}
+	p.groupBySubject(start, subject)
+}
+
+// groupBySubject orders the queued statements from `start` on by their subject: first the subject of the
+// triples statement, then the blank nodes in the order of their creation
+func (p *turtleParser) groupBySubject(start int, subject string) {
+	statements := p.queue[start:]
+	order := map[string]int{subject: 0}
+	for _, node := range p.created {
+		if _, ok := order[node]; !ok {
+			order[node] = len(order)
+		}
+	}
+	p.created = p.created[:0]
+	for _, st := range statements {
+		if _, ok := order[string(st.terms[0])]; !ok {
+			order[string(st.terms[0])] = len(order)
+		}
+	}
+	if len(order) < 2 {
+		return
+	}
+
+	groups := make([][]statement, len(order))
+	for _, st := range statements {
+		i := order[string(st.terms[0])]
+		groups[i] = append(groups[i], st)
+	}
+	grouped := statements[:0:0]
+	for _, group := range groups {
+		grouped = append(grouped, group...)
+	}
+	copy(statements, grouped)
+}
+
+// prefixDirective parses the prefix name and IRI of a prefix declaration
+func (p *turtleParser) prefixDirective() {
+	p.skipSpace()
+	prefix := ""
+	if p.peek() != ':' {
+		prefix = p.prefixName()
+	}
+	p.expect(':')
+	p.skipSpace()
+	if p.peek() != '<' {
+		p.fail("expected the IRI of prefix %v", prefix)
+	}
+	p.prefixes[prefix] = p.iriRef()
+}
+
+// baseDirective parses the IRI of a base declaration
+func (p *turtleParser) baseDirective() {
+	p.skipSpace()
+	if p.peek() != '<' {
+		p.fail("expected the base IRI")
+	}
+	p.base = p.iriRef()
+}
+
+// predicateObjectList parses the predicates and objects of a subject and queues the statements
+func (p *turtleParser) predicateObjectList(subject string) {
+	for {
+		predicate := p.verb()
+		for {
+			object := p.object()
+			p.emit(subject, predicate, object)
+			p.skipSpace()
+			if p.peek() != ',' {
+				break
+			}
+			p.read()
+		}
+
+		if p.peek() != ';' {
+			return
+		}
+		for p.peek() == ';' {
+			p.read()
+			p.skipSpace()
+		}
+		if r := p.peek(); r == '.' || r == ']' || r == '}' || r == eof {
+			return
+		}
+	}
+}
+
+// verb parses a predicate or the keyword 'a'
+func (p *turtleParser) verb() string {
+	p.skipSpace()
+	r := p.peek()
+	if r == '<' {
+		return "<" + EscapeIRI(p.iriRef()) + ">"
+	}
+	if isPNCharsBase(r) || r == ':' {
+		name, keyword := p.prefixedNameOrKeyword()
+		if name != "" {
+			return name
+		}
+		if keyword == "a" {
+			return rdfType
+		}
+		p.fail("unexpected keyword %v", keyword)
+	}
+	p.fail("expected a predicate, found %v", describe(r))
+	return ""
+}
+
+// subjectKind tells whether a subject may label a graph and whether it may stand without predicates
+type subjectKind int
+
+const (
+	labelSubject        subjectKind = iota // an IRI, a labeled blank node or []
+	propertyListSubject                    // a blank node property list
+	collectionSubject
+)
+
+// subject parses the subject of a triples statement or the label of a graph
+func (p *turtleParser) subject() (term string, kind subjectKind) {
+	p.skipSpace()
+	switch r := p.peek(); {
+	case r == '<':
+		return "<" + EscapeIRI(p.iriRef()) + ">", labelSubject
+	case r == '_':
+		return p.blankNodeLabel(), labelSubject
+	case r == '[':
+		p.read()
+		p.skipSpace()
+		if p.peek() == ']' {
+			p.read()
+			return p.newBlankNode(), labelSubject
+		}
+		node := p.newBlankNode()
+		p.predicateObjectList(node)
+		p.expect(']')
+		return node, propertyListSubject
+	case r == '(':
+		return p.collection(), collectionSubject
+	case isPNCharsBase(r) || r == ':':
+		name, keyword := p.prefixedNameOrKeyword()
+		if name == "" {
+			p.fail("unexpected keyword %v", keyword)
+		}
+		return name, labelSubject
+	default:
+		p.fail("expected a subject, found %v", describe(r))
+		return "", labelSubject
+	}
+}
+
+// object parses an object and queues the statements of nested blank nodes and collections
+func (p *turtleParser) object() string {
+	p.skipSpace()
+	switch r := p.peek(); {
+	case r == '<':
+		return "<" + EscapeIRI(p.iriRef()) + ">"
+	case r == '_':
+		return p.blankNodeLabel()
+	case r == '[':
+		p.read()
+		node := p.newBlankNode()
+		p.skipSpace()
+		if p.peek() != ']' {
+			p.predicateObjectList(node)
+		}
+		p.expect(']')
+		return node
+	case r == '(':
+		return p.collection()
+	case r == '"' || r == '\'':
+		return p.literal()
+	case r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.':
+		return p.numericLiteral()
+	case isPNCharsBase(r) || r == ':':
+		name, keyword := p.prefixedNameOrKeyword()
+		if name != "" {
+			return name
+		}
+		if keyword == "true" || keyword == "false" {
+			return `"` + keyword + `"^^<` + xsd + "boolean>"
+		}
+		p.fail("unexpected keyword %v", keyword)
+	}
+	p.fail("expected an object, found %v", describe(p.peek()))
+	return ""
+}
+
+// collection parses a collection and queues the statements of its list nodes
+func (p *turtleParser) collection() string {
+	p.read() // '('
+	var items []string
+	for {
+		p.skipSpace()
+		if p.peek() == ')' {
+			p.read()
+			break
+		}
+		items = append(items, p.object())
+	}
+
+	head := rdfNil
+	if len(items) > 0 {
+		head = p.newBlankNode()
+	}
+	for i, node := 0, head; i < len(items); i++ {
+		next := rdfNil
+		if i < len(items)-1 {
+			next = p.newBlankNode()
+		}
+		p.emit(node, rdfFirst, items[i])
+		p.emit(node, rdfRest, next)
+		node = next
+	}
+	return head
+}
+
+// newBlankNode returns a new blank node term. The labels of the document are escaped by blankNodeLabel
+// such that they cannot clash with these.
+func (p *turtleParser) newBlankNode() string {
+	p.blankNodes++
+	node := "_:genid" + strconv.FormatUint(p.blankNodes, 10)
+	p.created = append(p.created, node)
+	return node
+}
+
+// blankNodeLabel parses a BLANK_NODE_LABEL. Labels starting with 'x' or 'genid' are prefixed with 'x'
+// to keep them apart from generated blank nodes. The scope of the document is put in front, which starts
+// with neither.
+func (p *turtleParser) blankNodeLabel() string {
+	p.read() // '_'
+	if p.read() != ':' {
+		p.fail("blank node labels must start with '_:'")
+	}
+
+	var label strings.Builder
+	r := p.read()
+	if !(isPNCharsBase(r) || r == '_' || r >= '0' && r <= '9') {
+		p.fail("invalid first character %v of a blank node label", describe(r))
+	}
+	label.WriteRune(r)
+	p.nameRest(&label, func(r rune) bool { return isPNCharsBase(r) || r == '_' || isPNCharsExtra(r) })
+
+	if l := label.String(); strings.HasPrefix(l, "x") || strings.HasPrefix(l, "genid") {
+		return "_:" + p.scope + "x" + l
+	}
+	return "_:" + p.scope + label.String()
+}
+
+// nameRest reads the characters of a name that may contain, but not end with '.'
+func (p *turtleParser) nameRest(name *strings.Builder, valid func(r rune) bool) {
+	dots := 0
+	for {
+		r := p.read()
+		if r == '.' {
+			dots++
+			continue
+		}
+		if !valid(r) {
+			p.unread(r)
+			for ; dots > 0; dots-- {
+				p.unread('.')
+			}
+			return
+		}
+		for ; dots > 0; dots-- {
+			name.WriteByte('.')
+		}
+		name.WriteRune(r)
+	}
+}
+
+// keyword reads a word of letters
+func (p *turtleParser) keyword() string {
+	var word strings.Builder
+	r := p.read()
+	for ; r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'; r = p.read() {
+		word.WriteRune(r)
+	}
+	p.unread(r)
+	return word.String()
+}
+
+// prefixName reads a PN_PREFIX
+func (p *turtleParser) prefixName() string {
+	var name strings.Builder
+	r := p.read()
+	if !isPNCharsBase(r) {
+		p.fail("invalid first character %v of a prefix", describe(r))
+	}
+	name.WriteRune(r)
+	p.nameRest(&name, func(r rune) bool { return isPNCharsBase(r) || r == '_' || isPNCharsExtra(r) })
+	return name.String()
+}
+
+// prefixedNameOrKeyword reads a prefixed name and returns it as IRI term. If the name is not followed by
+// ':', it is returned as keyword instead.
+func (p *turtleParser) prefixedNameOrKeyword() (term string, keyword string) {
+	prefix := ""
+	if p.peek() != ':' {
+		prefix = p.prefixName()
+	}
+	if p.peek() != ':' {
+		return "", prefix
+	}
+	p.read()
+
+	namespace, ok := p.prefixes[prefix]
+	if !ok {
+		p.fail("undeclared prefix %v", prefix)
+	}
+	return "<" + EscapeIRI(namespace+p.localName()) + ">", ""
+}
+
+// localName reads a PN_LOCAL and decodes its escape sequences; percent-encodings are kept
+func (p *turtleParser) localName() string {
+	var name strings.Builder
+	dots := 0 // dots are only part of the name if they are followed by another character
+	for {
+		r := p.read()
+		switch {
+		case r == '.' && name.Len() > 0:
+			dots++
+			continue
+		case r == '\\':
+			if r = p.read(); !strings.ContainsRune("_~.-!$&'()*+,;=/?#@%", r) {
+				p.fail("invalid escape sequence \\%v in local name", string(r))
+			}
+		case r == '%':
+			hex := string([]rune{r, p.read(), p.read()})
+			if len(hex) != 3 || !isHex(hex[1]) || !isHex(hex[2]) {
+				p.fail("invalid percent-encoding %v in local name", hex)
+			}
+			for ; dots > 0; dots-- {
+				name.WriteByte('.')
+			}
+			name.WriteString(hex)
+			continue
+		case isPNCharsBase(r) || r == '_' || r == ':' || r >= '0' && r <= '9':
+		case isPNCharsExtra(r) && name.Len() > 0:
+		default:
+			p.unread(r)
+			for ; dots > 0; dots-- {
+				p.unread('.')
+			}
+			return name.String()
+		}
+		for ; dots > 0; dots-- {
+			name.WriteByte('.')
+		}
+		name.WriteRune(r)
+	}
+}
+
+// iriRef reads an IRIREF, decodes its escape sequences and resolves it against the base IRI
+func (p *turtleParser) iriRef() string {
+	p.read() // '<'
+	var iri strings.Builder
+	for {
+		r := p.read()
+		switch {
+		case r == '>':
+			return p.resolve(iri.String())
+		case r == '\\':
+			iri.WriteRune(p.uchar(p.read()))
+		case r == eof || r <= 0x20 || strings.ContainsRune("<\"{}|^`", r):
+			p.fail("character %v is not allowed in an IRI", describe(r))
+		default:
+			iri.WriteRune(r)
+		}
+	}
+}
+
+// uchar reads the digits of a \u or \U escape sequence
+func (p *turtleParser) uchar(kind rune) rune {
+	digits := 0
+	switch kind {
+	case 'u':
+		digits = 4
+	case 'U':
+		digits = 8
+	default:
+		p.fail("invalid escape sequence \\%v", string(kind))
+	}
+
+	var code rune
+	for i := 0; i < digits; i++ {
+		r := p.read()
+		value, err := strconv.ParseUint(string(r), 16, 8)
+		if r == eof || err != nil {
+			p.fail("invalid hex digit %v in escape sequence", describe(r))
+		}
+		code = code<<4 | rune(value)
+	}
+	if !utf8.ValidRune(code) {
+		p.fail("escape sequence of invalid code point %X", code)
+	}
+	return code
+}
+
+// literal reads a quoted literal with its language tag or datatype
+func (p *turtleParser) literal() string {
+	quote := p.read()
+	long := false
+	if p.peek() == quote {
+		p.read()
+		if p.peek() != quote {
+			return p.literalSuffix("") // empty string
+		}
+		p.read()
+		long = true
+	}
+
+	var text strings.Builder
+	for {
+		r := p.read()
+		switch {
+		case r == eof:
+			p.fail("unterminated literal")
+		case r == quote && !long:
+			return p.literalSuffix(text.String())
+		case r == quote:
+			// the literal ends with three quotes; quotes before them belong to the literal
+			quotes := 1
+			for ; quotes < 3 && p.peek() == quote; quotes++ {
+				p.read()
+			}
+			if quotes == 3 {
+				return p.literalSuffix(text.String())
+			}
+			text.WriteString(strings.Repeat(string(quote), quotes))
+		case r == '\\':
+			r = p.read()
+			if c, ok := echars[byte(r)]; ok && r < utf8.RuneSelf {
+				text.WriteByte(c)
+			} else {
+				text.WriteRune(p.uchar(r))
+			}
+		case (r == '\n' || r == '\r') && !long:
+			p.fail("line breaks have to be escaped in literals")
+		default:
+			text.WriteRune(r)
+		}
+	}
+}
+
+// literalSuffix reads the language tag or datatype of a literal and returns the literal term
+func (p *turtleParser) literalSuffix(text string) string {
+	term := `"` + escapeLiteral(text) + `"`
+	switch p.peek() {
+	case '@':
+		p.read()
+		var lang strings.Builder
+		valid := func(r rune) bool { return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' }
+		for r := p.read(); ; r = p.read() {
+			if valid(r) {
+				lang.WriteRune(r)
+			} else if r == '-' && lang.Len() > 0 {
+				lang.WriteRune(r)
+				valid = func(r rune) bool { return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' }
+			} else {
+				p.unread(r)
+				break
+			}
+		}
+		if tag := lang.String(); tag == "" || strings.HasSuffix(tag, "-") || strings.Contains(tag, "--") {
+			p.fail("invalid language tag @%v", tag)
+		}
+		return term + "@" + lang.String()
+	case '^':
+		p.read()
+		if p.read() != '^' {
+			p.fail("datatypes are written as ^^<IRI> or ^^prefix:name")
+		}
+		if p.peek() == '<' {
+			return term + "^^<" + EscapeIRI(p.iriRef()) + ">"
+		}
+		name, keyword := p.prefixedNameOrKeyword()
+		if name == "" {
+			p.fail("unexpected keyword %v", keyword)
+		}
+		return term + "^^" + name
+	}
+	return term
+}
+
+// numericLiteral reads an integer, decimal or double literal
+func (p *turtleParser) numericLiteral() string {
+	var number strings.Builder
+	digits := func() int {
+		n := 0
+		r := p.read()
+		for ; r >= '0' && r <= '9'; r = p.read() {
+			number.WriteRune(r)
+			n++
+		}
+		p.unread(r)
+		return n
+	}
+
+	if r := p.peek(); r == '+' || r == '-' {
+		number.WriteRune(p.read())
+	}
+	datatype := "integer"
+	intDigits := digits()
+	if p.peek() == '.' {
+		p.read()
+		if r := p.peek(); r >= '0' && r <= '9' {
+			number.WriteByte('.')
+			digits()
+			datatype = "decimal"
+		} else if (r == 'e' || r == 'E') && intDigits > 0 {
+			number.WriteByte('.')
+		} else {
+			p.unread('.') // the end of the statement
+		}
+	}
+	if r := p.peek(); r == 'e' || r == 'E' {
+		number.WriteRune(p.read())
+		if r := p.peek(); r == '+' || r == '-' {
+			number.WriteRune(p.read())
+		}
+		if digits() == 0 {
+			p.fail("missing exponent in number %v", number.String())
+		}
+		datatype = "double"
+	}
+
+	if text := number.String(); strings.TrimLeft(text, "+-") == "" || text[len(text)-1] == '+' || text[len(text)-1] == '-' {
+		p.fail("invalid number %v", text)
+	}
+	return `"` + number.String() + `"^^<` + xsd + datatype + ">"
+}
+
+// emit queues a statement of the current graph
+func (p *turtleParser) emit(subject, predicate, object string) {
+	terms := [4]string{subject, predicate, object, p.graph}
+	length := 2 // " ."
+	for _, term := range terms {
+		if term != "" {
+			length += len(term) + 1
+		}
+	}
+
+	st := statement{line: make([]byte, 0, length)}
+	for i, term := range terms {
+		if term == "" {
+			continue
+		}
+		if i > 0 {
+			st.line = append(st.line, ' ')
+		}
+		start := len(st.line)
+		st.line = append(st.line, term...)
+		st.terms[i] = st.line[start:len(st.line):len(st.line)]
+	}
+	st.line = append(st.line, " ."...)
+	p.queue = append(p.queue, st)
+}
+
+// resolve resolves an IRI reference against the base IRI according to RFC 3986, section 5.2
+func (p *turtleParser) resolve(ref string) string {
+	if isAbsoluteIRI([]byte(ref)) {
+		return removeDotSegmentsOfIRI(ref)
+	}
+	if p.base == "" {
+		p.fail("relative IRI <%v> without base IRI", ref)
+	}
+
+	r := iriParts(ref)
+	b := iriParts(p.base)
+	var t iri
+	switch {
+	case r.hasAuthority:
+		t = iri{scheme: b.scheme, hasAuthority: true, authority: r.authority, path: removeDotSegments(r.path), hasQuery: r.hasQuery, query: r.query}
+	case r.path == "":
+		t = iri{scheme: b.scheme, hasAuthority: b.hasAuthority, authority: b.authority, path: b.path, hasQuery: b.hasQuery, query: b.query}
+		if r.hasQuery {
+			t.hasQuery, t.query = true, r.query
+		}
+	default:
+		t = iri{scheme: b.scheme, hasAuthority: b.hasAuthority, authority: b.authority, hasQuery: r.hasQuery, query: r.query}
+		if strings.HasPrefix(r.path, "/") {
+			t.path = removeDotSegments(r.path)
+		} else if b.hasAuthority && b.path == "" {
+			t.path = removeDotSegments("/" + r.path)
+		} else {
+			t.path = removeDotSegments(b.path[:strings.LastIndex(b.path, "/")+1] + r.path)
+		}
+	}
+	t.hasFragment, t.fragment = r.hasFragment, r.fragment
+	return t.String()
+}
+
+// iri holds the components of an IRI reference
+type iri struct {
+	scheme, authority, path, query, fragment string
+	hasAuthority, hasQuery, hasFragment      bool
+}
+
+// iriPattern splits an IRI reference into its components, c.f. RFC 3986, appendix B
+var iriPattern = regexp.MustCompile(`^(([^:/?#]+):)?(//([^/?#]*))?([^?#]*)(\?([^#]*))?(#(.*))?$`)
+
+func iriParts(ref string) iri {
+	m := iriPattern.FindStringSubmatch(ref)
+	return iri{
+		scheme: m[2], hasAuthority: m[3] != "", authority: m[4], path: m[5],
+		hasQuery: m[6] != "", query: m[7], hasFragment: m[8] != "", fragment: m[9],
+	}
+}
+
+func (t iri) String() string {
+	var s strings.Builder
+	if t.scheme != "" {
+		s.WriteString(t.scheme + ":")
+	}
+	if t.hasAuthority {
+		s.WriteString("//" + t.authority)
+	}
+	s.WriteString(t.path)
+	if t.hasQuery {
+		s.WriteString("?" + t.query)
+	}
+	if t.hasFragment {
+		s.WriteString("#" + t.fragment)
+	}
+	return s.String()
+}
+
+// removeDotSegmentsOfIRI removes the dot segments of the path of an absolute IRI
+func removeDotSegmentsOfIRI(ref string) string {
+	if !strings.Contains(ref, "/.") {
+		return ref
+	}
+	t := iriParts(ref)
+	t.path = removeDotSegments(t.path)
+	return t.String()
+}
+
+// removeDotSegments removes the segments '.' and '..' of a path, c.f. RFC 3986, section 5.2.4
+func removeDotSegments(path string) string {
+	var output []string
+	for path != "" {
+		switch {
+		case strings.HasPrefix(path, "../"):
+			path = path[3:]
+		case strings.HasPrefix(path, "./"):
+			path = path[2:]
+		case strings.HasPrefix(path, "/./"):
+			path = path[2:]
+		case path == "/.":
+			path = "/"
+		case strings.HasPrefix(path, "/../"):
+			path = path[3:]
+			if len(output) > 0 {
+				output = output[:len(output)-1]
+			}
+		case path == "/..":
+			path = "/"
+			if len(output) > 0 {
+				output = output[:len(output)-1]
+			}
+		case path == "." || path == "..":
+			path = ""
+		default:
+			end := strings.IndexByte(path[1:], '/') + 1
+			if end == 0 {
+				end = len(path)
+			}
+			output = append(output, path[:end])
+			path = path[end:]
+		}
+	}
+	return strings.Join(output, "")
+}
+
+// EscapeIRI escapes the characters that are not allowed in an IRIREF of N-Triples as UCHAR sequences
+func EscapeIRI(iri string) string {
+	if strings.IndexFunc(iri, func(r rune) bool { return r < utf8.RuneSelf && iriForbidden[r] }) < 0 {
+		return iri
+	}
+	var escaped strings.Builder
+	for _, r := range iri {
+		if r < utf8.RuneSelf && iriForbidden[r] {
+			fmt.Fprintf(&escaped, `\u%04X`, r)
+		} else {
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
+
+// literalEscaper escapes the characters that are not allowed in a STRING_LITERAL_QUOTE of N-Triples
+var literalEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+func escapeLiteral(text string) string {
+	return literalEscaper.Replace(text)
+}
@@ -0,0 +1,238 @@
+package io
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseTurtle converts a document located at /data/doc to N-Quads lines, which are checked against the
+// N-Quads grammar
+func parseTurtle(t *testing.T, document string, trig bool) ([]string, error) {
+	parser := newTurtleParser(strings.NewReader(document), "/data/doc", trig)
+	var lines []string
+	for {
+		st, err := parser.next()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return lines, err
+		}
+		terms, err := ParseStatement(st.line, 3, true)
+		if err != nil || string(terms[0]) != string(st.terms[0]) || string(terms[3]) != string(st.terms[3]) {
+			t.Errorf("Invalid statement %v: %v", string(st.line), err)
+		}
+		lines = append(lines, string(st.line))
+	}
+}
+
+var turtleCases = []struct {
+	name     string
+	document string
+	expected []string
+}{
+	{"triple", "<http://ex/s> <http://ex/p> <http://ex/o> .",
+		[]string{"<http://ex/s> <http://ex/p> <http://ex/o> ."}},
+	{"prefixes", "@prefix ex: <http://ex/> .\nPREFIX : <http://default/>\nex:s :p ex:o.",
+		[]string{"<http://ex/s> <http://default/p> <http://ex/o> ."}},
+	{"local names", "@prefix ex: <http://ex/> .\nex:a.b ex:c\\~d ex:%20e, ex:, ex:1 .",
+		[]string{
+			"<http://ex/a.b> <http://ex/c~d> <http://ex/%20e> .",
+			"<http://ex/a.b> <http://ex/c~d> <http://ex/> .",
+			"<http://ex/a.b> <http://ex/c~d> <http://ex/1> .",
+		}},
+	{"base", "<s> <#p> <../o> .\n@base <http://ex/a/b> .\n<s> <//host/p> <?q> .\nBASE <c/>\n<d> <p> </e> .",
+		[]string{
+			"<file:///data/s> <file:///data/doc#p> <file:///o> .",
+			"<http://ex/a/s> <http://host/p> <http://ex/a/b?q> .",
+			"<http://ex/a/c/d> <http://ex/a/c/p> <http://ex/e> .",
+		}},
+	{"predicate and object lists", "<http://ex/s> a <http://ex/T> ; <http://ex/p> <http://ex/o1> , <http://ex/o2> ;; .",
+		[]string{
+			"<http://ex/s> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://ex/T> .",
+			"<http://ex/s> <http://ex/p> <http://ex/o1> .",
+			"<http://ex/s> <http://ex/p> <http://ex/o2> .",
+		}},
+	{"blank nodes", "_:a <http://ex/p> _:xb, _:genid1, [] .",
+		[]string{
+			"_:a <http://ex/p> _:xxb .",
+			"_:a <http://ex/p> _:xgenid1 .",
+			"_:a <http://ex/p> _:genid1 .",
+		}},
+	{"blank node property lists", "<http://ex/s> <http://ex/p> [ <http://ex/q> [ <http://ex/r> 1 ] ] ; <http://ex/t> 2 .\n[ <http://ex/p> 3 ] .\n[] <http://ex/p> 4 .",
+		[]string{
+			"<http://ex/s> <http://ex/p> _:genid1 .",
+			"<http://ex/s> <http://ex/t> \"2\"^^<http://www.w3.org/2001/XMLSchema#integer> .",
+			"_:genid1 <http://ex/q> _:genid2 .",
+			"_:genid2 <http://ex/r> \"1\"^^<http://www.w3.org/2001/XMLSchema#integer> .",
+			"_:genid3 <http://ex/p> \"3\"^^<http://www.w3.org/2001/XMLSchema#integer> .",
+			"_:genid4 <http://ex/p> \"4\"^^<http://www.w3.org/2001/XMLSchema#integer> .",
+		}},
+	{"collections", "<http://ex/s> <http://ex/p> ( <http://ex/a> \"b\" ) , () .",
+		[]string{
+			"<http://ex/s> <http://ex/p> _:genid1 .",
+			"<http://ex/s> <http://ex/p> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .",
+			"_:genid1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> <http://ex/a> .",
+			"_:genid1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:genid2 .",
+			"_:genid2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> \"b\" .",
+			"_:genid2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .",
+		}},
+	{"literals", `@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+<http://ex/s> <http://ex/p> "a\"b", 'c\'d'@en-GB, """e
+"f"g""", '''g''h''', "é"^^xsd:string, ""^^<http://ex/T> .`,
+		[]string{
+			`<http://ex/s> <http://ex/p> "a\"b" .`,
+			`<http://ex/s> <http://ex/p> "c'd"@en-GB .`,
+			`<http://ex/s> <http://ex/p> "e\n\"f\"g" .`,
+			`<http://ex/s> <http://ex/p> "g''h" .`,
+			`<http://ex/s> <http://ex/p> "é"^^<http://www.w3.org/2001/XMLSchema#string> .`,
+			`<http://ex/s> <http://ex/p> ""^^<http://ex/T> .`,
+		}},
+	{"numbers and booleans", "<http://ex/s> <http://ex/p> -1, +2.5, .5, 1e3, 1.E-2, true, false.",
+		[]string{
+			`<http://ex/s> <http://ex/p> "-1"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+			`<http://ex/s> <http://ex/p> "+2.5"^^<http://www.w3.org/2001/XMLSchema#decimal> .`,
+			`<http://ex/s> <http://ex/p> ".5"^^<http://www.w3.org/2001/XMLSchema#decimal> .`,
+			`<http://ex/s> <http://ex/p> "1e3"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+			`<http://ex/s> <http://ex/p> "1.E-2"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+			`<http://ex/s> <http://ex/p> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .`,
+			`<http://ex/s> <http://ex/p> "false"^^<http://www.w3.org/2001/XMLSchema#boolean> .`,
+		}},
+	{"comments and escapes in IRIs", "# comment\n<http://ex/\\u00E9> <http://ex/p> <http://ex/\\u0020> . # comment",
+		[]string{`<http://ex/é> <http://ex/p> <http://ex/\u0020> .`}},
+}
+
+func TestTurtle(t *testing.T) {
+	for _, c := range turtleCases {
+		t.Run(c.name, func(t *testing.T) {
+			lines, err := parseTurtle(t, c.document, false)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, lines)
+		})
+	}
+}
+
+func TestTriG(t *testing.T) {
+	document := `@prefix ex: <http://ex/> .
+ex:s ex:p ex:o .
+ex:g { ex:s ex:p ex:o1 . ex:s ex:q [ ex:r ex:o2 ] }
+GRAPH _:g { ex:s ex:p ex:o3 }
+{ ex:s ex:p ex:o4 . }
+[] { ex:s ex:p ex:o5 }`
+	lines, err := parseTurtle(t, document, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"<http://ex/s> <http://ex/p> <http://ex/o> .",
+		"<http://ex/s> <http://ex/p> <http://ex/o1> <http://ex/g> .",
+		"<http://ex/s> <http://ex/q> _:genid1 <http://ex/g> .",
+		"_:genid1 <http://ex/r> <http://ex/o2> <http://ex/g> .",
+		"<http://ex/s> <http://ex/p> <http://ex/o3> _:g .",
+		"<http://ex/s> <http://ex/p> <http://ex/o4> .",
+		"<http://ex/s> <http://ex/p> <http://ex/o5> _:genid2 .",
+	}, lines)
+}
+
+func TestTurtleSyntaxErrors(t *testing.T) {
+	documents := []string{
+		"<http://ex/s> <http://ex/p> <http://ex/o>",         // missing dot
+		"<http://ex/s> <http://ex/p> .",                     // missing object
+		"ex:s <http://ex/p> <http://ex/o> .",                // undeclared prefix
+		"@prefix ex: <http://ex/>\nex:s ex:p ex:o .",        // directive without dot
+		"<http://ex/s> \"p\" <http://ex/o> .",               // literal predicate
+		"<http://ex/s> <http://ex/p> \"o\n\" .",             // line break in short string
+		"<http://ex/s> <http://ex/p> \"o\"@ .",              // empty language tag
+		"<http://ex/s> <http://ex/p> <http://ex/o o> .",     // space in IRI
+		"<http://ex/s> <http://ex/p> 1e .",                  // missing exponent
+		"<http://ex/g> { <http://ex/s> <http://ex/p> 1 . }", // graph in Turtle
+		"( <http://ex/a> ) .",                               // collection without predicate
+	}
+	for _, document := range documents {
+		_, err := parseTurtle(t, document, false)
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("Expected a syntax error for %q, got %v", document, err)
+		}
+	}
+
+	// the position of the error is reported
+	_, err := parseTurtle(t, "<http://ex/s> <http://ex/p> <http://ex/o> .\n<http://ex/s> <http://ex/p> ?o .", false)
+	if syntaxErr, ok := err.(*SyntaxError); !ok || syntaxErr.Line != 2 || syntaxErr.Column != 28 {
+		t.Errorf("Expected a syntax error in line 2, column 28, got %#v", err)
+	}
+}
+
+func TestTripleParserTriG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.trig")
+	data := "@prefix ex: <http://ex/> .\nex:s ex:p ex:o .\nex:g { ex:s ex:p ex:o1 }\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := NewTripleParser(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+	parser.Graphs = NewGraphFilter([]string{"http://ex/g"}, nil)
+
+	trip, err := parser.NextTriple()
+	assert.NoError(t, err)
+	assert.Equal(t, "<http://ex/o1>", string(trip.Object))
+	assert.Equal(t, "<http://ex/s> <http://ex/p> <http://ex/o1> <http://ex/g> .", string(trip.Line))
+
+	trip, err = parser.NextTriple()
+	assert.Nil(t, trip)
+	assert.NoError(t, err)
+}
+
+func TestTripleParserTurtleFiles(t *testing.T) {
+	dir := t.TempDir()
+	documents := map[string]string{
+		"a.ttl": "@prefix ex: <http://ex/> .\nex:s ex:p _:b0 , [] .\n_:b0 ex:p <o> .\n",
+		"b.ttl": "@prefix ex: <http://other/> .\nex:s <p> _:b0 , _:xb .\n",
+		"c.ttl": "<http://ex/s> <http://ex/p> ex:o .\n", // the prefix of the other files is not declared
+	}
+	for name, data := range documents {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read := func(name string) (lines []string, err error) {
+		parser, err := NewTripleParser(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer parser.Close()
+		for trip, err := parser.NextTriple(); trip != nil || err != nil; trip, err = parser.NextTriple() {
+			if err != nil {
+				return lines, err
+			}
+			lines = append(lines, string(trip.Line))
+		}
+		return lines, nil
+	}
+
+	// each file has its own prefixes, base and blank node labels
+	lines, err := read(filepath.Join(dir, "[ab].ttl"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"<http://ex/s> <http://ex/p> _:f1_b0 .",
+		"<http://ex/s> <http://ex/p> _:genid1 .",
+		"_:f1_b0 <http://ex/p> <file://" + filepath.ToSlash(dir) + "/o> .",
+		"<http://other/s> <file://" + filepath.ToSlash(dir) + "/p> _:f2_b0 .",
+		"<http://other/s> <file://" + filepath.ToSlash(dir) + "/p> _:f2_xxb .",
+	}, lines)
+
+	_, err = read(dir)
+	assert.Error(t, err, "the prefix ex: is not declared in c.ttl")
+
+	// a single file keeps its labels
+	lines, err = read(filepath.Join(dir, "a.ttl"))
+	assert.NoError(t, err)
+	assert.Equal(t, "<http://ex/s> <http://ex/p> _:b0 .", lines[0])
+}
@@ -0,0 +1,153 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Wikidata publishes dumps of all entities in JSON (https://www.wikidata.org/wiki/Wikidata:Database_download#JSON_dumps_(recommended)).
+// The dump is a JSON array with one entity per line, so the claims of each entity are already grouped.
+
+// IRI prefixes of Wikidata entities and of the truthy properties, as in the N-Triples dumps.
+const (
+	WikidataEntityPrefix   = "http://www.wikidata.org/entity/"
+	WikidataPropertyPrefix = "http://www.wikidata.org/prop/direct/"
+)
+
+// Entity is an item or property of a Wikidata JSON dump. Only the fields used by the recommender are decoded.
+type Entity struct {
+	ID           string                   `json:"id"`
+	Type         string                   `json:"type"` // "item" or "property"
+	Labels       map[string]LanguageValue `json:"labels"`
+	Descriptions map[string]LanguageValue `json:"descriptions"`
+	Claims       map[string][]Claim       `json:"claims"` // statements by property ID
+}
+
+// LanguageValue is a label or description in a language.
+type LanguageValue struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// Claim is a statement about an entity.
+type Claim struct {
+	MainSnak Snak   `json:"mainsnak"`
+	Rank     string `json:"rank"` // "preferred", "normal" or "deprecated"
+}
+
+// Snak is the property and value of a claim.
+type Snak struct {
+	SnakType  string `json:"snaktype"` // "value", "somevalue" (unknown value) or "novalue"
+	Property  string `json:"property"`
+	DataValue struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"datavalue"`
+}
+
+// EntityID returns the ID of the item or property the snak refers to, or an empty string if its value is
+// not an entity.
+func (s *Snak) EntityID() string {
+	if s.SnakType != "value" || s.DataValue.Type != "wikibase-entityid" {
+		return ""
+	}
+	var value struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(s.DataValue.Value, &value)
+	return value.ID
+}
+
+// TruthyClaims returns the claims of the property that are in the truthy dumps: the claims of the best rank,
+// i.e. the preferred claims if there are any and the normal claims otherwise, which have a value.
+// Claims without a value ("novalue") are left out, as they do not use the property in the truthy dumps.
+func (e *Entity) TruthyClaims(property string) []Claim {
+	claims := e.Claims[property]
+	best := "normal"
+	for _, claim := range claims {
+		if claim.Rank == "preferred" {
+			best = "preferred"
+			break
+		}
+	}
+
+	var truthy []Claim
+	for _, claim := range claims {
+		if claim.Rank == best && claim.MainSnak.SnakType != "novalue" {
+			truthy = append(truthy, claim)
+		}
+	}
+	return truthy
+}
+
+// EntityReader reads the entities of a Wikidata JSON dump, or of a file with one JSON entity per line.
+type EntityReader struct {
+	reader  io.ReadCloser
+	scanner *bufio.Reader
+}
+
+// NewEntityReader opens a Wikidata JSON dump.
+func NewEntityReader(filePath string) (*EntityReader, error) {
+	reader, err := UniversalReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &EntityReader{reader: reader, scanner: bufio.NewReaderSize(reader, 4*1024*1024)}, nil
+}
+
+// NextJSON returns the undecoded JSON object of the next entity, or io.EOF at the end of the dump. The
+// slice is not reused, so it can be decoded concurrently by DecodeEntity.
+func (er *EntityReader) NextJSON() ([]byte, error) {
+	for {
+		line, err := er.scanner.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+
+		// skip the brackets of the array and the commas between the entities
+		line = bytes.TrimRight(bytes.TrimSpace(line), ",")
+		if len(line) == 0 || (len(line) == 1 && (line[0] == '[' || line[0] == ']')) {
+			continue
+		}
+		return line, nil
+	}
+}
+
+// Next returns the next entity of the dump, or io.EOF at its end.
+func (er *EntityReader) Next() (*Entity, error) {
+	line, err := er.NextJSON()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeEntity(line)
+}
+
+// DecodeEntity decodes the JSON object of an entity.
+func DecodeEntity(data []byte) (*Entity, error) {
+	entity := &Entity{}
+	if err := json.Unmarshal(data, entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// DecodeClaims decodes the ID, type and claims of an entity, but not its labels and descriptions, which
+// saves time for readers that only need the claims.
+func DecodeClaims(data []byte) (*Entity, error) {
+	var entity struct {
+		ID     string             `json:"id"`
+		Type   string             `json:"type"`
+		Claims map[string][]Claim `json:"claims"`
+	}
+	if err := json.Unmarshal(data, &entity); err != nil {
+		return nil, err
+	}
+	return &Entity{ID: entity.ID, Type: entity.Type, Claims: entity.Claims}, nil
+}
+
+// Close closes the dump.
+func (er *EntityReader) Close() error {
+	return er.reader.Close()
+}
@@ -0,0 +1,42 @@
+package io
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wikidataDump is a dump with an item and a property, in the format of the Wikidata JSON dumps
+var wikidataDump = "../testdata/wikidata.json"
+
+func TestEntityReader(t *testing.T) {
+	reader, err := NewEntityReader(wikidataDump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	item, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "Q42", item.ID)
+	assert.Equal(t, "Douglas Adams", item.Labels["en"].Value)
+
+	types := item.TruthyClaims("P31")
+	assert.Len(t, types, 1)
+	assert.Equal(t, "Q5", types[0].MainSnak.EntityID())
+
+	names := item.TruthyClaims("P735") // the preferred claim only
+	assert.Len(t, names, 1)
+	assert.Equal(t, "Q463035", names[0].MainSnak.EntityID())
+	assert.Empty(t, item.TruthyClaims("P1412")) // deprecated
+	assert.Empty(t, item.TruthyClaims("P40"))   // no value
+
+	property, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "property", property.Type)
+	assert.Equal(t, "ist ein(e)", property.Labels["de"].Value)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
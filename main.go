@@ -3,15 +3,20 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
+	"github.com/lgleim/SchemaTreeRecommender/batch"
 	"github.com/lgleim/SchemaTreeRecommender/configuration"
 	"github.com/lgleim/SchemaTreeRecommender/glossary"
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
 	"github.com/lgleim/SchemaTreeRecommender/preparation"
 	"github.com/lgleim/SchemaTreeRecommender/schematree"
 	"github.com/lgleim/SchemaTreeRecommender/server"
+	"github.com/lgleim/SchemaTreeRecommender/shell"
 	"github.com/lgleim/SchemaTreeRecommender/strategy"
 
 	"time"
@@ -21,6 +26,7 @@ import (
 	"runtime/trace"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func main() {
@@ -34,13 +40,75 @@ func main() {
 	var firstNsubjects int64                     // used by build-tree
 	var writeOutPropertyFreqs bool               // used by build-tree
 	var serveOnPort int                          // used by serve
-	var workflowFile string                      // used by serve
+	var workflowFile, typeWorkflowFile string    // used by serve, recommend-batch and shell
+	var cacheSize, precomputeSize int            // used by serve and build-tree
+	var unknownPolicy, unknownMappingFile string // used by serve
+	var prefixFile string                        // used by build-tree, serve and shell
+	var topK int                                 // used by recommend-batch
+	var outputFormat, outputFile string          // used by recommend-batch
+	var anomalyThreshold float64                 // used by detect-anomalies
+	var anomalyMinSupport uint64                 // used by detect-anomalies
 	var contiguousInput bool                     // used by split-dataset:by-type
 	var everyNthSubject uint                     // used by split-dataset:1-in-n
+	var includeGraphs, excludeGraphs []string    // used by build-tree, build-glossary, split-dataset and filter-dataset
+	var groupSubjects bool                       // used by build-tree
+	var sortMemory int                           // used by build-tree
+	var sortDirectory string                     // used by build-tree
+	var regroupingPolicy string                  // used by build-tree
+	var compression string                       // used by split-dataset and filter-dataset
+	var filteredFile string                      // used by filter-dataset
+	var stdinFormat string                       // used globally
 
 	// Setup helper variables
 	var timeCheckpoint time.Time // used globally
 
+	// readerOptions collects the flags of the build commands that change how the dataset is read
+	readerOptions := func() schematree.ReaderOptions {
+		regrouping, err := schematree.ParseRegroupingPolicy(regroupingPolicy)
+		if err != nil {
+			log.Panicln(err)
+		}
+		return schematree.ReaderOptions{
+			Graphs:        rio.NewGraphFilter(includeGraphs, excludeGraphs),
+			GroupSubjects: groupSubjects,
+			SortMemory:    sortMemory * 1024 * 1024,
+			SortDirectory: sortDirectory,
+			Regrouping:    regrouping,
+		}
+	}
+
+	// dataset returns the name of the dataset argument, with the extensions of --stdin-format for stdin
+	dataset := func(arg string) *string {
+		if arg == rio.Stdio && stdinFormat != "" {
+			arg = rio.Stdio + "." + strings.TrimPrefix(stdinFormat, ".")
+		}
+		return &arg
+	}
+
+	// outputCompression returns the extension of the compression of the split and filtered files, which are
+	// compressed with gzip by default unless they are written to stdout. Unknown compressions are fatal.
+	outputCompression := func(toStdout bool) string {
+		if compression == "none" || (compression == "" && toStdout) {
+			return ""
+		} else if compression == "" {
+			return ".gz"
+		}
+		ext := "." + strings.TrimPrefix(compression, ".")
+		if err := rio.CheckCompression(ext); err != nil {
+			log.Fatalln(err)
+		}
+		return ext
+	}
+
+	// filterOutput returns the output file of the filter commands and the writer of their messages. If the
+	// dataset is written to stdout, the messages are printed to stderr instead.
+	filterOutput := func() (string, io.Writer) {
+		if filteredFile != rio.Stdio {
+			return filteredFile, os.Stdout
+		}
+		return rio.Stdio + outputCompression(true), os.Stderr
+	}
+
 	// writeOutPropertyFreqs := flag.Bool("writeOutPropertyFreqs", false, "set this to write the frequency of all properties to a csv after first pass or schematree loading")
 
 	// root command
@@ -119,29 +187,30 @@ func main() {
 	cmdRoot.PersistentFlags().StringVar(&memprofile, "memprofile", "", "write memory profile to `file`")
 	cmdRoot.PersistentFlags().StringVar(&traceFile, "trace", "", "write execution trace to `file`")
 	cmdRoot.PersistentFlags().BoolVarP(&measureTime, "time", "t", false, "measure time of command execution")
+	cmdRoot.PersistentFlags().StringVar(&stdinFormat, "stdin-format", "", "read the dataset '-' from stdin as `extensions` of its format and compression, e.g. nq.gz or zst (default: uncompressed N-Triples)")
 
 	// subcommand build-tree
 	cmdBuildTree := &cobra.Command{
 		Use:   "build-tree <dataset>",
 		Short: "Build the SchemaTree model",
 		Long: "A SchemaTree model will be built using the file provided in <dataset>." +
-			" The dataset should be a N-Triple of Items.\nTwo output files will be" +
+			" The dataset should be a N-Triple of Items, an HDT file (.hdt), or a Wikidata JSON dump (.json).\nTwo output files will be" +
 			" generated in the same directory as <dataset> and with suffixed names, namely:" +
 			" '<dataset>.firstPass.bin' and '<dataset>.schemaTree.bin'",
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Create the tree output file by using the input dataset.
-			schema, err := schematree.Create(*inputDataset, uint64(firstNsubjects), false, 0)
+			schema, err := schematree.Create(*inputDataset, uint64(firstNsubjects), false, 0, precomputeSize, readerOptions())
 			if err != nil {
 				log.Panicln(err)
 			}
 
 			if writeOutPropertyFreqs {
-				propFreqsPath := *inputDataset + ".propertyFreqs.csv"
-				schema.WritePropFreqs(propFreqsPath)
+				propFreqsPath := rio.DatasetName(*inputDataset) + ".propertyFreqs.csv"
+				schema.WritePropFreqs(propFreqsPath, readPrefixes(prefixFile, false))
 				fmt.Printf("Wrote PropertyFreqs to %s\n", propFreqsPath)
 			}
 
@@ -154,33 +223,38 @@ func main() {
 		&writeOutPropertyFreqs, "write-frequencies", "f", false,
 		"write all property frequencies to a csv file named '<dataset>.propertyFreqs.csv' after the SchemaTree is built",
 	)
+	cmdBuildTree.Flags().StringVar(&prefixFile, "prefixes", "", "write the frequencies with CURIEs using the prefix declarations in `file`")
+	cmdBuildTree.Flags().IntVar(&precomputeSize, "precompute", 0, "precompute the recommendations of the `n` most frequent property sets and save them with the model")
+	addGraphFlags(cmdBuildTree.Flags(), &includeGraphs, &excludeGraphs)
+	addSortFlags(cmdBuildTree.Flags(), &groupSubjects, &sortMemory, &sortDirectory, &regroupingPolicy)
 
 	// subcommand build-tree
 	cmdBuildTreeTyped := &cobra.Command{
 		Use:   "build-tree-typed <dataset>",
 		Short: "Build the SchemaTree model with types",
 		Long: "A SchemaTree model will be built using the file provided in <dataset>." +
-			" The dataset should be a N-Triple of Items.\nTwo output files will be" +
+			" The dataset should be a N-Triple of Items, an HDT file (.hdt), or a Wikidata JSON dump (.json).\nTwo output files will be" +
 			" generated in the same directory as <dataset> and with suffixed names, namely:" +
 			" '<dataset>.firstPass.bin' and '<dataset>.schemaTree.typed.bin'",
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Create the tree output file by using the input dataset.
-			schema, err := schematree.Create(*inputDataset, uint64(firstNsubjects), true, 0)
+			schema, err := schematree.Create(*inputDataset, uint64(firstNsubjects), true, 0, precomputeSize, readerOptions())
 			if err != nil {
 				log.Panicln(err)
 			}
 
 			if writeOutPropertyFreqs {
-				propFreqsPath := *inputDataset + ".propertyFreqs.csv"
-				schema.WritePropFreqs(propFreqsPath)
+				propFreqsPath := rio.DatasetName(*inputDataset) + ".propertyFreqs.csv"
+				prefixes := readPrefixes(prefixFile, false)
+				schema.WritePropFreqs(propFreqsPath, prefixes)
 				fmt.Printf("Wrote PropertyFreqs to %s\n", propFreqsPath)
 
-				typeFreqsPath := *inputDataset + ".typeFreqs.csv"
-				schema.WriteTypeFreqs(typeFreqsPath)
+				typeFreqsPath := rio.DatasetName(*inputDataset) + ".typeFreqs.csv"
+				schema.WriteTypeFreqs(typeFreqsPath, prefixes)
 				fmt.Printf("Wrote PropertyFreqs to %s\n", typeFreqsPath)
 			}
 
@@ -193,33 +267,39 @@ func main() {
 		&writeOutPropertyFreqs, "write-frequencies", "f", false,
 		"write all property frequencies to a csv file named '<dataset>.propertyFreqs.csv' after the SchemaTree is built",
 	)
+	cmdBuildTreeTyped.Flags().StringVar(&prefixFile, "prefixes", "", "write the frequencies with CURIEs using the prefix declarations in `file`")
+	cmdBuildTreeTyped.Flags().IntVar(&precomputeSize, "precompute", 0, "precompute the recommendations of the `n` most frequent property sets and save them with the model")
+	addGraphFlags(cmdBuildTreeTyped.Flags(), &includeGraphs, &excludeGraphs)
+	addSortFlags(cmdBuildTreeTyped.Flags(), &groupSubjects, &sortMemory, &sortDirectory, &regroupingPolicy)
 
 	// subcommand build-glossary
 	cmdBuildGlossary := &cobra.Command{
 		Use:   "build-glossary <dataset>",
 		Short: "Build the Glossary that maps properties to multi-lingual descriptions",
 		Long: "A Glossary will be built using the file provided in <dataset>. The input" +
-			" file should be a N-Triple of Property entries.\nThe output file will be" +
+			" file should be a N-Triple of Property entries, or a Wikidata JSON dump (.json).\nThe output file will be" +
 			" generated in the same directory as <dataset> with the name:" +
 			" '<dataset>.glossary.bin'",
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Build the glossary
-			glos, stats, err := glossary.BuildGlossary(*inputDataset)
+			glos, stats, err := glossary.BuildGlossary(*inputDataset, rio.NewGraphFilter(includeGraphs, excludeGraphs))
 			if err != nil {
 				log.Panicln(err)
 			}
 
 			// Store it in the same directory with 'glossary.bin' extension
-			glos.WriteToFile(*inputDataset + ".glossary.bin")
+			glos.WriteToFile(rio.DatasetName(*inputDataset) + ".glossary.bin")
 			fmt.Printf("%+v\n", stats)
 			//glos.OutputStats()
 		},
 	}
 
+	addGraphFlags(cmdBuildGlossary.Flags(), &includeGraphs, &excludeGraphs)
+
 	// subcommand serve
 	cmdServe := &cobra.Command{
 		Use:   "serve <model> <glossary>",
@@ -231,6 +311,17 @@ func main() {
 			modelBinary := &args[0]
 			glossaryBinary := &args[1]
 
+			// Check the options before the model is loaded.
+			unknown := schematree.UnknownHandling{}
+			var err error
+			unknown.Policy, err = schematree.ParseUnknownPolicy(unknownPolicy)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if unknown.Policy == schematree.MapUnknown && unknownMappingFile == "" {
+				log.Fatalln("--unknown map requires a mapping, given by --unknown-mapping")
+			}
+
 			// Load the schematree from the binary file.
 			model, err := schematree.Load(*modelBinary)
 			if err != nil {
@@ -238,6 +329,9 @@ func main() {
 			}
 			schematree.PrintMemUsage()
 
+			// Memoize the recommendations of frequent queries if requested.
+			model.EnableCache(cacheSize, precomputeSize)
+
 			// Load the glossary from the binary file.
 			glos, err := glossary.ReadFromFile(*glossaryBinary)
 			if err != nil {
@@ -248,26 +342,32 @@ func main() {
 			// if no config file is given, the standard recommender is set as workflow.
 			var workflow *strategy.Workflow
 			if workflowFile != "" {
-				config, err := configuration.ReadConfigFile(&workflowFile)
-				if err != nil {
-					log.Panicln(err)
-				}
-				err = config.Test()
-				if err != nil {
-					log.Panicln(err)
-				}
-				workflow, err = configuration.ConfigToWorkflow(config, model)
-				if err != nil {
-					log.Panicln(err)
-				}
+				workflow = readWorkflow(workflowFile, model)
 				log.Printf("Run Config Workflow %v", workflowFile)
 			} else {
 				workflow = strategy.MakePresetWorkflow("direct", model)
 				fmt.Printf("Run Standard Recommender ")
 			}
 
+			// Same for the workflow of the type recommender.
+			var typeWorkflow *strategy.Workflow
+			if typeWorkflowFile != "" {
+				typeWorkflow = readWorkflow(typeWorkflowFile, model)
+				log.Printf("Run Config Type Workflow %v", typeWorkflowFile)
+			} else {
+				typeWorkflow = strategy.MakePresetWorkflow("types", model)
+			}
+
+			// Read the replacements of input properties that are unknown to the model.
+			if unknownMappingFile != "" {
+				unknown.Mapping, err = configuration.ReadUnknownMapping(unknownMappingFile)
+				if err != nil {
+					log.Panicln(err)
+				}
+			}
+
 			// Initiate the HTTP server. Make it stop on <Enter> press.
-			router := server.SetupEndpoints(model, glos, workflow, 500)
+			router := server.SetupEndpoints(model, glos, workflow, typeWorkflow, 500, unknown, readPrefixes(prefixFile, true))
 			fmt.Printf("Now listening on 0.0.0.0:%v\n", serveOnPort)
 			http.ListenAndServe(fmt.Sprintf("0.0.0.0:%v", serveOnPort), router)
 
@@ -280,6 +380,12 @@ func main() {
 	// cmdBuildTree.MarkFlagRequired("load")
 	cmdServe.Flags().IntVarP(&serveOnPort, "port", "p", 8080, "`port` of http server")
 	cmdServe.Flags().StringVarP(&workflowFile, "workflow", "w", "", "`path` to config file that defines the workflow")
+	cmdServe.Flags().StringVar(&unknownPolicy, "unknown", "ignore", "how to treat unknown input properties: ignore, error or map (requires --unknown-mapping)")
+	cmdServe.Flags().StringVar(&unknownMappingFile, "unknown-mapping", "", "`path` to a json file that maps unknown properties to known ones")
+	cmdServe.Flags().StringVar(&typeWorkflowFile, "type-workflow", "", "`path` to config file that defines the workflow of the type recommender")
+	cmdServe.Flags().IntVar(&cacheSize, "cache", 0, "memoize the recommendations of the last `n` distinct inputs")
+	cmdServe.Flags().StringVar(&prefixFile, "prefixes", "", "`path` to a file with the prefix declarations of the CURIEs accepted by the endpoints (default: common Wikidata and RDF prefixes)")
+	cmdServe.Flags().IntVar(&precomputeSize, "precompute", 0, "precompute the recommendations of the `n` most frequent property sets on startup, instead of those saved with the model by build-tree")
 
 	// subcommand visualize
 	cmdBuildDot := &cobra.Command{
@@ -312,6 +418,143 @@ func main() {
 		},
 	}
 
+	// subcommand shell
+	cmdShell := &cobra.Command{
+		Use:   "shell <model> [glossary]",
+		Short: "Explore a SchemaTree model interactively",
+		Long: "Load the <model> (schematree binary) and optionally the <glossary> (glossary binary) and start an" +
+			" interactive prompt to add and remove properties and types and to show the resulting recommendations" +
+			" with their labels and support. Type 'help' in the prompt for a list of commands.",
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			modelBinary := &args[0]
+
+			// Load the schematree from the binary file.
+			model, err := schematree.Load(*modelBinary)
+			if err != nil {
+				log.Panicln(err)
+			}
+
+			// Load the glossary from the binary file, if given.
+			var glos *glossary.Glossary
+			if len(args) > 1 {
+				glos, err = glossary.ReadFromFile(args[1])
+				if err != nil {
+					log.Panicln(err)
+				}
+			}
+
+			sh := shell.New(model, glos, readPrefixes(prefixFile, true))
+			if workflowFile != "" {
+				sh.Execute("workflow "+workflowFile, os.Stdout)
+			}
+			if err = sh.Run(shell.DefaultHistoryFile()); err != nil {
+				log.Panicln(err)
+			}
+		},
+	}
+	cmdShell.Flags().StringVarP(&workflowFile, "workflow", "w", "", "`path` to config file that defines the initial workflow")
+	cmdShell.Flags().StringVar(&prefixFile, "prefixes", "", "`path` to a file with the prefix declarations of the CURIEs to use (default: common Wikidata and RDF prefixes)")
+
+	// subcommand recommend-batch
+	cmdRecommendBatch := &cobra.Command{
+		Use:   "recommend-batch <model> <dataset>",
+		Short: "Recommend properties for all subjects of a dataset",
+		Long: "Load the SchemaTree <model> and run the workflow on the properties of every subject in the" +
+			" N-Triple <dataset>. The top-k recommendations of each subject are written to a file in the same" +
+			" directory as <dataset>, named '<dataset>.recommendations.jsonl' (one json object per subject) or" +
+			" '<dataset>.recommendations.nt' (suggestion statements), depending on the format.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			modelBinary := &args[0]
+			inputDataset := dataset(args[1])
+
+			format, err := batch.ParseFormat(outputFormat)
+			if err != nil {
+				log.Panicln(err)
+			}
+
+			// Load the schematree from the binary file.
+			model, err := schematree.Load(*modelBinary)
+			if err != nil {
+				log.Panicln(err)
+			}
+
+			// read config file if given as parameter, otherwise the standard recommender is used.
+			var workflow *strategy.Workflow
+			if workflowFile != "" {
+				workflow = readWorkflow(workflowFile, model)
+			} else {
+				workflow = strategy.MakePresetWorkflow("direct", model)
+			}
+
+			outputPath := outputFile
+			if outputPath == "" {
+				outputPath = rio.DatasetName(*inputDataset) + ".recommendations." + format.Extension()
+			}
+			f, err := os.Create(outputPath)
+			if err != nil {
+				log.Panicln(err)
+			}
+			out := bufio.NewWriter(f)
+
+			subjects, err := batch.Recommend(model, workflow, *inputDataset, topK, format, out)
+			if err != nil {
+				log.Panicln(err)
+			}
+			if err = out.Flush(); err != nil {
+				log.Panicln(err)
+			}
+			if err = f.Close(); err != nil {
+				log.Panicln(err)
+			}
+			fmt.Printf("Wrote recommendations for %v subjects to %s\n", subjects, outputPath)
+		},
+	}
+	cmdRecommendBatch.Flags().StringVarP(&workflowFile, "workflow", "w", "", "`path` to config file that defines the workflow")
+	cmdRecommendBatch.Flags().IntVarP(&topK, "top", "k", 10, "write the top `k` recommendations of each subject")
+	cmdRecommendBatch.Flags().StringVar(&outputFormat, "format", "jsonl", "output `format`: jsonl or nt")
+	cmdRecommendBatch.Flags().StringVarP(&outputFile, "output", "o", "", "write the recommendations to `file` instead")
+
+	// subcommand detect-anomalies
+	cmdDetectAnomalies := &cobra.Command{
+		Use:   "detect-anomalies <model> <dataset>",
+		Short: "Flag improbable properties of the subjects in a dataset",
+		Long: "Load the SchemaTree <model> and compute for every property of every subject in the N-Triple" +
+			" <dataset> how likely it is given the other properties of that subject. Properties that are" +
+			" less likely than the threshold are written to a csv file in the same directory as <dataset>," +
+			" named '<dataset>.anomalies.csv', with the columns subject;property;probability;support;setSupport.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			modelBinary := &args[0]
+			inputDataset := dataset(args[1])
+
+			// Load the schematree from the binary file.
+			model, err := schematree.Load(*modelBinary)
+			if err != nil {
+				log.Panicln(err)
+			}
+
+			outputPath := rio.DatasetName(*inputDataset) + ".anomalies.csv"
+			f, err := os.Create(outputPath)
+			if err != nil {
+				log.Panicln(err)
+			}
+			out := bufio.NewWriter(f)
+
+			subjects, flagged := model.DetectAnomalies(*inputDataset, anomalyThreshold, anomalyMinSupport, out)
+			if err = out.Flush(); err != nil {
+				log.Panicln(err)
+			}
+			if err = f.Close(); err != nil {
+				log.Panicln(err)
+			}
+			fmt.Printf("Flagged %v properties of %v subjects in %s\n", flagged, subjects, outputPath)
+		},
+	}
+	cmdDetectAnomalies.Flags().Float64Var(&anomalyThreshold, "threshold", 0.01, "flag properties with a probability below `p`")
+	cmdDetectAnomalies.Flags().Uint64Var(&anomalyMinSupport, "min-support", 10, "only flag properties if at least `n` subjects have the other properties")
+
 	// subcommand split-dataset
 	cmdSplitDataset := &cobra.Command{
 		Use:   "split-dataset",
@@ -322,6 +565,9 @@ func main() {
 		Args: cobra.NoArgs,
 	}
 
+	addGraphFlags(cmdSplitDataset.PersistentFlags(), &includeGraphs, &excludeGraphs)
+	addCompressionFlag(cmdSplitDataset.PersistentFlags(), &compression)
+
 	// subsubcommand split-dataset by-type
 	cmdSplitDatasetByType := &cobra.Command{
 		Use:   "by-type <dataset>",
@@ -334,15 +580,15 @@ func main() {
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Make the split
 			var sStats *preparation.SplitByTypeStats
 			var err error
 			if contiguousInput {
-				sStats, err = preparation.SplitByTypeInBlocks(*inputDataset)
+				sStats, err = preparation.SplitByTypeInBlocks(*inputDataset, rio.NewGraphFilter(includeGraphs, excludeGraphs), outputCompression(false))
 			} else {
-				sStats, err = preparation.SplitByType(*inputDataset)
+				sStats, err = preparation.SplitByType(*inputDataset, rio.NewGraphFilter(includeGraphs, excludeGraphs), outputCompression(false))
 			}
 			if err != nil {
 				log.Panicln(err)
@@ -370,10 +616,10 @@ func main() {
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Make the split
-			sStats, err := preparation.SplitByPrefix(*inputDataset)
+			sStats, err := preparation.SplitByPrefix(*inputDataset, rio.NewGraphFilter(includeGraphs, excludeGraphs), outputCompression(false))
 			if err != nil {
 				log.Panicln(err)
 			}
@@ -400,8 +646,11 @@ func main() {
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
-			preparation.SplitBySampling(*inputDataset, int64(everyNthSubject))
+			inputDataset := dataset(args[0])
+			err := preparation.SplitBySampling(*inputDataset, int64(everyNthSubject), rio.NewGraphFilter(includeGraphs, excludeGraphs), outputCompression(false))
+			if err != nil {
+				log.Panicln(err)
+			}
 		},
 	}
 	cmdSplitDatasetBySampling.Flags().UintVarP(&everyNthSubject, "nth", "n", 1000, "split every N-th subject")
@@ -414,6 +663,10 @@ func main() {
 		Args:  cobra.NoArgs,
 	}
 
+	addGraphFlags(cmdFilterDataset.PersistentFlags(), &includeGraphs, &excludeGraphs)
+	addCompressionFlag(cmdFilterDataset.PersistentFlags(), &compression)
+	cmdFilterDataset.PersistentFlags().StringVarP(&filteredFile, "output", "o", "", "write the filtered dataset to `file`, or to stdout with '-' (default: <base>-filtered.nt.gz next to the dataset)")
+
 	// subsubcommand filter-dataset for-schematree
 	cmdFilterDatasetForSchematree := &cobra.Command{
 		Use:   "for-schematree <dataset>",
@@ -424,19 +677,20 @@ func main() {
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Execute the filter
-			sStats, err := preparation.FilterForSchematree(*inputDataset)
+			output, messages := filterOutput()
+			sStats, err := preparation.FilterForSchematree(*inputDataset, rio.NewGraphFilter(includeGraphs, excludeGraphs), outputCompression(false), output, messages)
 			if err != nil {
 				log.Panicln(err)
 			}
 
 			// Prepare and output the stats for it
 			totalCount := float64(sStats.KeptCount + sStats.LostCount)
-			fmt.Println("Filter dataset for schematree:")
-			fmt.Printf("  kept: %d (%f)\n", sStats.KeptCount, float64(sStats.KeptCount)/totalCount)
-			fmt.Printf("  lost: %d (%f)\n", sStats.LostCount, float64(sStats.LostCount)/totalCount)
+			fmt.Fprintln(messages, "Filter dataset for schematree:")
+			fmt.Fprintf(messages, "  kept: %d (%f)\n", sStats.KeptCount, float64(sStats.KeptCount)/totalCount)
+			fmt.Fprintf(messages, "  lost: %d (%f)\n", sStats.LostCount, float64(sStats.LostCount)/totalCount)
 
 		},
 	}
@@ -451,19 +705,20 @@ func main() {
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Execute the filter
-			sStats, err := preparation.FilterForGlossary(*inputDataset)
+			output, messages := filterOutput()
+			sStats, err := preparation.FilterForGlossary(*inputDataset, rio.NewGraphFilter(includeGraphs, excludeGraphs), outputCompression(false), output, messages)
 			if err != nil {
 				log.Panicln(err)
 			}
 
 			// Prepare and output the stats for it
 			totalCount := float64(sStats.KeptCount + sStats.LostCount)
-			fmt.Println("Filter dataset for glossary:")
-			fmt.Printf("  kept: %d (%f)\n", sStats.KeptCount, float64(sStats.KeptCount)/totalCount)
-			fmt.Printf("  lost: %d (%f)\n", sStats.LostCount, float64(sStats.LostCount)/totalCount)
+			fmt.Fprintln(messages, "Filter dataset for glossary:")
+			fmt.Fprintf(messages, "  kept: %d (%f)\n", sStats.KeptCount, float64(sStats.KeptCount)/totalCount)
+			fmt.Fprintf(messages, "  lost: %d (%f)\n", sStats.LostCount, float64(sStats.LostCount)/totalCount)
 
 		},
 	}
@@ -478,19 +733,20 @@ func main() {
 		Args: cobra.ExactArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
-			inputDataset := &args[0]
+			inputDataset := dataset(args[0])
 
 			// Execute the filter
-			sStats, err := preparation.FilterForEvaluation(*inputDataset)
+			output, messages := filterOutput()
+			sStats, err := preparation.FilterForEvaluation(*inputDataset, rio.NewGraphFilter(includeGraphs, excludeGraphs), outputCompression(false), output, messages)
 			if err != nil {
 				log.Panicln(err)
 			}
 
 			// Prepare and output the stats for it
 			totalCount := float64(sStats.KeptCount + sStats.LostCount)
-			fmt.Println("Filter dataset for evaluation:")
-			fmt.Printf("  kept: %d (%f)\n", sStats.KeptCount, float64(sStats.KeptCount)/totalCount)
-			fmt.Printf("  lost: %d (%f)\n", sStats.LostCount, float64(sStats.LostCount)/totalCount)
+			fmt.Fprintln(messages, "Filter dataset for evaluation:")
+			fmt.Fprintf(messages, "  kept: %d (%f)\n", sStats.KeptCount, float64(sStats.KeptCount)/totalCount)
+			fmt.Fprintf(messages, "  lost: %d (%f)\n", sStats.LostCount, float64(sStats.LostCount)/totalCount)
 
 		},
 	}
@@ -509,12 +765,65 @@ func main() {
 	cmdRoot.AddCommand(cmdBuildGlossary)
 	cmdRoot.AddCommand(cmdServe)
 	cmdRoot.AddCommand(cmdBuildDot)
+	cmdRoot.AddCommand(cmdRecommendBatch)
+	cmdRoot.AddCommand(cmdShell)
+	cmdRoot.AddCommand(cmdDetectAnomalies)
 
 	// Start the CLI application
 	cmdRoot.Execute()
 
 }
 
+// readWorkflow reads the config file, tests if everything needed is there and creates the workflow.
+func readWorkflow(workflowFile string, model *schematree.SchemaTree) *strategy.Workflow {
+	config, err := configuration.ReadConfigFile(&workflowFile)
+	if err != nil {
+		log.Panicln(err)
+	}
+	err = config.Test()
+	if err != nil {
+		log.Panicln(err)
+	}
+	workflow, err := configuration.ConfigToWorkflow(config, model)
+	if err != nil {
+		log.Panicln(err)
+	}
+	return workflow
+}
+
+// readPrefixes reads the prefix registry from the given file. Without a file, the default prefixes are
+// returned if `useDefault` is set and nil otherwise.
+func readPrefixes(prefixFile string, useDefault bool) *rio.PrefixRegistry {
+	if prefixFile == "" {
+		if useDefault {
+			return rio.DefaultPrefixes()
+		}
+		return nil
+	}
+	prefixes, err := rio.ReadPrefixFile(prefixFile)
+	if err != nil {
+		log.Panicln(err)
+	}
+	return prefixes
+}
+
+// addGraphFlags adds the flags to include and exclude graphs of N-Quads datasets to a command.
+func addGraphFlags(flags *pflag.FlagSet, include, exclude *[]string) {
+	flags.StringSliceVar(include, "include-graph", nil, "only read the statements of the named graph `iri` of N-Quads datasets ('default' for the default graph), can be repeated")
+	flags.StringSliceVar(exclude, "exclude-graph", nil, "skip the statements of the named graph `iri` of N-Quads datasets ('default' for the default graph), can be repeated")
+}
+
+func addSortFlags(flags *pflag.FlagSet, group *bool, memory *int, directory *string, regrouping *string) {
+	flags.BoolVar(group, "group-subjects", false, "sort the statements by subject first, for datasets that are not grouped by subject")
+	flags.IntVar(memory, "sort-memory", rio.DefaultSortMemory/1024/1024, "sort `n` MiB of statements in memory before writing them to temporary files (with --group-subjects)")
+	flags.StringVar(directory, "temp-dir", "", "write the temporary files of --group-subjects to `directory` (default: the temporary directory of the system)")
+	flags.StringVar(regrouping, "check-grouping", "ignore", "how to treat subjects that appear again after other subjects: ignore, report or error")
+}
+
+func addCompressionFlag(flags *pflag.FlagSet, compression *string) {
+	flags.StringVar(compression, "compression", "", "compress the output files with `format`: gz, bgz, zst, xz or none (default: gz, none for stdout)")
+}
+
 func waitForReturn() {
 	buf := bufio.NewReader(os.Stdin)
 	fmt.Print("> ")
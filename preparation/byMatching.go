@@ -17,7 +17,9 @@ type SplitByPrefixStats struct {
 // SplitByPrefix will take a dataset and decide where to send it to based on a match of the
 // beginning of the subject.
 // Matches can be of following: item, property, other/miscellaneous
-func SplitByPrefix(filePath string) (*SplitByPrefixStats, error) {
+// Only the statements of the graphs selected by the filter are considered; nil selects all.
+// The output files get the compression extension, e.g. ".gz" or ".zst", and are compressed accordingly.
+func SplitByPrefix(filePath string, graphs *recIO.GraphFilter, compression string) (_ *SplitByPrefixStats, err error) {
 	stats := SplitByPrefixStats{}
 
 	// Setup attributes of the wikidata ontology
@@ -34,6 +36,7 @@ func SplitByPrefix(filePath string) (*SplitByPrefixStats, error) {
 		return nil, err
 	}
 	defer tParser.Close()
+	tParser.Graphs = graphs
 
 	// Open 3 files, one to nest each type.
 	const (
@@ -42,18 +45,22 @@ func SplitByPrefix(filePath string) (*SplitByPrefixStats, error) {
 		propBlock = iota
 	)
 	fileBase := recIO.TrimExtensions(filePath)
+	ext := recIO.DataExtension(filePath)
 
-	itemFile := recIO.CreateAndOpenWithGzip(fileBase + "-item.nt.gz")
-	defer itemFile.Close()
-
-	propFile := recIO.CreateAndOpenWithGzip(fileBase + "-prop.nt.gz")
-	defer propFile.Close()
-
-	miscFile := recIO.CreateAndOpenWithGzip(fileBase + "-misc.nt.gz")
-	defer miscFile.Close()
+	writers, err := createWriters(fileBase+"-item"+ext+compression, fileBase+"-prop"+ext+compression, fileBase+"-misc"+ext+compression)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := closeWriters(writers); err == nil {
+			err = closeErr
+		}
+	}()
+	itemFile, propFile, miscFile := writers[0], writers[1], writers[2]
 
 	// Go through all entries and decide on a line-by-line basis.
-	for trip, err := tParser.NextTriple(1); trip != nil && err == nil; trip, err = tParser.NextTriple(1) {
+	var trip *recIO.Triple
+	for trip, err = tParser.NextTriple(1); trip != nil && err == nil; trip, err = tParser.NextTriple(1) {
 
 		// We can check for equality in the first bytes instead of using actual regex or unicode.
 		if startsWithOneOf(trip.Subject, wdItemSubjects) {
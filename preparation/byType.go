@@ -16,7 +16,9 @@ type SplitByTypeStats struct {
 
 // SplitByType will take a dataset and generate smaller datasets for each subject type it finds.
 // Types can be of following: item, property, other/miscellaneous.
-func SplitByType(filePath string) (*SplitByTypeStats, error) {
+// Only the statements of the graphs selected by the filter are considered; nil selects all.
+// The output files get the compression extension, e.g. ".gz" or ".zst", and are compressed accordingly.
+func SplitByType(filePath string, graphs *recIO.GraphFilter, compression string) (_ *SplitByTypeStats, err error) {
 	stats := SplitByTypeStats{}
 
 	// Setup attributes of the wikidata ontology
@@ -33,7 +35,7 @@ func SplitByType(filePath string) (*SplitByTypeStats, error) {
 	// Define a N-Triple parser for the input file.
 	// The method will open the file twice because of the multiple read-interfaces applied on file.
 	var tParser *recIO.TripleParser
-	var err error
+	var trip *recIO.Triple
 
 	// Define the possible types.
 	const (
@@ -42,16 +44,23 @@ func SplitByType(filePath string) (*SplitByTypeStats, error) {
 		propBlock = iota
 	)
 
+	// The standard input is copied to a temporary file, so that it can be read twice.
+	dataset, removeDataset, err := recIO.SpoolStdin(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer removeDataset()
+
 	// Open the file for the first passthrough.
-	tParser, err = recIO.NewTripleParser(filePath)
+	tParser, err = recIO.NewTripleParser(dataset)
 	if err != nil {
-		tParser.Close()
 		return nil, err
 	}
+	tParser.Graphs = graphs
 
 	// Will perform one pass on the entire file to identify subjects and categorize them.
 	subjectTypeMap := map[string]int{}
-	for trip, err := tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
+	for trip, err = tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
 
 		// Identify if this entry is trying to describe a type, and get that type.
 		// Only register the mapping if the type is 'item' or 'prop. We do not need to store mappings for 'misc'.
@@ -72,22 +81,27 @@ func SplitByType(filePath string) (*SplitByTypeStats, error) {
 
 	// Open 3 files, one to nest each type.
 	fileBase := recIO.TrimExtensions(filePath)
-	itemFile := recIO.CreateAndOpenWithGzip(fileBase + "-item.nt.gz")
-	defer itemFile.Close()
-	propFile := recIO.CreateAndOpenWithGzip(fileBase + "-prop.nt.gz")
-	defer propFile.Close()
-	miscFile := recIO.CreateAndOpenWithGzip(fileBase + "-misc.nt.gz")
-	defer miscFile.Close()
+	ext := recIO.DataExtension(filePath)
+	writers, err := createWriters(fileBase+"-item"+ext+compression, fileBase+"-prop"+ext+compression, fileBase+"-misc"+ext+compression)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := closeWriters(writers); err == nil {
+			err = closeErr
+		}
+	}()
+	itemFile, propFile, miscFile := writers[0], writers[1], writers[2]
 
 	// Open the file for the second passthrough.
-	tParser, err = recIO.NewTripleParser(filePath)
+	tParser, err = recIO.NewTripleParser(dataset)
 	if err != nil {
-		tParser.Close()
 		return nil, err
 	}
+	tParser.Graphs = graphs
 
 	// On the second pass, go through all entries and send them to their file according to the mapping.
-	for trip, err := tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
+	for trip, err = tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
 		mapType, mapOk := subjectTypeMap[string(trip.Subject)]
 
 		// Mapped items are guaranteed to have correct block. Misc if no mapping found.
@@ -125,10 +139,11 @@ func SplitByType(filePath string) (*SplitByTypeStats, error) {
 
 // SplitByTypeInBlocks is a faster implementation of SplitByType, using only a single pass, but assumes
 // that subjects are always found in contiguous lines.
+// The output files get the compression extension, e.g. ".gz" or ".zst", and are compressed accordingly.
 //
 // TODO: Maybe there is a need to remove the type-classifying predicates. It that happens
 //       then it should be made as an optional argument.
-func SplitByTypeInBlocks(filePath string) (*SplitByTypeStats, error) {
+func SplitByTypeInBlocks(filePath string, graphs *recIO.GraphFilter, compression string) (_ *SplitByTypeStats, err error) {
 	stats := SplitByTypeStats{}
 
 	// Setup attributes of the wikidata ontology
@@ -148,6 +163,7 @@ func SplitByTypeInBlocks(filePath string) (*SplitByTypeStats, error) {
 		return nil, err
 	}
 	defer tParser.Close()
+	tParser.Graphs = graphs
 
 	// Open 3 files, one to nest each type.
 	const (
@@ -156,15 +172,18 @@ func SplitByTypeInBlocks(filePath string) (*SplitByTypeStats, error) {
 		propBlock = iota
 	)
 	fileBase := recIO.TrimExtensions(filePath)
+	ext := recIO.DataExtension(filePath)
 
-	itemFile := recIO.CreateAndOpenWithGzip(fileBase + "-item.nt.gz")
-	defer itemFile.Close()
-
-	propFile := recIO.CreateAndOpenWithGzip(fileBase + "-prop.nt.gz")
-	defer propFile.Close()
-
-	miscFile := recIO.CreateAndOpenWithGzip(fileBase + "-misc.nt.gz")
-	defer miscFile.Close()
+	writers, err := createWriters(fileBase+"-item"+ext+compression, fileBase+"-prop"+ext+compression, fileBase+"-misc"+ext+compression)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := closeWriters(writers); err == nil {
+			err = closeErr
+		}
+	}()
+	itemFile, propFile, miscFile := writers[0], writers[1], writers[2]
 
 	// Go through all entries in blocks of subjects. All the entries are stored in a buffer and when a
 	// type predicate is found it is noted so that the code knows where to send the block of entries.
@@ -172,7 +191,8 @@ func SplitByTypeInBlocks(filePath string) (*SplitByTypeStats, error) {
 	var curBlockSubject []byte // subject of the current block, used to check if we proceeded to another block
 	tempCount := 0
 	curBlockType := miscBlock // type of the current block
-	for trip, err := tParser.NextTriple(); err == nil; trip, err = tParser.NextTriple() {
+	var trip *recIO.Triple
+	for trip, err = tParser.NextTriple(); err == nil; trip, err = tParser.NextTriple() {
 
 		// Check if the subject of the block has changed, or it terminated.
 		if trip == nil || !bytes.Equal(curBlockSubject, trip.Subject) {
@@ -2,6 +2,7 @@ package preparation
 
 import (
 	"bytes"
+	"io"
 
 	recIO "github.com/lgleim/SchemaTreeRecommender/io"
 )
@@ -10,7 +11,7 @@ import (
 // usage when building schematrees.
 //
 // todo: In future, such hard-coded predicates should probably not exist.
-func FilterForSchematree(filePath string) (*FilterStats, error) {
+func FilterForSchematree(filePath string, graphs *recIO.GraphFilter, compression, output string, messages io.Writer) (*FilterStats, error) {
 	var removalPredicates = [][]byte{
 		[]byte("<http://www.w3.org/1999/02/22-rdf-syntax-ns#type>"),
 		[]byte("<http://www.w3.org/2000/01/rdf-schema#label>"),
@@ -19,7 +20,7 @@ func FilterForSchematree(filePath string) (*FilterStats, error) {
 		[]byte("<http://schema.org/name>"),
 		[]byte("<http://schema.org/description>"),
 	}
-	return filterByPredicate(filePath, removalPredicates)
+	return filterByPredicate(filePath, graphs, compression, output, messages, removalPredicates)
 }
 
 // FilterForGlossary creates a filtered version of a dataset to make it better for
@@ -33,18 +34,18 @@ func FilterForSchematree(filePath string) (*FilterStats, error) {
 //       statement in our generated file is also used in the construction of the glossary.
 //       With filter-out there can still be many statements that are silently ignored by
 //       the building step.
-func FilterForGlossary(filePath string) (*FilterStats, error) {
+func FilterForGlossary(filePath string, graphs *recIO.GraphFilter, compression, output string, messages io.Writer) (*FilterStats, error) {
 	var removalPredicates = [][]byte{
 		[]byte("<http://www.w3.org/1999/02/22-rdf-syntax-ns#type>"),
 		[]byte("<http://www.w3.org/2000/01/rdf-schema#label>"),
 		[]byte("<http://www.w3.org/2004/02/skos/core#prefLabel>"),
 	}
-	return filterByPredicate(filePath, removalPredicates)
+	return filterByPredicate(filePath, graphs, compression, output, messages, removalPredicates)
 }
 
 // FilterForEvaluation creates a filtered version of a dataset to make it faster when
 // executing the evaluation.
-func FilterForEvaluation(filePath string) (*FilterStats, error) {
+func FilterForEvaluation(filePath string, graphs *recIO.GraphFilter, compression, output string, messages io.Writer) (*FilterStats, error) {
 	var removalPredicates = [][]byte{
 		[]byte("<http://www.w3.org/1999/02/22-rdf-syntax-ns#type>"),
 		[]byte("<http://www.w3.org/2000/01/rdf-schema#label>"),
@@ -53,7 +54,7 @@ func FilterForEvaluation(filePath string) (*FilterStats, error) {
 		[]byte("<http://schema.org/name>"),
 		[]byte("<http://schema.org/description>"),
 	}
-	return filterByPredicate(filePath, removalPredicates)
+	return filterByPredicate(filePath, graphs, compression, output, messages, removalPredicates)
 }
 
 // FilterStats are the stats related to the filter operation.
@@ -64,24 +65,39 @@ type FilterStats struct {
 }
 
 // FilterByPredicate will create a filtered file by removing all entries that contain a predicate
-// listed in the removelPredicates argument.
-func filterByPredicate(filePath string, removalPredicates [][]byte) (*FilterStats, error) {
+// listed in the removelPredicates argument. Entries of graphs that are not selected by the graph
+// filter are removed as well; they are not counted in the stats.
+// The filtered file is written to output, which can be recIO.Stdio, or next to the dataset as
+// <base>-filtered.nt if output is empty. Then it gets the compression extension, e.g. ".gz" or ".zst", and
+// is compressed accordingly. The progress of reading the dataset is displayed on messages.
+func filterByPredicate(filePath string, graphs *recIO.GraphFilter, compression, output string, messages io.Writer, removalPredicates [][]byte) (_ *FilterStats, err error) {
 	stats := FilterStats{}
 
 	// Get a N-Triple parser for the input file.
-	tParser, err := recIO.NewTripleParser(filePath)
+	tParser, err := recIO.NewTripleParserWithMessages(filePath, messages)
 	if err != nil {
 		return nil, err
 	}
 	defer tParser.Close()
+	tParser.Graphs = graphs
 
 	// Open file.
-	fileBase := recIO.TrimExtensions(filePath)
-	filteredFile := recIO.CreateAndOpenWithGzip(fileBase + "-filtered.nt.gz")
-	defer filteredFile.Close()
+	if output == "" {
+		output = recIO.TrimExtensions(filePath) + "-filtered" + recIO.DataExtension(filePath) + compression
+	}
+	filteredFile, err := recIO.CreateWriter(output)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := filteredFile.Close(); err == nil {
+			err = closeErr
+		}
+	}()
 
 	// Go through all entries in blocks of subjects.
-	for trip, err := tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
+	var trip *recIO.Triple
+	for trip, err = tParser.NextTriple(); trip != nil && err == nil; trip, err = tParser.NextTriple() {
 
 		// Check if the subject of the block has changed, or it terminated.
 		toRemove := false
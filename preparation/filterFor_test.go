@@ -0,0 +1,64 @@
+package preparation
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenTurtle has an unterminated literal after two statements
+const brokenTurtle = `@prefix ex: <http://ex/> .
+ex:s1 ex:p ex:o .
+ex:s2 ex:p ex:o .
+ex:s3 ex:p "unterminated .
+ex:s4 ex:p ex:o .
+`
+
+func TestSyntaxErrorsAreReturned(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bad.ttl")
+	if err := os.WriteFile(path, []byte(brokenTurtle), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := FilterForSchematree(path, nil, "", "", os.Stdout)
+	assert.Error(t, err, "FilterForSchematree")
+	_, err = SplitByType(path, nil, "")
+	assert.Error(t, err, "SplitByType")
+	_, err = SplitByTypeInBlocks(path, nil, "")
+	assert.Error(t, err, "SplitByTypeInBlocks")
+	_, err = SplitByPrefix(path, nil, "")
+	assert.Error(t, err, "SplitByPrefix")
+}
+
+func TestWriteErrorsAreReturned(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to fail writes")
+	}
+	path := filepath.Join(t.TempDir(), "test.nt")
+	if err := os.WriteFile(path, []byte("<http://ex/s> <http://ex/p> <http://ex/o> .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the data is buffered and only written when the output is closed
+	_, err := FilterForSchematree(path, nil, "", "/dev/full", os.Stdout)
+	assert.Error(t, err)
+}
+
+func TestFilterMessages(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.nt")
+	if err := os.WriteFile(path, []byte("<http://ex/s> <http://ex/p> <http://ex/o> .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the progress is displayed on the given writer, e.g. on stderr if the output is stdout
+	var messages bytes.Buffer
+	stats, err := FilterForSchematree(path, nil, "", filepath.Join(dir, "filtered.nt"), &messages)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.KeptCount)
+	assert.Contains(t, messages.String(), "Reading data from file '"+path+"'")
+}
@@ -0,0 +1,33 @@
+package preparation
+
+import (
+	"io"
+
+	recIO "github.com/lgleim/SchemaTreeRecommender/io"
+)
+
+// createWriters creates the output files, which are compressed according to their extension (see
+// recIO.CreateWriter). If a file cannot be created, the files created before are closed again.
+func createWriters(paths ...string) ([]io.WriteCloser, error) {
+	writers := make([]io.WriteCloser, 0, len(paths))
+	for _, path := range paths {
+		writer, err := recIO.CreateWriter(path)
+		if err != nil {
+			closeWriters(writers)
+			return nil, err
+		}
+		writers = append(writers, writer)
+	}
+	return writers, nil
+}
+
+// closeWriters closes the output files and returns the first error. Compressed files are only complete
+// after closing, which writes the end of the compressed stream.
+func closeWriters(writers []io.WriteCloser) (err error) {
+	for _, writer := range writers {
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
@@ -0,0 +1,81 @@
+package schematree
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ScoreExisting computes for each item of the given set how likely it is given all the others, i.e. the
+// probability the recommender would assign to it if it was left out of the set. Improbable items are
+// candidates for mistakes or vandalism.
+//
+// The result is sorted ascending by probability, i.e. the most suspicious item comes first. Support is the
+// number of subjects with the whole set and SetSupport the number of subjects with the set without the
+// item. Items that do not occur in the tree are ignored, since the tree cannot judge them.
+func (tree *SchemaTree) ScoreExisting(properties IList) (scored PropertyRecommendations) {
+	known := make(IList, 0, len(properties))
+	for _, p := range properties {
+		if p.traversalPointer != nil {
+			known = append(known, p)
+		}
+	}
+
+	support := uint64(tree.Support(append(IList{}, known...)))
+
+	scored = make(PropertyRecommendations, 0, len(known))
+	others := make(IList, 0, len(known))
+	for i, p := range known {
+		others = append(append(others[:0], known[:i]...), known[i+1:]...)
+		setSupport := uint64(tree.Support(others))
+
+		probability := 0.0
+		if setSupport > 0 {
+			probability = float64(support) / float64(setSupport)
+		}
+		scored = append(scored, RankedPropertyCandidate{
+			Property:    p,
+			Probability: probability,
+			Support:     support,
+			SetSupport:  setSupport,
+		})
+	}
+
+	// sort ascending by probability
+	sort.Slice(scored, func(i, j int) bool { return scored[i].Probability < scored[j].Probability })
+	return
+}
+
+// DetectAnomalies scores the existing properties of every subject in the given N-Triples file (see
+// ScoreExisting) and writes those with a probability below `threshold` to `out` as CSV lines
+// `subject;property;probability;support;setSupport`. Properties whose remaining set has less than
+// `minSupport` subjects are not flagged, since their probability is not meaningful.
+// Subjects are processed in parallel, so the lines are not ordered. It returns the number of read
+// subjects and the number of flagged properties.
+func (tree *SchemaTree) DetectAnomalies(fileName string, threshold float64, minSupport uint64, out io.Writer) (subjects uint64, flagged uint64) {
+	var lock sync.Mutex
+
+	handler := func(s *SubjectSummary) {
+		list := make(IList, 0, len(s.Properties))
+		for p := range s.Properties {
+			list = append(list, p)
+		}
+
+		for _, scored := range tree.ScoreExisting(list) {
+			if scored.Probability >= threshold {
+				break // sorted ascending
+			}
+			if scored.SetSupport < minSupport {
+				continue
+			}
+			lock.Lock()
+			fmt.Fprintf(out, "%v;%v;%v;%v;%v\n", s.Str, scored.Property.IRI(), scored.Probability, scored.Support, scored.SetSupport)
+			flagged++
+			lock.Unlock()
+		}
+	}
+
+	subjects = SubjectSummaryReader(fileName, tree.PropMap, handler, 0, tree.Typed, ReaderOptions{})
+	return
+}
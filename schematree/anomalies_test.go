@@ -0,0 +1,73 @@
+package schematree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreExisting(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+	p31 := pMap.get("http://www.wikidata.org/prop/direct/P31")
+	p17 := pMap.get("http://www.wikidata.org/prop/direct/P17")
+	city := pMap.get("t#http://www.wikidata.org/entity/Q515")
+
+	t.Run("Leave-one-out probabilities", func(t *testing.T) {
+		scored := tree.ScoreExisting(IList{p31, p17, city})
+		assert.Len(t, scored, 3)
+		for i, s := range scored {
+			rest := IList{}
+			for _, p := range (IList{p31, p17, city}) {
+				if p != s.Property {
+					rest = append(rest, p)
+				}
+			}
+			assert.EqualValues(t, tree.Support(rest), s.SetSupport)
+			assert.EqualValues(t, tree.Support(IList{p31, p17, city}), s.Support)
+			if i > 0 {
+				assert.True(t, scored[i-1].Probability <= s.Probability)
+			}
+		}
+	})
+
+	t.Run("Same as recommendation", func(t *testing.T) {
+		recs := tree.RecommendProperty(IList{p31, city})
+		for _, s := range tree.ScoreExisting(IList{p31, p17, city}) {
+			if s.Property == p17 {
+				assert.True(t, recs.contains(p17.Str(), s.Probability-1e-12))
+			}
+		}
+	})
+
+	t.Run("Unknown items are ignored", func(t *testing.T) {
+		unknown := NewItem("http://example.org/unknown")
+		scored := tree.ScoreExisting(IList{p31, unknown})
+		assert.Len(t, scored, 1)
+		assert.Equal(t, p31, scored[0].Property)
+	})
+
+}
+
+func TestDetectAnomalies(t *testing.T) {
+
+	tree, _ := Load("../testdata/test.nt.gz.schemaTree.typed.bin")
+
+	var out bytes.Buffer
+	subjects, flagged := tree.DetectAnomalies("../testdata/test.nt.gz", 1.1, 0, &out)
+	assert.True(t, subjects > 0)
+	assert.True(t, flagged > 0)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.EqualValues(t, flagged, len(lines))
+	for _, line := range lines {
+		assert.Len(t, strings.Split(line, ";"), 5)
+	}
+
+	_, flagged = tree.DetectAnomalies("../testdata/test.nt.gz", 0, 0, &out)
+	assert.Zero(t, flagged)
+
+}
@@ -0,0 +1,199 @@
+package schematree
+
+import (
+	"container/heap"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// recommendationCache is a least-recently-used cache of the recommendations of requested property sets.
+// It complements the table of recommendations that is precomputed for the most frequent prefixes of the
+// tree (which are the most expensive queries, since they have the biggest subtrees to walk), see Precompute.
+// thread-safe
+type recommendationCache struct {
+	capacity int
+	lock     sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List // front is most recently used
+}
+
+type cacheEntry struct {
+	key  string
+	recs PropertyRecommendations
+}
+
+// EnableCache makes RecommendProperty memoize its results in a least-recently-used cache holding up
+// to `capacity` property sets. If `precompute` is positive, the table of precomputed recommendations is
+// replaced by one for the `precompute` most frequent prefixes (see Precompute), otherwise the table that
+// was built with the tree is kept.
+// Calling it again replaces the existing cache; with capacity set to 0 the cache is disabled.
+// The cache assumes that the tree is not modified anymore, i.e. it should be enabled after the tree
+// was created or loaded.
+func (tree *SchemaTree) EnableCache(capacity int, precompute int) {
+	if precompute > 0 {
+		tree.Precompute(precompute)
+	}
+	if capacity <= 0 {
+		tree.cache = nil
+		return
+	}
+
+	tree.cache = &recommendationCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Precompute computes the recommendations for the n most frequent prefixes of the tree (including the
+// empty set), which RecommendProperty returns from then on without walking the tree. The table replaces
+// the one computed before and is saved with the tree; with n set to 0 it is removed.
+func (tree *SchemaTree) Precompute(n int) {
+	if n <= 0 {
+		tree.precomputed = nil
+		return
+	}
+
+	t1 := time.Now()
+	fmt.Printf("Precomputing recommendations for the %v most frequent prefixes... ", n)
+	precomputed := make(map[string]PropertyRecommendations)
+	for _, prefix := range tree.frequentPrefixes(n) {
+		prefix.Sort()
+		precomputed[cacheKey(prefix)] = tree.recommendProperty(prefix)
+	}
+	tree.precomputed = precomputed
+	fmt.Printf("done (%v)\n", time.Since(t1))
+}
+
+// frequentPrefixes returns the property sets on the paths from the root to the n nodes with the highest support.
+// Since the support of a node is never higher than the support of its parent, a best-first search from the
+// root finds those nodes without visiting the entire tree.
+func (tree *SchemaTree) frequentPrefixes(n int) (prefixes []IList) {
+	frontier := &nodeHeap{&tree.Root}
+	for frontier.Len() > 0 && len(prefixes) < n {
+		node := heap.Pop(frontier).(*SchemaNode)
+
+		prefix := IList{}
+		for cur := node; cur.parent != nil; cur = cur.parent {
+			prefix = append(prefix, cur.ID)
+		}
+		prefixes = append(prefixes, prefix)
+
+		for _, child := range node.Children {
+			heap.Push(frontier, child)
+		}
+	}
+	return
+}
+
+// precomputedEntry is the encoding of the recommendations of a precomputed prefix in the saved tree
+type precomputedEntry struct {
+	Key             string // see cacheKey
+	Recommendations []precomputedRecommendation
+}
+
+// precomputedRecommendation is the encoding of a RankedPropertyCandidate, with the property given by its sort order
+type precomputedRecommendation struct {
+	Property    uint32
+	Probability float64
+	Support     uint64
+	SetSupport  uint64
+	Branches    int
+}
+
+// encodePrecomputed returns the precomputed recommendations for saving them with the tree
+func (tree *SchemaTree) encodePrecomputed() []precomputedEntry {
+	entries := make([]precomputedEntry, 0, len(tree.precomputed))
+	for key, recs := range tree.precomputed {
+		entry := precomputedEntry{Key: key, Recommendations: make([]precomputedRecommendation, len(recs))}
+		for i, rec := range recs {
+			entry.Recommendations[i] = precomputedRecommendation{rec.Property.SortOrder, rec.Probability, rec.Support, rec.SetSupport, rec.Branches}
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key }) // deterministic files
+	return entries
+}
+
+// decodePrecomputed restores the precomputed recommendations of a loaded tree, props are the items by sort order
+func (tree *SchemaTree) decodePrecomputed(entries []precomputedEntry, props []*IItem) {
+	tree.precomputed = make(map[string]PropertyRecommendations, len(entries))
+	for _, entry := range entries {
+		recs := make(PropertyRecommendations, len(entry.Recommendations))
+		for i, rec := range entry.Recommendations {
+			recs[i] = RankedPropertyCandidate{
+				Property:    props[rec.Property],
+				Probability: rec.Probability,
+				Support:     rec.Support,
+				SetSupport:  rec.SetSupport,
+				Branches:    rec.Branches,
+			}
+		}
+		tree.precomputed[entry.Key] = recs
+	}
+}
+
+// get returns a copy of the cached recommendations for the given sorted property list
+func (c *recommendationCache) get(key string) (PropertyRecommendations, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*cacheEntry).recs.copy(), true
+	}
+	return nil, false
+}
+
+// add stores a copy of the recommendations and evicts the least recently used entry if the cache is full
+func (c *recommendationCache) add(key string, recs PropertyRecommendations) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.entries[key]; ok { // might have been added by another thread meanwhile
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key, recs.copy()})
+	if c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey builds a map key from a property list. The list *MUST* be sorted.
+func cacheKey(properties IList) string {
+	key := make([]byte, 4*len(properties))
+	for i, p := range properties {
+		binary.LittleEndian.PutUint32(key[4*i:], p.SortOrder)
+	}
+	return string(key)
+}
+
+// copy returns a shallow copy of the recommendations, such that callers may modify the list
+func (ps PropertyRecommendations) copy() PropertyRecommendations {
+	c := make(PropertyRecommendations, len(ps))
+	copy(c, ps)
+	return c
+}
+
+// nodeHeap is a max-heap of schema nodes by support
+type nodeHeap []*SchemaNode
+
+func (h nodeHeap) Len() int            { return len(h) }
+func (h nodeHeap) Less(i, j int) bool  { return h[i].Support > h[j].Support }
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(*SchemaNode)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,102 @@
+package schematree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendationCache(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+	p31 := pMap.get("http://www.wikidata.org/prop/direct/P31")
+	q515 := pMap.get("t#http://www.wikidata.org/entity/Q515")
+
+	t.Run("Frequent prefixes start at the root", func(t *testing.T) {
+		prefixes := tree.frequentPrefixes(5)
+		assert.Len(t, prefixes, 5)
+		assert.Empty(t, prefixes[0])
+		for _, prefix := range prefixes[1:] {
+			assert.True(t, tree.Support(prefix) <= tree.Root.Support)
+		}
+	})
+
+	t.Run("Cached equals uncached", func(t *testing.T) {
+		uncached := tree.RecommendProperty(IList{q515, p31})
+		tree.EnableCache(2, 10)
+		defer tree.EnableCache(0, 0)
+		defer tree.Precompute(0)
+
+		for i := 0; i < 2; i++ { // the second round is answered from the cache
+			cached := tree.RecommendProperty(IList{p31, q515})
+			assert.Len(t, cached, len(uncached))
+			for j := range cached {
+				assert.Equal(t, uncached[j].Probability, cached[j].Probability)
+			}
+		}
+		assert.Equal(t, 1, tree.cache.lru.Len())
+	})
+
+	t.Run("Cached lists can be modified", func(t *testing.T) {
+		tree.EnableCache(1, 1)
+		defer tree.EnableCache(0, 0)
+		defer tree.Precompute(0)
+
+		first := tree.RecommendProperty(IList{})
+		first[0].Probability = -1
+		second := tree.RecommendProperty(IList{})
+		assert.NotEqual(t, -1.0, second[0].Probability)
+	})
+
+	t.Run("Least recently used entry is evicted", func(t *testing.T) {
+		tree.EnableCache(1, 0)
+		defer tree.EnableCache(0, 0)
+
+		tree.RecommendProperty(IList{p31})
+		tree.RecommendProperty(IList{q515})
+		_, ok := tree.cache.get(cacheKey(IList{p31}))
+		assert.False(t, ok)
+		_, ok = tree.cache.get(cacheKey(IList{q515}))
+		assert.True(t, ok)
+	})
+
+	t.Run("Precomputed recommendations are saved with the tree", func(t *testing.T) {
+		tree.Precompute(10)
+		defer tree.Precompute(0)
+		path := filepath.Join(t.TempDir(), "test.schemaTree.typed.bin")
+		if err := tree.Save(path); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, loaded.precomputed, 10)
+		for key, recs := range tree.precomputed {
+			loadedRecs := loaded.precomputed[key]
+			assert.Len(t, loadedRecs, len(recs))
+			for i := range recs {
+				assert.Equal(t, recs[i].Property.Str(), loadedRecs[i].Property.Str())
+				assert.Equal(t, recs[i].Probability, loadedRecs[i].Probability)
+				assert.Equal(t, recs[i].Support, loadedRecs[i].Support)
+			}
+		}
+
+		// answered from the table without a cache
+		assert.Nil(t, loaded.cache)
+		first := loaded.RecommendProperty(IList{})
+		assert.Equal(t, loaded.recommendProperty(IList{}), first)
+		first[0].Probability = -1
+		assert.NotEqual(t, -1.0, loaded.RecommendProperty(IList{})[0].Probability)
+	})
+
+	t.Run("Trees saved without precomputed recommendations are loaded as before", func(t *testing.T) {
+		legacy, err := Load(typedTreepath)
+		assert.NoError(t, err)
+		assert.Nil(t, legacy.precomputed)
+	})
+
+}
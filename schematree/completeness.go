@@ -0,0 +1,56 @@
+package schematree
+
+// DefaultCompletenessThreshold is the probability from which on a property is expected of an entity.
+const DefaultCompletenessThreshold = 0.5
+
+// Completeness describes how complete the property set of an entity is compared to its peers,
+// i.e. the training subjects that have all properties and types of the entity.
+type Completeness struct {
+	Score   float64                 // share of the expected properties the entity has, in [0, 1]
+	Present int                     // number of properties the entity has (types are not counted)
+	Missing PropertyRecommendations // properties expected of the entity that it does not have, descending by probability
+}
+
+// Completeness scores the given property set against its peers. A missing property is expected if
+// at least a `threshold` fraction of the peers has it (DefaultCompletenessThreshold if not positive).
+//
+// The expected number of properties of the entity is the number of present properties plus the
+// probabilities of the missing expected ones, and the score is the share of present properties in it.
+// An entity without expected missing properties thus scores 1, whereas e.g. a single missing property
+// that 90% of the peers have reduces the score of an entity with 9 properties to 9 / 9.9.
+// If the property set has no peers, nothing is expected and the score is 1.
+func (tree *SchemaTree) Completeness(properties IList, threshold float64) Completeness {
+	if threshold <= 0 {
+		threshold = DefaultCompletenessThreshold
+	}
+
+	present := 0
+	for _, p := range properties {
+		if p.IsProp() {
+			present++
+		}
+	}
+
+	// recommendations are sorted descending by probability, thus the expected ones are a prefix.
+	// Types are recommended as well on typed trees, e.g. for the empty set, but are not missing properties.
+	var missing PropertyRecommendations
+	for _, rec := range tree.RecommendProperty(properties) {
+		if rec.Probability < threshold {
+			break
+		}
+		if rec.Property.IsProp() {
+			missing = append(missing, rec)
+		}
+	}
+
+	missingWeight := 0.0
+	for _, rec := range missing {
+		missingWeight += rec.Probability
+	}
+
+	score := 1.0
+	if missingWeight > 0 {
+		score = float64(present) / (float64(present) + missingWeight)
+	}
+	return Completeness{Score: score, Present: present, Missing: missing}
+}
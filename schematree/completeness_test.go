@@ -0,0 +1,53 @@
+package schematree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteness(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+	city := IList{pMap.get("t#http://www.wikidata.org/entity/Q515")}
+	country := pMap.get("http://www.wikidata.org/prop/direct/P17")
+
+	t.Run("Missing expected properties", func(t *testing.T) {
+		c := tree.Completeness(city, 0.9)
+		assert.Equal(t, 0, c.Present)
+		assert.Equal(t, 0.0, c.Score)
+		assert.True(t, c.Missing.contains(country.Str(), 0.9))
+		for i, rec := range c.Missing {
+			assert.True(t, rec.Probability >= 0.9)
+			if i > 0 {
+				assert.True(t, c.Missing[i-1].Probability >= rec.Probability)
+			}
+		}
+	})
+
+	t.Run("Adding an expected property improves the score", func(t *testing.T) {
+		props := IList{pMap.get("http://www.wikidata.org/prop/direct/P625"), city[0]} // coordinate location
+		before := tree.Completeness(props, 0.5)
+		after := tree.Completeness(append(IList{country}, props...), 0.5)
+		assert.Equal(t, 1, before.Present)
+		assert.Equal(t, 2, after.Present)
+		assert.True(t, after.Score > before.Score)
+		assert.False(t, after.Missing.contains(country.Str(), 0))
+	})
+
+	t.Run("Types are not missing properties", func(t *testing.T) {
+		c := tree.Completeness(IList{}, 0.01)
+		assert.NotEmpty(t, c.Missing)
+		for _, rec := range c.Missing {
+			assert.True(t, rec.Property.IsProp(), rec.Property.Str())
+		}
+	})
+
+	t.Run("Nothing expected", func(t *testing.T) {
+		c := tree.Completeness(city, 1.1)
+		assert.Empty(t, c.Missing)
+		assert.Equal(t, 1.0, c.Score)
+	})
+
+}
@@ -1,6 +1,7 @@
 package schematree
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -13,10 +14,61 @@ import (
 // - its support, i.e. its total number of occurrences (totalCount)
 // - an integer indicating sort order
 type IItem struct {
-	Str              *string
+	namespace        *namespace // interned namespace of the IRI, see propMap
+	local            string     // rest of the IRI after the namespace
 	TotalCount       uint64
 	SortOrder        uint32
-	traversalPointer *SchemaNode // node traversal pointer
+	traversalPointer *SchemaNode   // node traversal pointer
+	branches         int           // number of nodes in the traversal chain, see indexBranches
+	branchIndex      []*SchemaNode // every branchStride-th node of the traversal chain
+}
+
+// NewItem returns an item of the IRI that does not belong to a schematree, e.g. for properties that are
+// unknown to it.
+func NewItem(iri string) *IItem {
+	ns, local := splitIRI(iri)
+	return &IItem{namespace: &namespace{iri: ns}, local: local}
+}
+
+// Str returns the IRI of the item, for types with the type prefix.
+func (p *IItem) Str() string {
+	return p.namespace.iri + p.local
+}
+
+// MarshalJSON encodes the item like a struct of its IRI, total count and sort order
+func (p *IItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Str        string
+		TotalCount uint64
+		SortOrder  uint32
+	}{p.Str(), p.TotalCount, p.SortOrder})
+}
+
+// lessIRI compares the IRIs of the items lexicographically without building them
+func (p *IItem) lessIRI(q *IItem) bool {
+	if p.namespace.iri == q.namespace.iri {
+		return p.local < q.local
+	}
+	a, aNext, b, bNext := p.namespace.iri, p.local, q.namespace.iri, q.local
+	for {
+		if a == "" {
+			a, aNext = aNext, ""
+		}
+		if b == "" {
+			b, bNext = bNext, ""
+		}
+		if a == "" || b == "" {
+			return a == "" && b != ""
+		}
+		n := len(a)
+		if len(b) < n {
+			n = len(b)
+		}
+		if a[:n] != b[:n] {
+			return a[:n] < b[:n]
+		}
+		a, b = a[n:], b[n:]
+	}
 }
 
 func (p *IItem) increment() {
@@ -26,18 +78,71 @@ func (p *IItem) increment() {
 const typePrefix = "t#"
 
 func (p *IItem) IsType() bool {
-	return strings.HasPrefix(*p.Str, typePrefix)
+	return strings.HasPrefix(p.namespace.iri, typePrefix)
 }
 
 func (p *IItem) IsProp() bool {
-	return !strings.HasPrefix(*p.Str, typePrefix)
+	return !strings.HasPrefix(p.namespace.iri, typePrefix)
+}
+
+// IRI returns the IRI the item stands for, i.e. for types the IRI of the type without the type prefix
+func (p *IItem) IRI() string {
+	return strings.TrimPrefix(p.Str(), typePrefix)
 }
 
 func (p IItem) String() string {
-	return fmt.Sprint(p.TotalCount, "x\t", *p.Str, " (", p.SortOrder, ")")
+	return fmt.Sprint(p.TotalCount, "x\t", p.Str(), " (", p.SortOrder, ")")
+}
+
+// propMap maps the IRIs of properties, and of types with the type prefix, to their items. To save memory,
+// the IRIs are split into a namespace and a local part like the CURIEs of a prefix registry (see splitIRI),
+// and each namespace is stored once for all of its items. IItem.Str returns the whole IRI again.
+type propMap struct {
+	namespaces map[string]*namespace
+	size       int // number of items
 }
 
-type propMap map[string]*IItem
+func newPropMap() *propMap {
+	return &propMap{namespaces: make(map[string]*namespace)}
+}
+
+// namespace is an interned namespace of a propMap with the items of its IRIs
+type namespace struct {
+	iri   string
+	items map[string]*IItem // by local part
+}
+
+// splitIRI splits the IRI into a namespace that ends with its last '/' or '#', and the local part after it.
+// IRIs without these characters have an empty namespace.
+func splitIRI(iri string) (ns string, local string) {
+	i := strings.LastIndexAny(iri, "/#") + 1
+	return iri[:i], iri[i:]
+}
+
+// Lookup returns the item of the IRI, if it is in the map.
+func (m *propMap) Lookup(iri string) (item *IItem, ok bool) {
+	ns, local := splitIRI(iri)
+	if n := m.namespaces[ns]; n != nil {
+		item, ok = n.items[local]
+	}
+	return
+}
+
+// Len returns the number of items in the map.
+func (m *propMap) Len() int {
+	return m.size
+}
+
+// Items returns the items of the map in no particular order.
+func (m *propMap) Items() IList {
+	items := make(IList, 0, m.size)
+	for _, ns := range m.namespaces {
+		for _, item := range ns.items {
+			items = append(items, item)
+		}
+	}
+	return items
+}
 
 var propMapLock sync.Mutex
 
@@ -45,31 +150,38 @@ var propMapLock sync.Mutex
 //       will build a new property, mutate the propMap to include it, and return the
 //       newly created property. The returned `item` is guaranteed to be non-null.
 // thread-safe
-func (m propMap) get(iri string) (item *IItem) { // TODO: Implement sameas Mapping/Resolution to single group identifier upon insert!
-	item, ok := m[iri]
+func (m *propMap) get(iri string) (item *IItem) { // TODO: Implement sameas Mapping/Resolution to single group identifier upon insert!
+	item, ok := m.Lookup(iri)
 	if !ok {
 		propMapLock.Lock()
 		defer propMapLock.Unlock()
 
 		// recheck existence - might have been created by other thread
-		if item, ok = m[iri]; ok {
+		if item, ok = m.Lookup(iri); ok {
 			return
 		}
 
-		item = &IItem{&iri, 0, uint32(len(m)), nil}
-		m[iri] = item
+		ns, local := splitIRI(iri)
+		n := m.namespaces[ns]
+		if n == nil {
+			n = &namespace{iri: ns, items: make(map[string]*IItem)}
+			m.namespaces[ns] = n
+		}
+		item = &IItem{namespace: n, local: local, SortOrder: uint32(m.size)}
+		n.items[local] = item
+		m.size++
 	}
 	return
 }
 
-func (p propMap) count() (int, int) {
+func (p *propMap) count() (int, int) {
 	props := 0
 	types := 0
-	for _, item := range p {
-		if item.IsType() {
-			types++
+	for _, ns := range p.namespaces {
+		if strings.HasPrefix(ns.iri, typePrefix) {
+			types += len(ns.items)
 		} else {
-			props++
+			props += len(ns.items)
 		}
 	}
 	return props, types
@@ -136,7 +248,7 @@ func (l IList) String() string {
 	//// list representation (includes duplicates)
 	o := "[ "
 	for i := 0; i < len(l); i++ {
-		o += *(l[i].Str) + " "
+		o += l[i].Str() + " "
 	}
 	return o + "]"
 
@@ -11,8 +11,10 @@ import (
 //// Test Data ////
 
 func i2iItem(i int) *IItem {
-	s := strconv.Itoa(i)
-	return &IItem{&s, uint64(i), uint32(i), nil}
+	item := NewItem(strconv.Itoa(i))
+	item.TotalCount = uint64(i)
+	item.SortOrder = uint32(i)
+	return item
 }
 
 func orderedList(length int) (ls IList) {
@@ -27,7 +29,7 @@ func recommendations(length int) (ls PropertyRecommendations) {
 	ps := orderedList(10)
 	ls = make([]RankedPropertyCandidate, length, length)
 	for i := 0; i < length; i++ {
-		ls[i] = RankedPropertyCandidate{ps[i], (1 / float64(i+1))}
+		ls[i] = RankedPropertyCandidate{Property: ps[i], Probability: (1 / float64(i+1))}
 	}
 	return
 }
@@ -35,9 +37,9 @@ func recommendations(length int) (ls PropertyRecommendations) {
 //// IItem ////
 
 func TestIItem(t *testing.T) {
-	s := "item"
 	t.Run("increment", func(t *testing.T) {
-		item := &IItem{&s, 1, 1, nil}
+		item := NewItem("item")
+		item.TotalCount = 1
 		assert.Equal(t, uint64(1), item.TotalCount)
 		item.increment()
 		assert.Equal(t, uint64(2), item.TotalCount)
@@ -48,6 +50,62 @@ func TestIItem(t *testing.T) {
 	})
 }
 
+//// propMap ////
+
+func TestPropMap(t *testing.T) {
+	iris := []string{
+		"http://www.wikidata.org/prop/direct/P31",
+		"http://www.wikidata.org/prop/direct/P21",
+		"t#http://www.wikidata.org/entity/Q5",
+		"http://www.w3.org/1999/02/22-rdf-syntax-ns#type",
+		"root",
+	}
+	pMap := newPropMap()
+	for _, iri := range iris {
+		pMap.get(iri)
+	}
+
+	t.Run("split", func(t *testing.T) {
+		ns, local := splitIRI("http://www.w3.org/1999/02/22-rdf-syntax-ns#type")
+		assert.Equal(t, "http://www.w3.org/1999/02/22-rdf-syntax-ns#", ns)
+		assert.Equal(t, "type", local)
+		ns, local = splitIRI("root")
+		assert.Equal(t, "", ns)
+		assert.Equal(t, "root", local)
+	})
+	t.Run("interning", func(t *testing.T) {
+		assert.Equal(t, len(iris), pMap.Len())
+		assert.Len(t, pMap.namespaces, 4)
+		p31, _ := pMap.Lookup(iris[0])
+		p21, _ := pMap.Lookup(iris[1])
+		assert.Same(t, p31.namespace, p21.namespace)
+		assert.Same(t, p31, pMap.get(iris[0]))
+		props, types := pMap.count()
+		assert.Equal(t, 4, props)
+		assert.Equal(t, 1, types)
+	})
+	t.Run("str", func(t *testing.T) {
+		for i, iri := range iris {
+			item, ok := pMap.Lookup(iri)
+			assert.True(t, ok)
+			assert.Equal(t, iri, item.Str())
+			assert.Equal(t, uint32(i), item.SortOrder)
+		}
+		_, ok := pMap.Lookup("http://www.wikidata.org/prop/direct/P17")
+		assert.False(t, ok)
+		assert.Len(t, pMap.Items(), len(iris))
+	})
+	t.Run("lessIRI", func(t *testing.T) {
+		items := pMap.Items()
+		items = append(items, NewItem("http://www.wikidata.org/prop/direct/P3"), NewItem("http://www.wikidata.org/prop/"))
+		for _, p := range items {
+			for _, q := range items {
+				assert.Equal(t, p.Str() < q.Str(), p.lessIRI(q), "%v < %v", p.Str(), q.Str())
+			}
+		}
+	})
+}
+
 //// IList ////
 
 func TestPropertyDeduplication(t *testing.T) {
@@ -0,0 +1,68 @@
+package schematree
+
+import (
+	"math"
+	"sort"
+)
+
+// Estimator computes the probability used for ranking a candidate from its support evidence, i.e. from the
+// number of subjects that have the input set and the candidate (support) and the number of subjects that
+// have the input set (setSupport).
+//
+// The plain maximum likelihood estimate support/setSupport treats a probability of 1.0 observed on two
+// subjects the same as one observed on a million subjects. The smoothing estimators below pull such
+// rarely observed probabilities towards less confident values.
+type Estimator func(support, setSupport uint64) float64
+
+// MaximumLikelihoodEstimator is the estimator used by RecommendProperty itself.
+var MaximumLikelihoodEstimator Estimator = func(support, setSupport uint64) float64 {
+	return float64(support) / float64(setSupport)
+}
+
+// MakeLaplaceEstimator creates an additive (Laplace) smoothing estimator with pseudo-count alpha.
+// It corresponds to the posterior mean of a Beta(alpha, alpha) prior. If alpha is not positive, 1 is used.
+func MakeLaplaceEstimator(alpha float64) Estimator {
+	if alpha <= 0 {
+		alpha = 1
+	}
+	return func(support, setSupport uint64) float64 {
+		return (float64(support) + alpha) / (float64(setSupport) + 2*alpha)
+	}
+}
+
+// MakeWilsonEstimator creates an estimator that returns the lower bound of the Wilson score interval
+// for the given z-value (e.g. 1.96 for a 95% confidence interval). If z is not positive, 1.96 is used.
+func MakeWilsonEstimator(z float64) Estimator {
+	if z <= 0 {
+		z = 1.96
+	}
+	return func(support, setSupport uint64) float64 {
+		if setSupport == 0 {
+			return 0
+		}
+		n := float64(setSupport)
+		p := float64(support) / n
+		z2 := z * z
+		center := p + z2/(2*n)
+		spread := z * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+		return (center - spread) / (1 + z2/n)
+	}
+}
+
+// Reestimate returns a copy of the recommendations where the probability of each candidate is
+// recomputed by the given estimator, sorted descending by the new probability.
+// Candidates without support evidence (e.g. from external recommenders) keep their probability.
+// The receiver is not modified, such that cached recommendations can safely be re-estimated.
+func (ps PropertyRecommendations) Reestimate(estimator Estimator) PropertyRecommendations {
+	ranked := make(PropertyRecommendations, len(ps))
+	copy(ranked, ps)
+	for i := range ranked {
+		if ranked[i].SetSupport > 0 {
+			ranked[i].Probability = estimator(ranked[i].Support, ranked[i].SetSupport)
+		}
+	}
+
+	// sort descending by the new probability. Stable, to keep the order of equally ranked candidates.
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Probability > ranked[j].Probability })
+	return ranked
+}
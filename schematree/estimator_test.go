@@ -0,0 +1,39 @@
+package schematree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimators(t *testing.T) {
+
+	t.Run("Laplace pulls rare sets towards 0.5", func(t *testing.T) {
+		laplace := MakeLaplaceEstimator(1)
+		assert.InDelta(t, 0.75, laplace(2, 2), 1e-12)
+		assert.True(t, laplace(1000000, 1000000) > laplace(2, 2))
+	})
+
+	t.Run("Wilson lower bound", func(t *testing.T) {
+		wilson := MakeWilsonEstimator(1.96)
+		assert.True(t, wilson(2, 2) < 0.5)
+		assert.True(t, wilson(1000000, 1000000) > 0.99)
+		assert.True(t, wilson(5, 10) < 0.5)
+		assert.EqualValues(t, 0, wilson(0, 0))
+	})
+
+	t.Run("Reestimate ranks frequent sets first", func(t *testing.T) {
+		ps := orderedList(3)
+		recs := PropertyRecommendations{
+			{Property: ps[0], Probability: 1, Support: 2, SetSupport: 2},
+			{Property: ps[1], Probability: 0.9, Support: 9000, SetSupport: 10000},
+			{Property: ps[2], Probability: 0.1},
+		}
+		ranked := recs.Reestimate(MakeWilsonEstimator(0))
+		assert.Equal(t, ps[1], ranked[0].Property)
+		assert.Equal(t, ps[0], ranked[1].Property)
+		assert.EqualValues(t, 0.1, ranked[2].Probability) // no evidence, probability is kept
+		assert.EqualValues(t, 1, recs[0].Probability)     // receiver is not modified
+	})
+
+}
@@ -1,14 +1,24 @@
 package schematree
 
 import (
+	"container/heap"
 	"fmt"
 	"sort"
+	"strings"
 )
 
 // RankedPropertyCandidate is a struct to rank suggestions
+//
+// Besides the probability, it carries the evidence the probability was derived from, such that a
+// recommendation can be explained as "Support of SetSupport similar subjects have this property".
+// The evidence is only filled by the core recommender; backoff strategies may leave it empty.
 type RankedPropertyCandidate struct {
 	Property    *IItem
 	Probability float64
+	Support     uint64  `json:",omitempty"` // number of subjects that have both the input set and the candidate
+	SetSupport  uint64  `json:",omitempty"` // number of subjects that have the input set (denominator of Probability)
+	Branches    int     `json:",omitempty"` // number of tree branches that matched the input set
+	Error       float64 `json:",omitempty"` // half-width of the 95% confidence interval of an approximate Probability
 }
 
 // PropertyRecommendations is a list of RankedPropertyCandidates
@@ -18,7 +28,7 @@ type PropertyRecommendations []RankedPropertyCandidate
 func (ps PropertyRecommendations) String() string {
 	s := ""
 	for _, p := range ps {
-		s += fmt.Sprintf("%v: %v\n", *p.Property.Str, p.Probability)
+		s += fmt.Sprintf("%v: %v\n", p.Property.Str(), p.Probability)
 	}
 	return s
 }
@@ -49,201 +59,362 @@ func (tree *SchemaTree) Recommend(properties []string, types []string) PropertyR
 }
 
 // BuildPropertyList receives prop and type strings, and builds a list of IItem from it that can later
-// be used to execute the recommender.
+// be used to execute the recommender. Strings that are unknown to the schematree are silently dropped,
+// use ResolvePropertyList to learn about them.
 func (tree *SchemaTree) BuildPropertyList(properties []string, types []string) IList {
+	list, _, _ := tree.ResolvePropertyList(properties, types, UnknownHandling{})
+	return list
+}
 
-	list := []*IItem{}
-	// Find IItems of property strings
-	for _, pString := range properties {
-		p, ok := tree.PropMap[pString]
+// UnknownPolicy decides how property and type strings are treated that are unknown to the schematree,
+// e.g. because of a typo or because the property is newer than the schematree.
+type UnknownPolicy int
+
+const (
+	// IgnoreUnknown drops unknown strings, the recommendation is computed on the known ones
+	IgnoreUnknown UnknownPolicy = iota
+	// FailOnUnknown makes ResolvePropertyList return an error if any string is unknown
+	FailOnUnknown
+	// MapUnknown replaces unknown strings by their entry in the mapping table and drops them otherwise
+	MapUnknown
+)
+
+// UnknownHandling configures how ResolvePropertyList treats unknown property and type strings.
+type UnknownHandling struct {
+	Policy  UnknownPolicy
+	Mapping map[string]string // replacements of unknown strings, only used by MapUnknown. Types are given without type prefix.
+}
+
+// ParseUnknownPolicy returns the policy with the given name: ignore, error or map.
+func ParseUnknownPolicy(name string) (UnknownPolicy, error) {
+	switch name {
+	case "ignore", "":
+		return IgnoreUnknown, nil
+	case "error":
+		return FailOnUnknown, nil
+	case "map":
+		return MapUnknown, nil
+	}
+	return IgnoreUnknown, fmt.Errorf("unknown policy for unknown properties: %v", name)
+}
+
+// ResolvePropertyList receives prop and type strings, and builds a list of IItem from it that can later
+// be used to execute the recommender. Additionally, it returns all strings that could not be resolved
+// (in the order props first, then types). With the FailOnUnknown policy, an error is returned in that case.
+func (tree *SchemaTree) ResolvePropertyList(properties []string, types []string, handling UnknownHandling) (list IList, unknown []string, err error) {
+	list = []*IItem{}
+
+	resolve := func(str string, prefix string) {
+		p, ok := tree.PropMap.Lookup(prefix + str)
+		if !ok && handling.Policy == MapUnknown {
+			if mapped, isMapped := handling.Mapping[str]; isMapped {
+				p, ok = tree.PropMap.Lookup(prefix + mapped)
+			}
+		}
 		if ok {
 			list = append(list, p)
+		} else {
+			unknown = append(unknown, str)
 		}
 	}
 
+	// Find IItems of property strings
+	for _, pString := range properties {
+		resolve(pString, "")
+	}
+
 	// Find IItems of type strings
 	for _, tString := range types {
-		tString := "t#" + tString
-		p, ok := tree.PropMap[tString]
-		if ok {
-			list = append(list, p)
-		}
+		resolve(tString, typePrefix)
 	}
 
-	return list
+	if len(unknown) > 0 && handling.Policy == FailOnUnknown {
+		err = fmt.Errorf("unknown properties or types: %v", strings.Join(unknown, ", "))
+	}
+	return
 }
 
-// RecommendProperty recommends a ranked list of property candidates by given IItems
+// RecommendProperty recommends a ranked list of property candidates by given IItems.
+// Precomputed recommendations (see Precompute) and, if caching is enabled (see EnableCache), memoized
+// recommendations are returned whenever possible.
 func (tree *SchemaTree) RecommendProperty(properties IList) (ranked PropertyRecommendations) {
+	if tree.cache == nil && tree.precomputed == nil {
+		return tree.recommendProperty(properties)
+	}
 
-	if len(properties) > 0 {
-
-		properties.Sort() // descending by support
+	properties.Sort() // descending by support
+	key := cacheKey(properties)
+	if ranked, ok := tree.precomputed[key]; ok {
+		return ranked.copy()
+	}
+	if tree.cache == nil {
+		return tree.recommendProperty(properties)
+	}
+	if ranked, ok := tree.cache.get(key); ok {
+		return ranked
+	}
+	ranked = tree.recommendProperty(properties)
+	tree.cache.add(key, ranked)
+	return
+}
 
-		pSet := properties.toSet()
+// recommendProperty is the uncached core of RecommendProperty
+func (tree *SchemaTree) recommendProperty(properties IList) (ranked PropertyRecommendations) {
+	if len(properties) == 0 {
+		return tree.rankEmptySet(nil)
+	}
+	return rankCandidates(tree.collectCandidates(properties))
+}
 
-		candidates := make(map[*IItem]uint32)
+// RecommendTopK recommends the k most probable property candidates by given IItems.
+// The result is the same as the first k entries of RecommendProperty, except that candidates of equal
+// probability may come in another order, but instead of ranking and sorting all candidates only a bounded
+// heap of the k best candidates is maintained.
+// If k is not positive, all candidates are returned.
+func (tree *SchemaTree) RecommendTopK(properties IList, k int) (ranked PropertyRecommendations) {
+	if k <= 0 {
+		return tree.RecommendProperty(properties)
+	}
 
-		var makeCandidates func(startNode *SchemaNode)
-		makeCandidates = func(startNode *SchemaNode) { // head hunter function ;)
-			for _, child := range startNode.Children {
-				if child.ID.IsProp() {
-					candidates[child.ID] += child.Support
-				}
-				makeCandidates(child)
-			}
+	if len(properties) == 0 {
+		// the empty set is answered by the sort order of the properties, no need to look at the others
+		setSupport := uint64(tree.Root.Support) // empty set occured in all transactions
+		setSup := float64(setSupport)
+		if k > tree.PropMap.Len() {
+			k = tree.PropMap.Len()
 		}
-
-		// the least frequent property from the list is farthest from the root
-		rarestProperty := properties[len(properties)-1]
-
-		var setSupport uint64
-		// walk from each "leaf" instance of that property towards the root...
-		for leaf := rarestProperty.traversalPointer; leaf != nil; leaf = leaf.nextSameID { // iterate all instances for that property
-			if leaf.prefixContains(properties) {
-				setSupport += uint64(leaf.Support) // number of occuences of this set of properties in the current branch
-
-				// walk up
-				for cur := leaf; cur.parent != nil; cur = cur.parent {
-					if !(pSet[cur.ID]) {
-						if cur.ID.IsProp() {
-							candidates[cur.ID] += leaf.Support
-						}
-					}
+		ranked = make([]RankedPropertyCandidate, k, k)
+		for _, prop := range tree.PropMap.Items() {
+			if int(prop.SortOrder) < k {
+				ranked[int(prop.SortOrder)] = RankedPropertyCandidate{
+					Property:    prop,
+					Probability: float64(prop.TotalCount) / setSup,
+					Support:     prop.TotalCount,
+					SetSupport:  setSupport,
+					Branches:    1, // the root node
 				}
-				// walk down
-				makeCandidates(leaf)
 			}
 		}
+		return
+	}
 
-		// now that all candidates have been collected, rank them
-		i := 0
-		setSup := float64(setSupport)
-		ranked = make([]RankedPropertyCandidate, len(candidates), len(candidates))
-		for candidate, support := range candidates {
-			ranked[i] = RankedPropertyCandidate{candidate, float64(support) / setSup}
-			i++
+	candidates, setSupport, branches := tree.collectCandidates(properties)
+
+	// keep the k best candidates in a min-heap, such that the worst of them can be replaced in O(log k)
+	top := make(candidateHeap, 0, k)
+	for candidate, support := range candidates {
+		if len(top) < k {
+			heap.Push(&top, rankedSupport{candidate, support})
+		} else if top.less(top[0], rankedSupport{candidate, support}) {
+			top[0] = rankedSupport{candidate, support}
+			heap.Fix(&top, 0)
 		}
+	}
 
-		// sort descending by support
-		sort.Slice(ranked, func(i, j int) bool { return ranked[i].Probability > ranked[j].Probability })
-	} else {
-		// TODO: Race condition on propMap: fatal error: concurrent map iteration and map write
-		// fmt.Println(tree.Root.Support)
-		setSup := float64(tree.Root.Support) // empty set occured in all transactions
-		ranked = make([]RankedPropertyCandidate, len(tree.PropMap), len(tree.PropMap))
-		for _, prop := range tree.PropMap {
-			ranked[int(prop.SortOrder)] = RankedPropertyCandidate{prop, float64(prop.TotalCount) / setSup}
+	// popping the min-heap yields the candidates in ascending order, so fill the result from the back
+	setSup := float64(setSupport)
+	ranked = make([]RankedPropertyCandidate, len(top), len(top))
+	for i := len(top) - 1; i >= 0; i-- {
+		c := heap.Pop(&top).(rankedSupport)
+		ranked[i] = RankedPropertyCandidate{
+			Property:    c.item,
+			Probability: float64(c.support) / setSup,
+			Support:     uint64(c.support),
+			SetSupport:  setSupport,
+			Branches:    branches,
 		}
 	}
 
 	return
 }
 
-// RecommendPropertiesAndTypes recommends a ranked list of property and type candidates by given IItems
-func (tree *SchemaTree) RecommendPropertiesAndTypes(properties IList) (ranked PropertyRecommendations) {
+// RecommendPropertyExcluding recommends a ranked list of property candidates for subjects that have all
+// given properties but none of the excluded ones (e.g. properties an editor knows to have "no value").
+// Probabilities are conditioned on both, by subtracting the support of every branch that contains an
+// excluded property from the branches that contain the given properties.
+func (tree *SchemaTree) RecommendPropertyExcluding(properties IList, excluded IList) (ranked PropertyRecommendations) {
+	if len(excluded) == 0 {
+		return tree.RecommendProperty(properties)
+	}
 
-	if len(properties) > 0 {
+	// like in RecommendProperty, the types are candidates for the empty set only
+	include := (*IItem).IsProp
+	if len(properties) == 0 {
+		include = nil
+	}
+	return rankCandidates(tree.collectCandidatesExcluding(properties, excluded, include))
+}
 
-		properties.Sort() // descending by support
+// collectCandidates sums up the support of all properties that co-occur with the given non-empty set
+// of properties. It also returns the support of the set itself and the number of branches it was found in.
+func (tree *SchemaTree) collectCandidates(properties IList) (candidates map[*IItem]uint32, setSupport uint64, branches int) {
+	return tree.collectCandidatesExcluding(properties, nil, (*IItem).IsProp)
+}
 
-		pSet := properties.toSet()
+// collectCandidatesExcluding works like collectCandidates, but only counts subjects that have none of
+// the excluded properties and only collects candidates for which `include` holds (all if nil).
+// In contrast to collectCandidates, the given properties may be empty.
+func (tree *SchemaTree) collectCandidatesExcluding(properties IList, excluded IList, include func(*IItem) bool) (candidates map[*IItem]uint32, setSupport uint64, branches int) {
+	properties.Sort() // descending by support
 
-		candidates := make(map[*IItem]uint32)
+	pSet := properties.toSet()
+	var xSet map[*IItem]bool
+	if len(excluded) > 0 {
+		xSet = excluded.toSet()
+	}
+
+	candidates = make(map[*IItem]uint32)
 
-		var makeCandidates func(startNode *SchemaNode)
-		makeCandidates = func(startNode *SchemaNode) { // head hunter function ;)
-			for _, child := range startNode.Children {
-				candidates[child.ID] += child.Support
-				makeCandidates(child)
+	// makeCandidates collects all candidates below startNode and returns the number of subjects below
+	// startNode that had to be removed because they have an excluded property.
+	var makeCandidates func(startNode *SchemaNode) (removed uint32)
+	makeCandidates = func(startNode *SchemaNode) (removed uint32) { // head hunter function ;)
+		for _, child := range startNode.Children {
+			if xSet[child.ID] { // all subjects of this subtree have an excluded property
+				removed += child.Support
+				continue
+			}
+			childRemoved := makeCandidates(child)
+			removed += childRemoved
+			if (include == nil || include(child.ID)) && child.Support > childRemoved {
+				candidates[child.ID] += child.Support - childRemoved
 			}
 		}
+		return
+	}
 
-		// the least frequent property from the list is farthest from the root
-		rarestProperty := properties[len(properties)-1]
+	// without given properties, the root node is the only branch
+	if len(properties) == 0 {
+		setSupport = uint64(tree.Root.Support - makeCandidates(&tree.Root))
+		return candidates, setSupport, 1
+	}
 
-		var setSupport uint64
-		// walk from each "leaf" instance of that property towards the root...
-		for leaf := rarestProperty.traversalPointer; leaf != nil; leaf = leaf.nextSameID { // iterate all instances for that property
-			if leaf.prefixContains(properties) {
-				setSupport += uint64(leaf.Support) // number of occuences of this set of properties in the current branch
+	// the least frequent property from the list is farthest from the root
+	rarestProperty := properties[len(properties)-1]
 
-				// walk up
-				for cur := leaf; cur.parent != nil; cur = cur.parent {
-					if !(pSet[cur.ID]) {
-						candidates[cur.ID] += leaf.Support
-					}
-				}
-				// walk down
-				makeCandidates(leaf)
+	// walk from each "leaf" instance of that property towards the root...
+	for leaf := rarestProperty.traversalPointer; leaf != nil; leaf = leaf.nextSameID { // iterate all instances for that property
+		if leaf.prefixContains(properties) && !leaf.prefixContainsAny(xSet) {
+			// walk down
+			support := leaf.Support - makeCandidates(leaf)
+			if support == 0 {
+				continue
 			}
-		}
 
-		// now that all candidates have been collected, rank them
-		i := 0
-		setSup := float64(setSupport)
-		ranked = make([]RankedPropertyCandidate, len(candidates), len(candidates))
-		for candidate, support := range candidates {
-			ranked[i] = RankedPropertyCandidate{candidate, float64(support) / setSup}
-			i++
-		}
+			setSupport += uint64(support) // number of occuences of this set of properties in the current branch
+			branches++
 
-		// sort descending by support
-		sort.Slice(ranked, func(i, j int) bool { return ranked[i].Probability > ranked[j].Probability })
-	} else {
-		// TODO: Race condition on propMap: fatal error: concurrent map iteration and map write
-		// fmt.Println(tree.Root.Support)
-		setSup := float64(tree.Root.Support) // empty set occured in all transactions
-		ranked = make([]RankedPropertyCandidate, len(tree.PropMap), len(tree.PropMap))
-		for _, prop := range tree.PropMap {
-			ranked[int(prop.SortOrder)] = RankedPropertyCandidate{prop, float64(prop.TotalCount) / setSup}
+			// walk up
+			for cur := leaf; cur.parent != nil; cur = cur.parent {
+				if !(pSet[cur.ID]) {
+					if include == nil || include(cur.ID) {
+						candidates[cur.ID] += support
+					}
+				}
+			}
 		}
 	}
 
 	return
 }
 
-// func (tree *schemaTree) recommendType(properties iList) typeRecommendations {
-// 	var setSupport uint32
-// 	//tree.root.support // empty set occured in all transactions
+// rankedSupport is a candidate with its unnormalized support, as kept in the candidateHeap
+type rankedSupport struct {
+	item    *IItem
+	support uint32
+}
+
+// candidateHeap is a min-heap of candidates, i.e. the least supported candidate is at the top.
+// Equally supported candidates are ordered by their sort order to make the top k deterministic.
+type candidateHeap []rankedSupport
 
-// 	properties.sort() // descending by support
+func (h candidateHeap) less(a, b rankedSupport) bool {
+	if a.support != b.support {
+		return a.support < b.support
+	}
+	return a.item.SortOrder > b.item.SortOrder
+}
 
-// 	pSet := properties.toSet()
+func (h candidateHeap) Len() int            { return len(h) }
+func (h candidateHeap) Less(i, j int) bool  { return h.less(h[i], h[j]) }
+func (h candidateHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *candidateHeap) Push(x interface{}) { *h = append(*h, x.(rankedSupport)) }
+func (h *candidateHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
 
-// 	candidates := make(map[*iItem]uint32)
+// RecommendPropertiesAndTypes recommends a ranked list of property and type candidates by given IItems
+func (tree *SchemaTree) RecommendPropertiesAndTypes(properties IList) (ranked PropertyRecommendations) {
+	if len(properties) == 0 {
+		return tree.rankEmptySet(nil)
+	}
+	return rankCandidates(tree.collectCandidatesExcluding(properties, nil, nil))
+}
 
-// 	var makeCandidates func(startNode *SchemaNode)
-// 	makeCandidates = func(startNode *SchemaNode) { // head hunter function ;)
-// 		for _, child := range startNode.children {
-// 			candidates[child.ID] += child.support
-// 			makeCandidates(child)
-// 		}
-// 	}
+// RecommendTypes recommends a ranked list of type candidates by given IItems. Only typed schematrees
+// contain types, for untyped schematrees the list is always empty.
+// The returned candidates are type properties (see IItem.IsType), use IItem.IRI to get the type itself.
+func (tree *SchemaTree) RecommendTypes(properties IList) (ranked PropertyRecommendations) {
+	if len(properties) == 0 {
+		return tree.rankEmptySet((*IItem).IsType)
+	}
+	return rankCandidates(tree.collectCandidatesExcluding(properties, nil, (*IItem).IsType))
+}
 
-// 	// walk from each leaf towards root...l
-// 	for leaf := properties[len(properties)-1].traversalPointer; leaf != nil; leaf = leaf.nextSameID {
-// 		if leaf.prefixContains(&properties) {
-// 			setSupport += leaf.support // number of occuences of this set of properties in the current branch
-// 			for cur := leaf; cur.parent != nil; cur = cur.parent {
-// 				if !(pSet[cur.ID]) {
-// 					candidates[cur.ID] += leaf.support
-// 				}
-// 			}
-// 			makeCandidates(leaf)
-// 		}
-// 	}
+// rankCandidates turns collected candidates into recommendations sorted descending by probability
+func rankCandidates(candidates map[*IItem]uint32, setSupport uint64, branches int) (ranked PropertyRecommendations) {
+	i := 0
+	setSup := float64(setSupport)
+	ranked = make([]RankedPropertyCandidate, len(candidates), len(candidates))
+	for candidate, support := range candidates {
+		ranked[i] = RankedPropertyCandidate{
+			Property:    candidate,
+			Probability: float64(support) / setSup,
+			Support:     uint64(support),
+			SetSupport:  setSupport,
+			Branches:    branches,
+		}
+		i++
+	}
 
-// 	// TODO: If there are no candidates, consider doing (n-1)-gram smoothing over property subsets
+	// sort descending by support
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].Probability > ranked[j].Probability })
+	return
+}
 
-// 	// now that all candidates have been collected, rank them
-// 	ranked := make([]rankedCandidate, 0, len(candidates))
-// 	for candidate, support := range candidates {
-// 		ranked = append(ranked, rankedCandidate{candidate, float64(support) / float64(setSupport)})
-// 	}
+// rankEmptySet recommends all items for which `include` holds (all if nil) given an empty input set.
+// Their probabilities directly follow from their total counts, thus they are already sorted by sort order.
+func (tree *SchemaTree) rankEmptySet(include func(*IItem) bool) (ranked PropertyRecommendations) {
+	// TODO: Race condition on propMap: fatal error: concurrent map iteration and map write
+	setSupport := uint64(tree.Root.Support) // empty set occured in all transactions
+	setSup := float64(setSupport)
+	candidate := func(prop *IItem) RankedPropertyCandidate {
+		return RankedPropertyCandidate{
+			Property:    prop,
+			Probability: float64(prop.TotalCount) / setSup,
+			Support:     prop.TotalCount,
+			SetSupport:  setSupport,
+			Branches:    1, // the root node
+		}
+	}
 
-// 	// sort descending by support
-// 	sort.Slice(ranked, func(i, j int) bool { return ranked[i].probability > ranked[j].probability })
+	if include == nil {
+		ranked = make([]RankedPropertyCandidate, tree.PropMap.Len(), tree.PropMap.Len())
+		for _, prop := range tree.PropMap.Items() {
+			ranked[int(prop.SortOrder)] = candidate(prop)
+		}
+		return
+	}
 
-// 	return ranked
-// }
+	ranked = PropertyRecommendations{}
+	for _, prop := range tree.PropMap.Items() {
+		if include(prop) {
+			ranked = append(ranked, candidate(prop))
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].Property.SortOrder < ranked[j].Property.SortOrder })
+	return
+}
@@ -1,6 +1,7 @@
 package schematree
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,7 +12,7 @@ var typedTreepath = "../testdata/10M.nt.gz.schemaTree.typed.bin"
 // contains checks if a recommendation is in property recommendations with a probability threshold included.
 func (ps PropertyRecommendations) contains(str string, prob float64) bool {
 	for _, a := range ps {
-		if *a.Property.Str == str && a.Probability >= prob {
+		if a.Property.Str() == str && a.Probability >= prob {
 			return true
 		}
 	}
@@ -54,3 +55,287 @@ func TestRecommendProperty(t *testing.T) {
 	})
 
 }
+
+func TestRecommendPropertyEvidence(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+
+	t.Run("Evidence matches probability", func(t *testing.T) {
+		props := IList{pMap.get("http://www.wikidata.org/prop/direct/P31")}
+		list := tree.RecommendProperty(props)
+		assert.NotEmpty(t, list)
+		for _, rec := range list {
+			assert.EqualValues(t, tree.Support(props), rec.SetSupport)
+			assert.True(t, rec.Branches > 0)
+			assert.InDelta(t, float64(rec.Support)/float64(rec.SetSupport), rec.Probability, 1e-12)
+		}
+	})
+
+	t.Run("Empty set evidence", func(t *testing.T) {
+		list := tree.RecommendProperty(IList{})
+		assert.NotEmpty(t, list)
+		assert.EqualValues(t, tree.Root.Support, list[0].SetSupport)
+		assert.EqualValues(t, list[0].Property.TotalCount, list[0].Support)
+	})
+
+}
+
+func TestRecommendTopK(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+
+	for _, props := range []IList{
+		{},
+		{pMap.get("http://www.wikidata.org/prop/direct/P31")},
+		{pMap.get("t#http://www.wikidata.org/entity/Q515")},
+	} {
+		t.Run(props.String(), func(t *testing.T) {
+			all := tree.RecommendProperty(props)
+			for _, k := range []int{1, 10, len(all) + 5} {
+				top := tree.RecommendTopK(props, k)
+				if k > len(all) {
+					assert.Len(t, top, len(all))
+				} else {
+					assert.Len(t, top, k)
+				}
+				for i := range top {
+					assert.Equal(t, all[i].Probability, top[i].Probability)
+				}
+			}
+		})
+	}
+
+}
+
+// benchmarkK is the number of recommendations of both benchmarks, the hard limit of the server
+const benchmarkK = 500
+
+func BenchmarkRecommendProperty(b *testing.B) {
+	tree, _ := Load(typedTreepath)
+	props := IList{tree.PropMap.get("http://www.wikidata.org/prop/direct/P31")}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recs := tree.RecommendProperty(props)
+		if len(recs) > benchmarkK {
+			recs = recs[:benchmarkK]
+		}
+	}
+}
+
+func BenchmarkRecommendTopK(b *testing.B) {
+	tree, _ := Load(typedTreepath)
+	props := IList{tree.PropMap.get("http://www.wikidata.org/prop/direct/P31")}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.RecommendTopK(props, benchmarkK)
+	}
+}
+
+func TestRecommendPropertyExcluding(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+	p31 := pMap.get("http://www.wikidata.org/prop/direct/P31")
+	p17 := pMap.get("http://www.wikidata.org/prop/direct/P17")
+
+	// support of subjects having all of `with` and not `without`
+	supportWithout := func(with IList, without *IItem) uint64 {
+		return uint64(tree.Support(append(IList{}, with...))) - uint64(tree.Support(append(IList{without}, with...)))
+	}
+
+	for _, props := range []IList{{}, {p31}} {
+		t.Run(props.String(), func(t *testing.T) {
+			list := tree.RecommendPropertyExcluding(props, IList{p17})
+			assert.NotEmpty(t, list)
+			assert.False(t, list.contains(p17.Str(), 0))
+
+			setSupport := supportWithout(props, p17)
+			for _, rec := range list {
+				assert.Equal(t, setSupport, rec.SetSupport)
+				assert.Equal(t, supportWithout(append(IList{rec.Property}, props...), p17), rec.Support, rec.Property.Str())
+			}
+		})
+	}
+
+	t.Run("Types are candidates of the empty set", func(t *testing.T) {
+		types := 0
+		for _, rec := range tree.RecommendPropertyExcluding(IList{}, IList{p17}) {
+			if rec.Property.IsType() {
+				types++
+			}
+		}
+		assert.NotZero(t, types)
+	})
+
+	t.Run("Without exclusions", func(t *testing.T) {
+		for _, props := range []IList{{}, {p31}} {
+			list := tree.RecommendProperty(props)
+			excluding := tree.RecommendPropertyExcluding(props, IList{})
+			assert.Len(t, excluding, len(list))
+			for i := range list {
+				assert.Equal(t, list[i].Probability, excluding[i].Probability)
+			}
+		}
+	})
+
+}
+
+func TestRecommendTypes(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+
+	for _, props := range []IList{
+		{},
+		{pMap.get("http://www.wikidata.org/prop/direct/P625")}, // coordinate location
+	} {
+		t.Run(props.String(), func(t *testing.T) {
+			list := tree.RecommendTypes(props)
+			assert.NotEmpty(t, list)
+			for i, rec := range list {
+				assert.True(t, rec.Property.IsType())
+				if i > 0 {
+					assert.True(t, list[i-1].Probability >= rec.Probability)
+				}
+			}
+		})
+	}
+
+	t.Run("Same as RecommendPropertiesAndTypes", func(t *testing.T) {
+		props := IList{pMap.get("http://www.wikidata.org/prop/direct/P17")} // country
+		types := tree.RecommendTypes(props)
+		all := tree.RecommendPropertiesAndTypes(props)
+		for _, rec := range types {
+			assert.True(t, all.contains(rec.Property.Str(), rec.Probability))
+		}
+	})
+
+}
+
+func TestResolvePropertyList(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	p31 := "http://www.wikidata.org/prop/direct/P31"
+	typo := "http://www.wikidata.org/prop/direct/P31x"
+	q515 := "http://www.wikidata.org/entity/Q515"
+
+	t.Run("ignore", func(t *testing.T) {
+		list, unknown, err := tree.ResolvePropertyList([]string{p31, typo}, []string{q515, "Q0"}, UnknownHandling{})
+		assert.NoError(t, err)
+		assert.Len(t, list, 2)
+		assert.Equal(t, []string{typo, "Q0"}, unknown)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, unknown, err := tree.ResolvePropertyList([]string{p31, typo}, nil, UnknownHandling{Policy: FailOnUnknown})
+		assert.Error(t, err)
+		assert.Equal(t, []string{typo}, unknown)
+
+		_, _, err = tree.ResolvePropertyList([]string{p31}, []string{q515}, UnknownHandling{Policy: FailOnUnknown})
+		assert.NoError(t, err)
+	})
+
+	t.Run("map", func(t *testing.T) {
+		handling := UnknownHandling{Policy: MapUnknown, Mapping: map[string]string{typo: p31, "city": q515}}
+		list, unknown, err := tree.ResolvePropertyList([]string{typo, "other"}, []string{"city"}, handling)
+		assert.NoError(t, err)
+		assert.Equal(t, "[ "+p31+" t#"+q515+" ]", list.String())
+		assert.Equal(t, []string{"other"}, unknown)
+	})
+
+}
+
+func TestRecommendPropertySampled(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+
+	// the property occurring in the most branches is the one worth sampling
+	var props IList
+	population := 0
+	for _, item := range tree.PropMap.Items() {
+		branches := 0
+		for leaf := item.traversalPointer; leaf != nil; leaf = leaf.nextSameID {
+			branches++
+		}
+		if item.IsProp() && (branches > population || branches == population && item.SortOrder < props[0].SortOrder) {
+			props, population = IList{item}, branches
+		}
+	}
+	exact := tree.RecommendProperty(props)
+
+	t.Run("Exact within budget", func(t *testing.T) {
+		list := tree.RecommendPropertySampled(props, population)
+		assert.Len(t, list, len(exact))
+		for i := range list {
+			assert.Equal(t, exact[i].Probability, list[i].Probability)
+			assert.Zero(t, list[i].Error)
+		}
+	})
+
+	t.Run("Branch index", func(t *testing.T) {
+		assert.Equal(t, population, props[0].branches)
+		i := 0
+		for leaf := props[0].traversalPointer; leaf != nil; leaf = leaf.nextSameID {
+			assert.Same(t, leaf, props[0].branch(i))
+			i++
+		}
+	})
+
+	t.Run("Approximate", func(t *testing.T) {
+		if population < 4 {
+			t.Skip("too few branches to sample")
+		}
+		list := tree.RecommendPropertySampled(props, population/2)
+		assert.NotEmpty(t, list)
+		misses := 0
+		for _, rec := range list {
+			assert.True(t, rec.Branches <= population/2)
+			assert.True(t, rec.Probability >= 0 && rec.Probability <= 1)
+			for _, e := range exact {
+				if e.Property == rec.Property && math.Abs(e.Probability-rec.Probability) > rec.Error+1e-9 {
+					misses++
+				}
+			}
+		}
+		// the 95% confidence intervals should cover the exact probability for the vast majority of candidates
+		assert.True(t, float64(misses) <= 0.2*float64(len(list)), "%v of %v outside of error bounds", misses, len(list))
+	})
+
+	t.Run("Rare property set", func(t *testing.T) {
+		// pair the property with the more frequent one that is contained in the fewest of its branches
+		contained := make(map[*IItem]int)
+		for leaf := props[0].traversalPointer; leaf != nil; leaf = leaf.nextSameID {
+			for cur := leaf.parent; cur.parent != nil; cur = cur.parent {
+				if cur.ID.IsProp() {
+					contained[cur.ID]++
+				}
+			}
+		}
+		var rare *IItem
+		for item, count := range contained {
+			if rare == nil || count < contained[rare] || count == contained[rare] && item.SortOrder < rare.SortOrder {
+				rare = item
+			}
+		}
+		maxBranches := contained[rare] + 1
+		if rare == nil || population <= samplingMaxDraws*maxBranches {
+			t.Skip("no property set that only a small fraction of the branches match")
+		}
+		set := IList{rare, props[0]}
+		exactSet := tree.RecommendProperty(set)
+
+		// fewer than maxBranches of the drawn branches can match, so the exact recommendations are returned
+		list := tree.RecommendPropertySampled(set, maxBranches)
+		assert.NotEmpty(t, list)
+		assert.Len(t, list, len(exactSet))
+		for i := range list {
+			assert.Equal(t, exactSet[i].Probability, list[i].Probability)
+			assert.Zero(t, list[i].Error)
+		}
+	})
+
+}
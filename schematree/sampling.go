@@ -0,0 +1,190 @@
+package schematree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// samplingSeed makes RecommendPropertySampled deterministic: the same input set always leads to the
+// same sample, such that repeated requests of an editor get stable recommendations.
+const samplingSeed = 42
+
+// samplingZ is the z-value of the reported confidence intervals (95%)
+const samplingZ = 1.96
+
+// samplingMaxDraws bounds the number of branches drawn by RecommendPropertySampled to this multiple of
+// `maxBranches`, for input sets that only a small fraction of the drawn branches match.
+const samplingMaxDraws = 10
+
+// samplingMinMatches is the number of matching branches below which RecommendPropertySampled falls back to
+// the exact recommender, as an estimate from fewer branches is too unreliable.
+const samplingMinMatches = 30
+
+// samplingMaxNodes caps the nodes visited below each matching branch of RecommendPropertySampled.
+const samplingMaxNodes = 10000
+
+// branchStride is the distance of the nodes of a traversal chain that are kept in IItem.branchIndex
+const branchStride = 64
+
+// indexBranches counts the nodes in the traversal chain of every item and keeps every branchStride-th of
+// them, such that RecommendPropertySampled knows the number of branches of an item without walking its
+// chain and reaches any of them in less than branchStride steps. It is called once the tree is built or
+// loaded; items of trees that are built without it have no branches for RecommendPropertySampled.
+func (tree *SchemaTree) indexBranches() {
+	for _, item := range tree.PropMap.Items() {
+		item.branches = 0
+		item.branchIndex = nil
+		for node := item.traversalPointer; node != nil; node = node.nextSameID {
+			if item.branches%branchStride == 0 {
+				item.branchIndex = append(item.branchIndex, node)
+			}
+			item.branches++
+		}
+	}
+}
+
+// branch returns the i-th node in the traversal chain of the item
+func (p *IItem) branch(i int) *SchemaNode {
+	node := p.branchIndex[i/branchStride]
+	for j := i % branchStride; j > 0; j-- {
+		node = node.nextSameID
+	}
+	return node
+}
+
+// RecommendPropertySampled approximates RecommendProperty by checking a bounded number of branches, for
+// interactive clients that cannot wait for the exact recommender on rare property combinations.
+//
+// The exact recommender checks every occurrence of the rarest input property in the tree, which is slow
+// for sets whose rarest property still occurs in a huge number of branches. Instead, this method draws a
+// simple random sample of these branches until `maxBranches` of them match the input set, and extrapolates
+// from the matching ones. The probability of a candidate is then a ratio estimate and the half-width of its
+// 95% confidence interval is reported in the Error field; Support and SetSupport are estimates of the true
+// counts, Branches is the number of matching branches in the sample.
+//
+// The latency is capped: at most samplingMaxDraws * maxBranches branches are drawn, each in constant time,
+// and at most samplingMaxNodes nodes are visited below each matching branch, so candidates that only occur
+// deep down in huge subtrees may be underestimated. If fewer than samplingMinMatches of the drawn branches
+// (or fewer than maxBranches, if that is smaller) match the input set, the exact recommendations are
+// returned instead, as are they if the rarest property occurs in no more than `maxBranches` branches (or
+// maxBranches is not positive).
+func (tree *SchemaTree) RecommendPropertySampled(properties IList, maxBranches int) (ranked PropertyRecommendations) {
+	if len(properties) == 0 || maxBranches <= 0 {
+		return tree.RecommendProperty(properties)
+	}
+
+	properties.Sort() // descending by support
+	pSet := properties.toSet()
+	rarestProperty := properties[len(properties)-1]
+
+	population := rarestProperty.branches
+	if population <= maxBranches {
+		return tree.RecommendProperty(properties)
+	}
+
+	// estimate is the running sums over the sampled branches needed for the ratio estimate of one candidate,
+	// with x the set support and y the candidate support of a branch.
+	type estimate struct{ y, yy, xy float64 }
+	estimates := make(map[*IItem]*estimate)
+	var x, xx float64
+	matches := 0
+
+	branch := make(map[*IItem]uint32) // candidate supports of the current branch
+	var budget int                    // nodes left to visit below the current branch
+	var walkDown func(node *SchemaNode)
+	walkDown = func(node *SchemaNode) {
+		for _, child := range node.Children {
+			if budget == 0 {
+				return
+			}
+			budget--
+			if child.ID.IsProp() {
+				branch[child.ID] += child.Support
+			}
+			walkDown(child)
+		}
+	}
+
+	// the branches are drawn without replacement by a partial Fisher-Yates shuffle of their indexes, where
+	// `swapped` holds the entries of the shuffled index array that differ from their position
+	rng := rand.New(rand.NewSource(samplingSeed))
+	swapped := make(map[int]int)
+	at := func(i int) int {
+		if j, ok := swapped[i]; ok {
+			return j
+		}
+		return i
+	}
+	draws := 0
+	for ; draws < population && draws < samplingMaxDraws*maxBranches && matches < maxBranches; draws++ {
+		j := draws + rng.Intn(population-draws)
+		index := at(j)
+		swapped[j] = at(draws)
+
+		leaf := rarestProperty.branch(index)
+		if !leaf.prefixContains(properties) {
+			continue // contributes x = y = 0
+		}
+
+		for item := range branch {
+			delete(branch, item)
+		}
+		budget = samplingMaxNodes
+		walkDown(leaf)
+		for cur := leaf; cur.parent != nil; cur = cur.parent {
+			if !pSet[cur.ID] && cur.ID.IsProp() {
+				branch[cur.ID] += leaf.Support
+			}
+		}
+
+		xi := float64(leaf.Support)
+		x += xi
+		xx += xi * xi
+		matches++
+		for item, support := range branch {
+			e := estimates[item]
+			if e == nil {
+				e = &estimate{}
+				estimates[item] = e
+			}
+			yi := float64(support)
+			e.y += yi
+			e.yy += yi * yi
+			e.xy += xi * yi
+		}
+	}
+
+	if draws < population && matches < samplingMinMatches && matches < maxBranches {
+		return tree.RecommendProperty(properties)
+	}
+	if matches == 0 {
+		return PropertyRecommendations{} // all branches were drawn and none matched
+	}
+
+	// The variance of the ratio estimate R = y/x for a simple random sample of n out of N branches is
+	// approximately (1-n/N) / (n * mean(x)^2) * sum((y_i - R*x_i)^2) / (n-1), c.f. Cochran, Sampling Techniques.
+	n, N := float64(draws), float64(population)
+	scale := N / n
+	meanX := x / n
+	varianceFactor := (1 - n/N) / (n * meanX * meanX * math.Max(n-1, 1))
+	setSupport := uint64(math.Round(x * scale))
+
+	ranked = make(PropertyRecommendations, 0, len(estimates))
+	for item, e := range estimates {
+		ratio := e.y / x
+		residuals := e.yy - 2*ratio*e.xy + ratio*ratio*xx
+		ranked = append(ranked, RankedPropertyCandidate{
+			Property:    item,
+			Probability: ratio,
+			Support:     uint64(math.Round(e.y * scale)),
+			SetSupport:  setSupport,
+			Branches:    matches,
+			Error:       samplingZ * math.Sqrt(math.Max(residuals, 0)*varianceFactor),
+		})
+	}
+
+	// sort descending by probability
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].Probability > ranked[j].Probability })
+	return
+}
@@ -19,7 +19,7 @@ type SchemaNode struct {
 }
 
 //newRootNode creates a new root node for a given propMap
-func newRootNode(pMap propMap) SchemaNode {
+func newRootNode(pMap *propMap) SchemaNode {
 	// return schemaNode{newRootiItem(), nil, make(map[*iItem]*schemaNode), nil, 0, nil}
 	return SchemaNode{pMap.get("root"), nil, []*SchemaNode{}, nil, 0}
 }
@@ -189,6 +189,20 @@ func (node *SchemaNode) prefixContains(propertyPath IList) bool {
 	return false
 }
 
+// prefixContainsAny checks if any property of the given set is an ancestor of a node (or the node itself)
+// thread-safe!
+func (node *SchemaNode) prefixContainsAny(properties map[*IItem]bool) bool {
+	if len(properties) == 0 {
+		return false
+	}
+	for cur := node; cur.parent != nil; cur = cur.parent { // walk from leaf towards root
+		if properties[cur.ID] {
+			return true
+		}
+	}
+	return false
+}
+
 func (node *SchemaNode) graphViz(minSup uint32) string {
 	s := ""
 	// // draw horizontal links
@@ -198,7 +212,7 @@ func (node *SchemaNode) graphViz(minSup uint32) string {
 
 	// // draw types
 	// for k, v := range node.Types {
-	// 	s += fmt.Sprintf("%v -> \"%v\" [color=red,label=%v];\n", node, *k.Str, v)
+	// 	s += fmt.Sprintf("%v -> \"%v\" [color=red,label=%v];\n", node, k.Str(), v)
 	// }
 
 	// draw children
@@ -213,5 +227,5 @@ func (node *SchemaNode) graphViz(minSup uint32) string {
 }
 
 // func (node *SchemaNode) String() string {
-// 	return fmt.Sprintf("\"%v (%p id:%p str:%p)\"", *node.ID.Str, node, node.ID, node.ID.Str)
+// 	return fmt.Sprintf("\"%v (%p id:%p str:%p)\"", node.ID.Str(), node, node.ID, node.ID.Str)
 // }
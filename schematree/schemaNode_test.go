@@ -14,8 +14,8 @@ import (
 //     ...
 // }
 
-func testPropertyMap() propMap {
-	return make(propMap)
+func testPropertyMap() *propMap {
+	return newPropMap()
 }
 
 func testSchemaNode(str string) SchemaNode {
@@ -25,7 +25,7 @@ func testSchemaNode(str string) SchemaNode {
 func emptyRootNodeTest(t *testing.T, root SchemaNode) {
 
 	assert.NotNil(t, root.ID, "schemaNode ID is nil")
-	assert.Equal(t, "root", *root.ID.Str, "iri of root node is not \"root\"")
+	assert.Equal(t, "root", root.ID.Str(), "iri of root node is not \"root\"")
 	assert.Nil(t, root.parent, "parent of root not nil")
 	assert.Equal(t, 0, len(root.Children), "root node should be created with empty child array")
 }
@@ -3,34 +3,42 @@ package schematree
 import (
 	"encoding/gob"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+
 	gzip "github.com/klauspost/pgzip"
 )
 
 // TypedSchemaTree is a schematree that includes type information as property nodes
 type SchemaTree struct {
-	PropMap propMap    // PropMap maps the string representations of properties to the corresponding IItem
+	PropMap *propMap   // PropMap maps the string representations of properties to the corresponding IItem
 	Root    SchemaNode // Root is the root node of the schematree. All further nodes are descendants of this node.
 	MinSup  uint32     // TODO (not used)
 	Typed   bool       // Typed indicates if this schematree includes type information as properties
+
+	cache       *recommendationCache               // optional memoization of recommendations, see EnableCache
+	precomputed map[string]PropertyRecommendations // optional recommendations of frequent prefixes, see Precompute
 }
 
-// Create creates a new schema tree from given dataset with given first n subjects, typed and minSup
-func Create(filename string, firstNsubjects uint64, typed bool, minSup uint32) (*SchemaTree, error) {
+// Create creates a new schema tree from given dataset with given first n subjects, typed and minSup.
+// The recommendations of the `precompute` most frequent prefixes are computed and saved with the tree,
+// see Precompute.
+func Create(filename string, firstNsubjects uint64, typed bool, minSup uint32, precompute int, options ReaderOptions) (*SchemaTree, error) {
 
 	schema := New(typed, minSup)
-	schema.TwoPass(filename, uint64(firstNsubjects))
+	schema.TwoPass(filename, uint64(firstNsubjects), options)
+	schema.Precompute(precompute)
 	var err error
 	if typed {
-		err = schema.Save(filename + ".schemaTree.typed.bin")
+		err = schema.Save(rio.DatasetName(filename) + ".schemaTree.typed.bin")
 	} else {
-		err = schema.Save(filename + ".schemaTree.bin")
+		err = schema.Save(rio.DatasetName(filename) + ".schemaTree.bin")
 	}
 	PrintMemUsage()
 	return schema, err
@@ -42,7 +50,7 @@ func New(typed bool, minSup uint32) (tree *SchemaTree) {
 		minSup = 1
 	}
 
-	pMap := make(propMap)
+	pMap := newPropMap()
 	tree = &SchemaTree{
 		PropMap: pMap,
 		Root:    newRootNode(pMap),
@@ -93,12 +101,7 @@ func (tree *SchemaTree) Insert(e *SubjectSummary) {
 func (tree *SchemaTree) updateSortOrder() {
 	// make a list of all known properties
 	// Runtime: O(n), Memory: O(n)
-	iList := make(IList, len(tree.PropMap))
-	i := 0
-	for _, v := range tree.PropMap {
-		iList[i] = v
-		i++
-	}
+	iList := tree.PropMap.Items()
 
 	// sort by descending support. In case of equal support, lexicographically
 	// Runtime: O(n*log(n)), Memory: -
@@ -108,7 +111,7 @@ func (tree *SchemaTree) updateSortOrder() {
 			if iList[i].TotalCount != iList[j].TotalCount {
 				return iList[i].TotalCount > iList[j].TotalCount
 			}
-			return *(iList[i].Str) < *(iList[j].Str)
+			return iList[i].lessIRI(iList[j])
 		})
 
 	// update term's internal sortOrder
@@ -138,6 +141,14 @@ func (tree *SchemaTree) Support(properties IList) uint32 {
 	return support
 }
 
+// encodedItem is the encoding of an IItem in the saved tree, with the fields that IItem had when it still
+// stored the whole IRI, so that trees are saved and loaded the same way as before
+type encodedItem struct {
+	Str        string
+	TotalCount uint64
+	SortOrder  uint32
+}
+
 // Save stores a binarized version of the schematree to the given filepath
 func (tree *SchemaTree) Save(filePath string) error {
 	t1 := time.Now()
@@ -155,9 +166,9 @@ func (tree *SchemaTree) Save(filePath string) error {
 	e := gob.NewEncoder(w)
 
 	// encode propMap
-	props := make([]*IItem, len(tree.PropMap), len(tree.PropMap))
-	for _, p := range tree.PropMap {
-		props[int(p.SortOrder)] = p
+	props := make([]encodedItem, tree.PropMap.Len(), tree.PropMap.Len())
+	for _, p := range tree.PropMap.Items() {
+		props[int(p.SortOrder)] = encodedItem{p.Str(), p.TotalCount, p.SortOrder}
 	}
 	err = e.Encode(props)
 	if err != nil {
@@ -185,6 +196,11 @@ func (tree *SchemaTree) Save(filePath string) error {
 		return err
 	}
 
+	// encode the precomputed recommendations, which older trees do not have
+	if len(tree.precomputed) > 0 {
+		err = e.Encode(tree.encodePrecomputed())
+	}
+
 	if err == nil {
 		fmt.Printf("done (%v)\n", time.Since(t1))
 	} else {
@@ -220,14 +236,17 @@ func Load(filePath string) (*SchemaTree, error) {
 	d := gob.NewDecoder(r)
 
 	// decode propMap
-	var props []*IItem
-	err = d.Decode(&props)
+	var encodedProps []encodedItem
+	err = d.Decode(&encodedProps)
 	if err != nil {
 		return nil, err
 	}
-	for sortOrder, item := range props {
+	props := make([]*IItem, len(encodedProps))
+	for sortOrder, encoded := range encodedProps {
+		item := tree.PropMap.get(encoded.Str)
+		item.TotalCount = encoded.TotalCount
 		item.SortOrder = uint32(sortOrder)
-		tree.PropMap[*item.Str] = item
+		props[sortOrder] = item
 	}
 	fmt.Printf("%v properties... ", len(props))
 
@@ -242,10 +261,11 @@ func Load(filePath string) (*SchemaTree, error) {
 	err = tree.Root.decodeGob(d, props)
 
 	// legacy import bug workaround
-	if *tree.Root.ID.Str != "root" {
+	if tree.Root.ID.Str() != "root" {
 		fmt.Println("WARNING!!! Encountered legacy root node import bug - root node counts will be incorrect!")
 		tree.Root.ID = tree.PropMap.get("root")
 	}
+	tree.indexBranches()
 
 	//decode Typed
 	var i int
@@ -257,6 +277,19 @@ func Load(filePath string) (*SchemaTree, error) {
 		return nil, err
 	}
 
+	// decode the precomputed recommendations, if the tree was saved with them
+	var typed bool
+	err = d.Decode(&typed)
+	var precomputed []precomputedEntry
+	if err == nil {
+		err = d.Decode(&precomputed)
+	}
+	if err == nil {
+		tree.decodePrecomputed(precomputed, props)
+	} else if err == io.EOF {
+		err = nil
+	}
+
 	if err != nil {
 		fmt.Printf("Encountered error while decoding the file: %v\n", err)
 		return nil, err
@@ -267,7 +300,7 @@ func Load(filePath string) (*SchemaTree, error) {
 }
 
 // first pass: collect I-List and statistics
-func (tree *SchemaTree) firstPass(fileName string, firstN uint64) {
+func (tree *SchemaTree) firstPass(fileName string, firstN uint64, options ReaderOptions) {
 	//	if _, err := os.Stat(fileName + ".firstPass.bin"); os.IsNotExist(err) {
 	counter := func(s *SubjectSummary) {
 		for prop := range s.Properties {
@@ -276,7 +309,7 @@ func (tree *SchemaTree) firstPass(fileName string, firstN uint64) {
 	}
 
 	t1 := time.Now()
-	subjectCount := SubjectSummaryReader(fileName, tree.PropMap, counter, firstN, tree.Typed)
+	subjectCount := SubjectSummaryReader(fileName, tree.PropMap, counter, firstN, tree.Typed, options)
 	propCount, typeCount := tree.PropMap.count()
 
 	fmt.Printf("%v subjects, %v properties, %v types\n", subjectCount, propCount, typeCount)
@@ -333,7 +366,7 @@ func (tree *SchemaTree) firstPass(fileName string, firstN uint64) {
 }
 
 // build schema tree
-func (tree *SchemaTree) secondPass(fileName string, firstN uint64) {
+func (tree *SchemaTree) secondPass(fileName string, firstN uint64, options ReaderOptions) {
 	tree.updateSortOrder() // duplicate -- legacy compatability
 
 	inserter := func(s *SubjectSummary) {
@@ -343,7 +376,8 @@ func (tree *SchemaTree) secondPass(fileName string, firstN uint64) {
 	// go countTreeNodes(schema)
 
 	t1 := time.Now()
-	SubjectSummaryReader(fileName, tree.PropMap, inserter, firstN, tree.Typed)
+	SubjectSummaryReader(fileName, tree.PropMap, inserter, firstN, tree.Typed, options)
+	tree.indexBranches()
 
 	fmt.Println("Second Pass:", time.Since(t1))
 	PrintMemUsage()
@@ -351,19 +385,28 @@ func (tree *SchemaTree) secondPass(fileName string, firstN uint64) {
 }
 
 // TwoPass constructs a SchemaTree from the firstN subjects of the given NTriples file using a two-pass approach
-func (tree *SchemaTree) TwoPass(fileName string, firstN uint64) {
+func (tree *SchemaTree) TwoPass(fileName string, firstN uint64, options ReaderOptions) {
 	// go func() {
 	// 	for true {
 	// 		time.Sleep(10 * time.Second)
 	// 		PrintMemUsage()
 	// 	}
 	// }()
-	tree.firstPass(fileName, firstN)
-	tree.secondPass(fileName, firstN)
+
+	// the standard input is copied to a temporary file, so that it can be read twice
+	fileName, removeFile, err := rio.SpoolStdin(fileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer removeFile()
+
+	tree.firstPass(fileName, firstN, options)
+	tree.secondPass(fileName, firstN, options)
 }
 
 // WritePropFreqs writes all Properties together with their Support to the given File as CSV
-func (tree SchemaTree) WritePropFreqs(file string) {
+// If prefixes are given, the properties are written as CURIEs.
+func (tree SchemaTree) WritePropFreqs(file string, prefixes *rio.PrefixRegistry) {
 	f, err := os.Create(file)
 	if err != nil {
 		log.Fatalln("Could not open file to writePropFreqs!")
@@ -371,15 +414,20 @@ func (tree SchemaTree) WritePropFreqs(file string) {
 	defer f.Close()
 
 	f.WriteString("URI;Frequency\n")
-	for uri, item := range tree.PropMap {
+	for _, item := range tree.PropMap.Items() {
 		if item.IsProp() {
+			uri := item.Str()
+			if prefixes != nil {
+				uri = prefixes.Compact(uri)
+			}
 			f.WriteString(fmt.Sprintf("%v;%v\n", uri, item.TotalCount))
 		}
 	}
 }
 
 // WriteTypeFreqs writes all Types together with their Support to the given File as CSV
-func (tree SchemaTree) WriteTypeFreqs(file string) {
+// If prefixes are given, the types are written as CURIEs.
+func (tree SchemaTree) WriteTypeFreqs(file string, prefixes *rio.PrefixRegistry) {
 	f, err := os.Create(file)
 	if err != nil {
 		log.Fatalln("Could not open file to writeTypeFreqs!")
@@ -387,9 +435,13 @@ func (tree SchemaTree) WriteTypeFreqs(file string) {
 	defer f.Close()
 
 	f.WriteString("URI;Frequency\n")
-	for uri, item := range tree.PropMap {
+	for _, item := range tree.PropMap.Items() {
 		if item.IsType() {
-			f.WriteString(fmt.Sprintf("%v;%v\n", strings.TrimPrefix(uri, "t#"), item.TotalCount))
+			uri := item.IRI()
+			if prefixes != nil {
+				uri = prefixes.Compact(uri)
+			}
+			f.WriteString(fmt.Sprintf("%v;%v\n", uri, item.TotalCount))
 		}
 	}
 }
@@ -403,7 +455,7 @@ func (tree SchemaTree) String() string {
 
 	cluster := ""
 
-	for _, prop := range tree.PropMap {
+	for _, prop := range tree.PropMap.Items() {
 		cluster = ""
 		for node := prop.traversalPointer; node != nil; node = node.nextSameID {
 			if node.Support >= minSupport {
@@ -411,7 +463,7 @@ func (tree SchemaTree) String() string {
 			}
 		}
 		if cluster != "" {
-			s += fmt.Sprintf("subgraph \"cluster_%v\" { rank=same; label=\"%v\"; %v}\n", prop.Str, *prop.Str, cluster)
+			s += fmt.Sprintf("subgraph \"cluster_%p\" { rank=same; label=\"%v\"; %v}\n", prop, prop.Str(), cluster)
 		}
 	}
 
@@ -17,14 +17,14 @@ func TestSchemaTree(t *testing.T) {
 
 // typedTreeTest test a typed schematree generated by the testdata/test.nt.gz file
 func typedTreeTest(t *testing.T, tree *SchemaTree) {
-	assert.EqualValues(t, 184, len(tree.PropMap))
+	assert.EqualValues(t, 184, tree.PropMap.Len())
 	assert.EqualValues(t, 1, tree.MinSup)
 	assert.True(t, tree.Typed)
 }
 
 // typedTreeTest test a untyped schematree generated by the testdata/test.nt.gz file
 func untypedTreeTest(t *testing.T, tree *SchemaTree) {
-	assert.EqualValues(t, 176, len(tree.PropMap))
+	assert.EqualValues(t, 176, tree.PropMap.Len())
 	assert.EqualValues(t, 1, tree.MinSup)
 	assert.False(t, tree.Typed)
 }
@@ -32,12 +32,12 @@ func untypedTreeTest(t *testing.T, tree *SchemaTree) {
 func TestCreate(t *testing.T) {
 
 	t.Run("TypedSchemaTree", func(t *testing.T) {
-		tree, _ := Create(filePath, 0, true, 1)
+		tree, _ := Create(filePath, 0, true, 1, 0, ReaderOptions{})
 		typedTreeTest(t, tree)
 	})
 
 	t.Run("UntypedSchemaTree", func(t *testing.T) {
-		tree, _ := Create(filePath, 0, false, 1)
+		tree, _ := Create(filePath, 0, false, 1, 0, ReaderOptions{})
 		untypedTreeTest(t, tree)
 	})
 }
@@ -46,13 +46,13 @@ func TestTwoPass(t *testing.T) {
 
 	t.Run("typed schematree", func(t *testing.T) {
 		tree := New(true, 1)
-		tree.TwoPass(filePath, 100)
+		tree.TwoPass(filePath, 100, ReaderOptions{})
 		typedTreeTest(t, tree)
 	})
 
 	t.Run("untyped schematree", func(t *testing.T) {
 		tree := New(false, 1)
-		tree.TwoPass(filePath, 100)
+		tree.TwoPass(filePath, 100, ReaderOptions{})
 		untypedTreeTest(t, tree)
 	})
 
@@ -62,14 +62,14 @@ func TestLoad(t *testing.T) {
 
 	t.Run("TypedSchemaTree", func(t *testing.T) {
 		tree, _ := Load("../testdata/10M.nt.gz.schemaTree.typed.bin")
-		assert.EqualValues(t, 1497, len(tree.PropMap))
+		assert.EqualValues(t, 1497, tree.PropMap.Len())
 		assert.EqualValues(t, 1, tree.MinSup)
 		assert.True(t, tree.Typed)
 
 	})
 	t.Run("UnTypedSchemaTree", func(t *testing.T) {
 		tree, _ := Load("../testdata/10M.nt.gz.schemaTree.bin")
-		assert.EqualValues(t, 1242, len(tree.PropMap))
+		assert.EqualValues(t, 1242, tree.PropMap.Len())
 		assert.EqualValues(t, 1, tree.MinSup)
 		assert.False(t, tree.Typed)
 	})
@@ -88,7 +88,7 @@ func TestInsert(t *testing.T) {
 
 	tree.Insert(&s)
 	assert.EqualValues(t, rootSup+1, tree.Root.Support)
-	assert.Equal(t, "http://www.wikidata.org/prop/direct/P31", *tree.Root.getOrCreateChild(prop1).ID.Str)
+	assert.Equal(t, "http://www.wikidata.org/prop/direct/P31", tree.Root.getOrCreateChild(prop1).ID.Str())
 	assert.EqualValues(t, p1Sup+1, tree.Root.getOrCreateChild(prop1).Support)
 
 	tree.Insert(&s)
@@ -23,7 +23,7 @@ func Serve(schema *SchemaTree, port int) {
 
 		list := []*IItem{}
 		for _, pString := range properties {
-			p, ok := pMap[pString]
+			p, ok := pMap.Lookup(pString)
 			if ok {
 				list = append(list, p)
 			}
@@ -34,13 +34,9 @@ func Serve(schema *SchemaTree, port int) {
 
 		// Directly run the SchemaTree recommender
 		var rec PropertyRecommendations
-		rec = schema.RecommendProperty(list)
+		rec = schema.RecommendTopK(list, 500)
 		fmt.Println(time.Since(t1))
 
-		if len(rec) > 500 {
-			rec = rec[:500]
-		}
-
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(rec)
 	}
@@ -56,7 +52,7 @@ func Serve(schema *SchemaTree, port int) {
 
 		list := []*IItem{}
 		for _, pString := range properties {
-			p, ok := pMap["http://www.wikidata.org/prop/direct/"+pString]
+			p, ok := pMap.Lookup("http://www.wikidata.org/prop/direct/" + pString)
 			if ok {
 				list = append(list, p)
 			}
@@ -69,8 +65,8 @@ func Serve(schema *SchemaTree, port int) {
 
 		res := []string{}
 		for _, r := range rec {
-			if strings.HasPrefix(*r.Property.Str, "http://www.wikidata.org/prop/direct/") {
-				res = append(res, strings.TrimPrefix(*r.Property.Str, "http://www.wikidata.org/prop/direct/"))
+			if strings.HasPrefix(r.Property.Str(), "http://www.wikidata.org/prop/direct/") {
+				res = append(res, strings.TrimPrefix(r.Property.Str(), "http://www.wikidata.org/prop/direct/"))
 			}
 		}
 
@@ -0,0 +1,101 @@
+package schematree
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// subjectFilter remembers the subjects that were read, to detect subjects that appear again after other
+// subjects. It is a scalable Bloom filter: it grows by adding Bloom filters of twice the capacity of the
+// last one, with half its false positive rate, so the memory grows with the number of subjects and the
+// total false positive rate stays below twice that of the first filter. A subject is never missed, but
+// new subjects are taken for known ones with that rate.
+type subjectFilter struct {
+	filters []*bloomFilter
+}
+
+const (
+	subjectFilterCapacity = 1 << 20 // subjects in the first Bloom filter
+	subjectFilterErrors   = 1e-7    // false positive rate of the first Bloom filter
+)
+
+// add adds the subject and returns if it was added before (or may have been).
+func (f *subjectFilter) add(subject []byte) (seen bool) {
+	h1, h2 := subjectHashes(subject)
+	for _, filter := range f.filters {
+		if filter.contains(h1, h2) {
+			return true
+		}
+	}
+
+	if len(f.filters) == 0 || f.filters[len(f.filters)-1].full() {
+		capacity, errors := subjectFilterCapacity, subjectFilterErrors
+		if len(f.filters) > 0 {
+			last := f.filters[len(f.filters)-1]
+			capacity, errors = 2*last.capacity, last.errors/2
+		}
+		f.filters = append(f.filters, newBloomFilter(capacity, errors))
+	}
+	f.filters[len(f.filters)-1].add(h1, h2)
+	return false
+}
+
+// bloomFilter is a Bloom filter for a fixed number of elements, which it finds by double hashing
+type bloomFilter struct {
+	bits      []uint64
+	numBits   uint64
+	numHashes uint64
+	capacity  int
+	errors    float64
+	size      int
+}
+
+func newBloomFilter(capacity int, errors float64) *bloomFilter {
+	numBits := uint64(math.Ceil(-float64(capacity) * math.Log(errors) / (math.Ln2 * math.Ln2)))
+	numHashes := uint64(math.Ceil(math.Log2(1 / errors)))
+	return &bloomFilter{
+		bits:      make([]uint64, (numBits+63)/64),
+		numBits:   numBits,
+		numHashes: numHashes,
+		capacity:  capacity,
+		errors:    errors,
+	}
+}
+
+func (b *bloomFilter) add(h1, h2 uint64) {
+	for i := uint64(0); i < b.numHashes; i++ {
+		bit := (h1 + i*h2) % b.numBits
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.size++
+}
+
+func (b *bloomFilter) contains(h1, h2 uint64) bool {
+	for i := uint64(0); i < b.numHashes; i++ {
+		bit := (h1 + i*h2) % b.numBits
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloomFilter) full() bool {
+	return b.size >= b.capacity
+}
+
+// subjectHashes returns two hashes of the subject for double hashing. The FNV hash of similar IRIs is
+// mixed with the finalizer of SplitMix64, which spreads it over all bits.
+func subjectHashes(subject []byte) (h1, h2 uint64) {
+	hash := fnv.New64a()
+	hash.Write(subject)
+	h1 = mix64(hash.Sum64())
+	h2 = mix64(h1 ^ 0x9e3779b97f4a7c15)
+	return h1, h2 | 1 // odd, so that the hashes of a subject differ
+}
+
+func mix64(h uint64) uint64 {
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	return h ^ (h >> 31)
+}
@@ -0,0 +1,31 @@
+package schematree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectFilter(t *testing.T) {
+	filter := &subjectFilter{}
+	numSubjects := 3 * subjectFilterCapacity // fills the first two Bloom filters
+	for i := 0; i < numSubjects; i++ {
+		if filter.add([]byte("<http://example/s" + strconv.Itoa(i) + ">")) {
+			t.Fatalf("subject %v taken for a known subject", i)
+		}
+	}
+	assert.Len(t, filter.filters, 2)
+
+	for i := 0; i < numSubjects; i += 1000 {
+		assert.True(t, filter.add([]byte("<http://example/s"+strconv.Itoa(i)+">")))
+	}
+}
+
+func TestParseRegroupingPolicy(t *testing.T) {
+	policy, err := ParseRegroupingPolicy("report")
+	assert.NoError(t, err)
+	assert.Equal(t, ReportRegrouping, policy)
+	_, err = ParseRegroupingPolicy("fail")
+	assert.Error(t, err)
+}
@@ -7,27 +7,14 @@ I/O and RDF Parsing
 package schematree
 
 import (
-	"bufio"
-	"compress/bzip2"
-	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
-	"os"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
-	"time"
-	"unicode/utf8"
 
 	rio "github.com/lgleim/SchemaTreeRecommender/io"
-
-	gzip "github.com/klauspost/pgzip"
-
-	"github.com/biogo/hts/bgzf"
-	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
 // All type annotations (types) and properties (properties) for a fixed subject
@@ -42,31 +29,80 @@ type SubjectSummary struct {
 func (subj *SubjectSummary) String() string {
 	var properties string
 	for item := range subj.Properties {
-		properties += *item.Str + " "
+		properties += item.Str() + " "
 	}
 	return fmt.Sprintf("{\n  types:      [ %v ]\n  properties: [ %v ]\n}", 0, len(subj.Properties)) //TODO count types
 }
 
-// SubjectSummaryReader reads a RDF Dataset from disk (in N-Triples format) which is expected to be
-// grouped by subjects. For each subject group, the method will build a SubjectSummary structure and
-// send it to a handler function.
+// ReaderOptions are the optional settings of SubjectSummaryReader. The zero value reads all statements.
+type ReaderOptions struct {
+	Graphs        *rio.GraphFilter // only read the statements of these graphs of N-Quads documents
+	GroupSubjects bool             // sort the statements by subject first, for files that are not grouped by subject
+	SortMemory    int              // bytes of statements sorted in memory before they are written to temporary files, see rio.SubjectSorter
+	SortDirectory string           // directory of the temporary files of the sort, the default directory of the system if empty
+	Regrouping    RegroupingPolicy // what to do about subjects that appear again after other subjects
+}
+
+// RegroupingPolicy decides what SubjectSummaryReader does about subjects whose statements are not next to
+// each other, which would be counted once for each group of their statements.
+type RegroupingPolicy int
+
+const (
+	// IgnoreRegrouping does not check if the subjects are grouped
+	IgnoreRegrouping RegroupingPolicy = iota
+	// ReportRegrouping counts the subjects that appear again and prints them after reading the dataset
+	ReportRegrouping
+	// FailOnRegrouping stops reading at the first subject that appears again
+	FailOnRegrouping
+)
+
+// ParseRegroupingPolicy returns the policy with the given name: ignore, report or error.
+func ParseRegroupingPolicy(name string) (RegroupingPolicy, error) {
+	switch name {
+	case "ignore", "":
+		return IgnoreRegrouping, nil
+	case "report":
+		return ReportRegrouping, nil
+	case "error":
+		return FailOnRegrouping, nil
+	}
+	return IgnoreRegrouping, fmt.Errorf("unknown policy for subjects that are not grouped: %v", name)
+}
+
+// maxReportedRegroupings is the number of subjects that appear again which are reported by name
+const maxReportedRegroupings = 10
+
+// SubjectSummaryReader reads a RDF Dataset from disk (in N-Triples, N-Quads, Turtle or TriG format) which is
+// expected to be grouped by subjects, unless options.GroupSubjects sorts it by subject first with bounded
+// memory. For each subject group, the method will build a SubjectSummary structure and send it to a handler
+// function.
 // It will always detect types, but may choose to ignore them.
-//
-// todo: The parsing is done a subset of N-Triple format files. If subjects, predicates or objects contain
-//       any spaces, even if inside quotes, it will break.
+// The syntax is chosen by the file extension, see rio.TripleParser. Invalid lines of N-Triples and N-Quads
+// files are reported and skipped, syntax errors in Turtle and TriG documents are fatal.
+// Wikidata JSON dumps (.json) are read entity by entity instead, see wikidataSummaryReader.
+// With a RegroupingPolicy other than IgnoreRegrouping, the subjects are remembered in a Bloom filter (see
+// subjectFilter) to find subjects that appear again; a few subjects may be taken for such by mistake.
+// The subject names and properties are IRIs without angle brackets; blank nodes are kept as `_:label`.
+// It returns the number of subjects that were sent to the handler, which is at most firstN if that is set.
 func SubjectSummaryReader(
 	fileName string, // path to the file that should be parsed
-	pMap propMap, // maps of properties that the schematree recognizes
+	pMap *propMap, // maps of properties that the schematree recognizes
 	handler func(s *SubjectSummary), // handler function that gets executed after a SubjectSummary is completed
 	firstN uint64, // stop after N subjects are read; setting this to zero will read all entries
 	willConvertTypes bool, // true if the reader should convert identified type entries into TypeProperties.
+	options ReaderOptions,
 ) (subjectCount uint64) {
+	if rio.SyntaxOf(fileName) == rio.WikidataJSON {
+		return wikidataSummaryReader(fileName, pMap, handler, firstN, willConvertTypes)
+	}
+
 	// IO setup
-	reader, err := rio.UniversalReader(fileName)
+	parser, err := rio.NewTripleParser(fileName)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer reader.Close()
+	defer parser.Close()
+	parser.Graphs = options.Graphs
 
 	// set up concurrent handler routines
 	concurrency := runtime.NumCPU() // * 4    (should be fine with NumCPU since thats num of logical cpus and has no IO operation)
@@ -83,40 +119,44 @@ func SubjectSummaryReader(
 	}
 
 	// parse file
-	var isPrefix, skip bool
-	var line, token []byte
+	var terms [4][]byte
+	numTerms := 2 // the object is only needed for types
+	if willConvertTypes {
+		numTerms = 3
+	}
 	var lastSubj string
-	var bytesProcessed int
-	scanner := bufio.NewReaderSize(reader, 4*1024*1024) // 4MB line Buffer
 	var summary *SubjectSummary
 	//summary := &SubjectSummary{Properties: make(map[*IItem]uint32)}
-	typeProps := []*IItem{
-		pMap.get("http://www.wikidata.org/prop/direct/P31"),
-		pMap.get("http://www.w3.org/1999/02/22-rdf-syntax-ns#type"),
-		pMap.get("http://dbpedia.org/ontology/type"),
+	typeIRIs := []string{
+		"http://www.wikidata.org/prop/direct/P31",
+		"http://www.w3.org/1999/02/22-rdf-syntax-ns#type",
+		"http://dbpedia.org/ontology/type",
+	}
+	typeProps := make([]*IItem, len(typeIRIs))
+	typeTerms := make([][]byte, len(typeIRIs))
+	for i, iri := range typeIRIs {
+		typeProps[i], typeTerms[i] = pMap.get(iri), []byte("<"+iri+">")
 	}
+	parser.DecodeObjectsOf(typeTerms...) // the other objects are not used, which saves their lookup in HDT files
 
-	for line, isPrefix, err = scanner.ReadLine(); err == nil; line, isPrefix, err = scanner.ReadLine() {
-		if isPrefix {
-			fmt.Printf("Line Buffer too small!!! Line prefix: %v\n", string(line[:200]))
-			skip = true
-			continue
-		}
-		if skip { // !isPrefix follows implicitly
-			skip = false
-			continue
-		}
+	nextStatement := func() ([4][]byte, error) { return parser.NextStatement(numTerms) }
+	if options.GroupSubjects {
+		sorter := rio.NewSubjectSorter(parser, numTerms, options.SortMemory, options.SortDirectory)
+		defer sorter.Close()
+		nextStatement = sorter.NextStatement
+	}
 
-		// process subject
-		bytesProcessed, token = firstWord(line)
+	var seenSubjects *subjectFilter
+	var regrouped []string
+	var regroupedCount uint64
+	if options.Regrouping != IgnoreRegrouping {
+		seenSubjects = &subjectFilter{}
+	}
 
-		// if r, _ := utf8.DecodeRune(token); r == '#' { // line is a comment
-		if len(token) == 0 || token[0] == '#' { // line is a comment
-			continue
-		}
+	for terms, err = nextStatement(); err == nil; terms, err = nextStatement() {
 
 		// If this a new subject, emit the previous predicate set and start clean
-		if lastSubj != string(token) { // should only be allocated on stack - c.f. https://github.com/golang/go/issues/11777
+		if lastSubj != string(terms[0]) { // should only be allocated on stack - c.f. https://github.com/golang/go/issues/11777
 			if lastSubj != "" {
 				summaries <- summary
 				if subjectCount++; firstN > 0 && subjectCount >= firstN {
@@ -124,13 +164,22 @@ func SubjectSummaryReader(
 				}
 			}
 
-			lastSubj = string(token) // allocate string (on heap)
-			summary = &SubjectSummary{Properties: make(map[*IItem]uint32), Str: lastSubj}
+			// Check if the subject was read before, in another group of statements
+			if seenSubjects != nil && seenSubjects.add(terms[0]) {
+				if options.Regrouping == FailOnRegrouping {
+					log.Fatalf("Subject %s appears again after %v subjects were read. The dataset is not grouped by subject, sort it first, e.g. with GroupSubjects (--group-subjects).\n", terms[0], subjectCount)
+				}
+				if regroupedCount++; regroupedCount <= maxReportedRegroupings {
+					regrouped = append(regrouped, string(terms[0]))
+				}
+			}
+
+			lastSubj = string(terms[0]) // allocate string (on heap)
+			summary = &SubjectSummary{Properties: make(map[*IItem]uint32), Str: string(rio.IRIValue(terms[0]))}
 		}
 
 		// process predicate
-		line = line[bytesProcessed:]
-		bytesProcessed, token = firstWord(line)
+		token := rio.IRIValue(terms[1])
 
 		// c.f. https://www.mediawiki.org/wiki/Wikibase/Indexing/RDF_Dump_Format#Prefixes_used
 		if strings.HasPrefix(string(token), "http://www.wikidata.org/prop/") &&
@@ -156,11 +205,9 @@ func SubjectSummaryReader(
 			if predicate == typeProp {
 				summary.NumTypePredicates++
 
-				// If set to convert types, then read the object to generate a type property from it.
+				// If set to convert types, then use the object to generate a type property from it.
 				if willConvertTypes {
-					line = line[bytesProcessed:]
-					bytesProcessed, token = firstWord(line)
-					tokenStr := "t#" + string(token) // prefix t# identifies properties that represent types
+					tokenStr := "t#" + string(rio.IRIValue(terms[2])) // prefix t# identifies properties that represent types
 					pType := pMap.get(tokenStr)
 					summary.Properties[pType]++
 				}
@@ -169,9 +216,10 @@ func SubjectSummaryReader(
 		}
 	}
 
-	// dispatch last summary
-	if len(summary.Properties) > 0 {
+	// dispatch last summary, unless it was already dispatched when reaching firstN
+	if summary != nil && len(summary.Properties) > 0 && (firstN == 0 || subjectCount < firstN) {
 		summaries <- summary
+		subjectCount++
 	}
 
 	if err != nil && err != io.EOF {
@@ -180,138 +228,16 @@ func SubjectSummaryReader(
 	close(summaries)
 	wg.Wait()
 
-	return
-}
-
-// Adapted from 'ScanWords' in https://golang.org/src/bufio/scan.go
-//
-// firstWord returns the first space-separated word of text, with
-// surrounding spaces & angle brackets deleted.
-func firstWord(data []byte) (advance int, token []byte) {
-	// Skip leading spaces.
-	start := 0
-	for width := 0; start < len(data); start += width {
-		if data[start] < utf8.RuneSelf {
-			if !isSpaceOrBracket(rune(data[start])) {
-				break
-			}
-			width = 1
-		} else {
-			var r rune
-			r, width = utf8.DecodeRune(data[start:])
-			if !isSpaceOrBracket(r) {
-				break
-			}
-		}
-	}
-	// Scan until space, marking end of word.
-	for width, i := 0, start; i < len(data); i += width {
-		// Fast path 1: ASCII.
-		if data[i] < utf8.RuneSelf {
-			if isSpaceOrBracket(rune(data[i])) {
-				return i + 1, data[start:i]
-			}
-			width = 1
-		} else {
-			var r rune
-			r, width = utf8.DecodeRune(data[i:])
-			if isSpaceOrBracket(r) {
-				return i + width, data[start:i]
-			}
-		}
+	if regroupedCount > 0 {
+		fmt.Printf("%v subjects appear again after other subjects and were counted more than once, e.g. %v\n",
+			regroupedCount, strings.Join(regrouped, ", "))
+		fmt.Println("The dataset is not grouped by subject, sort it first, e.g. with GroupSubjects (--group-subjects).")
 	}
-	// If we're at EOL, we have a final, non-empty, non-terminated word. Return it.
-	if len(data) > start {
-		return len(data), data[start:]
-	}
-	// Request more data.
-	return start, nil
-}
 
-// Adapted from 'isSpace' in https://golang.org/src/bufio/scan.go
-//
-// isSpace reports whether the character is a Unicode white space character .
-func isSpaceOrBracket(r rune) bool {
-	if r <= '\u00FF' {
-		// Obvious ASCII ones: \t through \r plus space. Plus two Latin-1 oddballs.
-		switch r {
-		case ' ', '\t', '\n', '\v', '\f', '\r', '<', '>':
-			return true
-		case '\u0085', '\u00A0':
-			return true
-		}
-		return false
-	}
-	// High-valued ones.
-	if '\u2000' <= r && r <= '\u200a' {
-		return true
-	}
-	switch r {
-	case '\u1680', '\u2028', '\u2029', '\u202f', '\u205f', '\u3000':
-		return true
-	}
-	return false
-}
-
-// UniversalReader opens a file for read mode. It is able to automatically decompress
-// files that use either GZ, BGZ or BZ2. It will also display a loading bar on stdout.
-func UniversalReader(fileName string) (reader io.ReadCloser, err error) {
-	if stat, _ := os.Stdin.Stat(); (stat.Mode() & os.ModeCharDevice) == 0 {
-		fmt.Println("Reading data from stdin")
-		reader = os.Stdin
-	} else {
-		var file *os.File
-		file, err = os.Open(fileName)
-		if err != nil {
-			return
-		}
-
-		var stat os.FileInfo
-		stat, err = file.Stat()
-		if err != nil {
-			return
-		}
-		if stat.IsDir() {
-			err = errors.New("Reading entire directories is not yet possible")
-			return
-		}
-		fmt.Printf("Reading data from file '%v'. Progress w.r.t. on-disk-size: \n", fileName)
-
-		// create and start progress bar
-		bar := pb.New(int(stat.Size())).SetUnits(pb.U_BYTES).SetRefreshRate(500 * time.Millisecond).Start()
-		bar.ShowElapsedTime = true
-		bar.ShowSpeed = true
-		reader = bar.NewProxyReader(file)
-
-		// decompress stream if applicable
-		switch ext := filepath.Ext(fileName); ext {
-		case ".bz2":
-			reader = ioutil.NopCloser(bzip2.NewReader(reader)) // Decompression
-		case ".gz":
-			reader, err = gzip.NewReaderN(reader, 8*1024*1024, 48) // readahead
-		case ".bgz":
-			reader, err = bgzf.NewReader(reader, 0)
-			//case ".zst", ".zstd":
-			//reader = releaseCloser{gozstd.NewReader(reader)}
-		}
-
-		if err != nil {
-			return
-		}
-
-		reader = finishCloser{reader, bar, file}
-	}
 	return
 }
 
-type finishCloser struct {
-	io.ReadCloser
-	bar  *pb.ProgressBar
-	file *os.File
-}
-
-func (r finishCloser) Close() error {
-	r.bar.Finish()
-	defer r.file.Close()
-	return r.ReadCloser.Close()
+// UniversalReader opens a file for read mode, see rio.UniversalReader.
+func UniversalReader(fileName string) (reader io.ReadCloser, err error) {
+	return rio.UniversalReader(fileName)
 }
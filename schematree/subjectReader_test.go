@@ -0,0 +1,225 @@
+package schematree
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/stretchr/testify/assert"
+)
+
+// readSubjects reads the dataset and returns the number of properties of each subject
+func readSubjects(t *testing.T, path string, options ReaderOptions) map[string]int {
+	subjects := map[string]int{}
+	var lock sync.Mutex
+	SubjectSummaryReader(path, newPropMap(), func(s *SubjectSummary) {
+		lock.Lock()
+		subjects[s.Str] = len(s.Properties)
+		lock.Unlock()
+	}, 0, true, options)
+	return subjects
+}
+
+func TestSubjectSummaryReaderQuads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.nq")
+	data := "<http://example/s1> <http://example/p1> <http://example/o> <http://example/g> .\n" +
+		"<http://example/s1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example/T> .\n" +
+		"<http://example/s1> <http://example/p2> \"literal\" <http://example/h> .\n" +
+		"_:s2 <http://example/p1> <http://example/o> <http://example/h> .\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	all := readSubjects(t, path, ReaderOptions{})
+	assert.Equal(t, map[string]int{"http://example/s1": 4, "_:s2": 1}, all) // p1, type, t#T, p2
+
+	excluded := readSubjects(t, path, ReaderOptions{Graphs: rio.NewGraphFilter(nil, []string{"http://example/h"})})
+	assert.Equal(t, map[string]int{"http://example/s1": 3}, excluded)
+
+	included := readSubjects(t, path, ReaderOptions{Graphs: rio.NewGraphFilter([]string{rio.DefaultGraph}, nil)})
+	assert.Equal(t, map[string]int{"http://example/s1": 2}, included)
+}
+
+func TestSubjectSummaryReaderTurtle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ttl")
+	data := "@prefix ex: <http://example/> .\n" +
+		"ex:s1 a ex:T ; ex:p1 [ ex:p2 ex:o ] ; ex:p3 \"literal\" .\n" +
+		"_:s2 ex:p1 ex:o .\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	subjects := readSubjects(t, path, ReaderOptions{})
+	assert.Equal(t, map[string]int{"http://example/s1": 4, "_:genid1": 1, "_:s2": 1}, subjects) // type, t#T, p1, p3
+}
+
+func TestSubjectSummaryReaderWikidata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+	data := "[\n" +
+		`{"type":"item","id":"Q1","claims":{"P31":[{"mainsnak":{"snaktype":"value","property":"P31","datavalue":{"value":{"id":"Q5"},"type":"wikibase-entityid"}},"rank":"normal"}],` +
+		`"P21":[{"mainsnak":{"snaktype":"value","property":"P21","datavalue":{"value":{"id":"Q6"},"type":"wikibase-entityid"}},"rank":"deprecated"}]}},` + "\n" +
+		`{"type":"property","id":"P31","claims":{"P1629":[{"mainsnak":{"snaktype":"value","property":"P1629","datavalue":{"value":{"id":"Q21503252"},"type":"wikibase-entityid"}},"rank":"normal"}]}},` + "\n" +
+		`{"type":"item","id":"Q2","claims":{"P21":[{"mainsnak":{"snaktype":"somevalue","property":"P21"},"rank":"normal"}]}}` + "\n" +
+		"]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	subjects := readSubjects(t, path, ReaderOptions{})
+	assert.Equal(t, map[string]int{"http://www.wikidata.org/entity/Q1": 2, "http://www.wikidata.org/entity/Q2": 1}, subjects) // P31, t#Q5; P21
+
+	pMap := newPropMap()
+	SubjectSummaryReader(path, pMap, func(s *SubjectSummary) {}, 0, true, ReaderOptions{})
+	_, ok := pMap.Lookup("t#http://www.wikidata.org/entity/Q5")
+	assert.True(t, ok)
+	_, ok = pMap.Lookup("http://www.wikidata.org/prop/direct/P21")
+	assert.True(t, ok)
+
+	// the first N items of the dump are read, although the later items are smaller and would be decoded first
+	data = "[\n"
+	claim := `{"mainsnak":{"snaktype":"somevalue","property":"P21"},"rank":"normal"}`
+	var expected []string
+	for i := 1; i <= 100; i++ {
+		id := "Q" + strconv.Itoa(i)
+		claims := claim
+		if i <= 10 {
+			claims += strings.Repeat(","+claim, 2000)
+			expected = append(expected, "http://www.wikidata.org/entity/"+id)
+		}
+		data += `{"type":"item","id":"` + id + `","claims":{"P21":[` + claims + `]}},` + "\n"
+	}
+	data += "]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var handled []string
+	var lock sync.Mutex
+	count := SubjectSummaryReader(path, newPropMap(), func(s *SubjectSummary) {
+		lock.Lock()
+		handled = append(handled, s.Str)
+		lock.Unlock()
+	}, 10, false, ReaderOptions{})
+	assert.Equal(t, uint64(10), count)
+	assert.ElementsMatch(t, expected, handled)
+}
+
+func TestSubjectSummaryReaderFirstN(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.nt")
+	data := "<http://example/s1> <http://example/p1> <http://example/o> .\n" +
+		"<http://example/s2> <http://example/p1> <http://example/o> .\n" +
+		"<http://example/s2> <http://example/p2> <http://example/o> .\n" +
+		"<http://example/s3> <http://example/p1> <http://example/o> .\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// read returns the subjects that were handled, each of them once
+	read := func(path string, firstN uint64) (count uint64, handled []string) {
+		var lock sync.Mutex
+		count = SubjectSummaryReader(path, newPropMap(), func(s *SubjectSummary) {
+			lock.Lock()
+			handled = append(handled, s.Str)
+			lock.Unlock()
+		}, firstN, false, ReaderOptions{})
+		sort.Strings(handled)
+		return
+	}
+
+	// the last subject is handled and counted
+	count, handled := read(path, 0)
+	assert.Equal(t, uint64(3), count)
+	assert.Equal(t, []string{"http://example/s1", "http://example/s2", "http://example/s3"}, handled)
+
+	// reading stops after the first N subjects, which are handled once
+	count, handled = read(path, 2)
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, []string{"http://example/s1", "http://example/s2"}, handled)
+
+	// the limit may reach the last subject
+	count, handled = read(path, 3)
+	assert.Equal(t, uint64(3), count)
+	assert.Len(t, handled, 3)
+
+	// a dataset without statements has no subjects
+	empty := filepath.Join(dir, "empty.nt")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	count, handled = read(empty, 0)
+	assert.Equal(t, uint64(0), count)
+	assert.Empty(t, handled)
+}
+
+func TestSubjectSummaryReaderGroupSubjects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unsorted.nt")
+	data := "<http://example/s1> <http://example/p1> <http://example/o> .\n" +
+		"<http://example/s2> <http://example/p1> <http://example/o> .\n" +
+		"<http://example/s1> <http://example/p2> <http://example/o> .\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// s1 is split into two summaries without grouping
+	assert.Equal(t, uint64(3), SubjectSummaryReader(path, newPropMap(), func(s *SubjectSummary) {}, 0, false, ReaderOptions{}))
+
+	options := ReaderOptions{GroupSubjects: true, SortMemory: 1, SortDirectory: t.TempDir()}
+	subjects := readSubjects(t, path, options)
+	assert.Equal(t, map[string]int{"http://example/s1": 2, "http://example/s2": 1}, subjects)
+}
+
+func TestSubjectSummaryReaderRegrouping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unsorted.nt")
+	data := "<http://example/s1> <http://example/p1> <http://example/o> .\n" +
+		"<http://example/s2> <http://example/p1> <http://example/o> .\n" +
+		"<http://example/s1> <http://example/p2> <http://example/o> .\n" +
+		"<http://example/s3> <http://example/p1> <http://example/o> .\n" +
+		"<http://example/s2> <http://example/p2> <http://example/o> .\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// reported, but read as before
+	options := ReaderOptions{Regrouping: ReportRegrouping}
+	var count uint64
+	output := captureStdout(t, func() {
+		count = SubjectSummaryReader(path, newPropMap(), func(s *SubjectSummary) {}, 0, false, options)
+	})
+	assert.Equal(t, uint64(5), count)
+	assert.Contains(t, output, "2 subjects appear again after other subjects and were counted more than once, e.g. <http://example/s1>, <http://example/s2>\n")
+	assert.Contains(t, output, "The dataset is not grouped by subject")
+
+	// grouped datasets are not reported
+	options.GroupSubjects = true
+	output = captureStdout(t, func() {
+		count = SubjectSummaryReader(path, newPropMap(), func(s *SubjectSummary) {}, 0, false, options)
+	})
+	assert.Equal(t, uint64(3), count)
+	assert.NotContains(t, output, "appear again")
+}
+
+// captureStdout returns what the function prints to the standard output
+func captureStdout(t *testing.T, f func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	defer func() { os.Stdout = stdout }()
+	f()
+	writer.Close()
+	return <-output
+}
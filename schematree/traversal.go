@@ -0,0 +1,97 @@
+package schematree
+
+// Traversal remembers the tree branches that match a property set, such that the branches of a
+// superset can be found without searching the whole tree again. This speeds up editing sessions,
+// in which properties are added one after the other.
+type Traversal struct {
+	properties IList         // sorted, the least frequent property is last
+	leaves     []*SchemaNode // nodes of the least frequent property whose prefix contains all properties
+}
+
+// Traverse finds all branches of the tree that match the given property set.
+func (tree *SchemaTree) Traverse(properties IList) *Traversal {
+	sorted := append(IList{}, properties...)
+	sorted.Sort()
+
+	// without given properties, the root node is the only branch
+	if len(sorted) == 0 {
+		return &Traversal{properties: sorted, leaves: []*SchemaNode{&tree.Root}}
+	}
+
+	leaves := []*SchemaNode{}
+	for leaf := sorted[len(sorted)-1].traversalPointer; leaf != nil; leaf = leaf.nextSameID {
+		if leaf.prefixContains(sorted) {
+			leaves = append(leaves, leaf)
+		}
+	}
+	return &Traversal{properties: sorted, leaves: leaves}
+}
+
+// Properties returns the sorted property set of the traversal.
+func (t *Traversal) Properties() IList {
+	return t.properties
+}
+
+// Refine returns the traversal of the property set extended by the given property.
+//
+// If the property is not the least frequent property of the extended set, it has to lie above the matching
+// branches of the set in the tree, thus these branches only need to be filtered. Otherwise the branches
+// of the new least frequent property have to be searched.
+func (tree *SchemaTree) Refine(t *Traversal, property *IItem) *Traversal {
+	for _, p := range t.properties {
+		if p == property {
+			return t
+		}
+	}
+
+	properties := append(append(IList{}, t.properties...), property)
+	properties.Sort()
+	if len(t.properties) > 0 && properties[len(properties)-1] != property {
+		single := IList{property}
+		leaves := make([]*SchemaNode, 0, len(t.leaves))
+		for _, leaf := range t.leaves {
+			if leaf.prefixContains(single) {
+				leaves = append(leaves, leaf)
+			}
+		}
+		return &Traversal{properties: properties, leaves: leaves}
+	}
+	return tree.Traverse(properties)
+}
+
+// RecommendTraversal recommends properties for the property set of the traversal. The result is the
+// same as that of RecommendProperty, but the matching branches are not searched again.
+func (tree *SchemaTree) RecommendTraversal(t *Traversal) PropertyRecommendations {
+	if len(t.properties) == 0 {
+		return tree.rankEmptySet(nil)
+	}
+
+	pSet := t.properties.toSet()
+	candidates := make(map[*IItem]uint32)
+	var setSupport uint64
+
+	var makeCandidates func(startNode *SchemaNode)
+	makeCandidates = func(startNode *SchemaNode) {
+		for _, child := range startNode.Children {
+			if child.ID.IsProp() {
+				candidates[child.ID] += child.Support
+			}
+			makeCandidates(child)
+		}
+	}
+
+	for _, leaf := range t.leaves {
+		// walk down
+		makeCandidates(leaf)
+		setSupport += uint64(leaf.Support)
+
+		// walk up
+		for cur := leaf; cur.parent != nil; cur = cur.parent {
+			if !pSet[cur.ID] && cur.ID.IsProp() {
+				candidates[cur.ID] += leaf.Support
+			}
+		}
+	}
+
+	return rankCandidates(candidates, setSupport, len(t.leaves))
+}
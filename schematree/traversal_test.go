@@ -0,0 +1,45 @@
+package schematree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraversal(t *testing.T) {
+
+	tree, _ := Load(typedTreepath)
+	pMap := tree.PropMap
+	p31 := pMap.get("http://www.wikidata.org/prop/direct/P31")
+	p17 := pMap.get("http://www.wikidata.org/prop/direct/P17")
+	p625 := pMap.get("http://www.wikidata.org/prop/direct/P625")
+	city := pMap.get("t#http://www.wikidata.org/entity/Q515")
+
+	// the same as RecommendProperty, up to the order of equally probable candidates
+	assertSame := func(t *testing.T, properties IList, recs PropertyRecommendations) {
+		expected := tree.RecommendProperty(append(IList{}, properties...))
+		assert.Len(t, recs, len(expected))
+		probabilities := make(map[*IItem]float64)
+		for _, rec := range expected {
+			probabilities[rec.Property] = rec.Probability
+		}
+		for _, rec := range recs {
+			assert.Equal(t, probabilities[rec.Property], rec.Probability, rec.Property.Str())
+		}
+	}
+
+	// add properties in both frequency orders, such that branches are filtered as well as searched
+	for _, order := range []IList{{p31, city, p17, p625}, {p625, p17, city, p31}} {
+		t.Run(order.String(), func(t *testing.T) {
+			traversal := tree.Traverse(IList{})
+			assertSame(t, IList{}, tree.RecommendTraversal(traversal))
+			for i, p := range order {
+				traversal = tree.Refine(traversal, p)
+				assert.Len(t, traversal.Properties(), i+1)
+				assertSame(t, order[:i+1], tree.RecommendTraversal(traversal))
+			}
+			assert.Equal(t, traversal, tree.Refine(traversal, p31))
+		})
+	}
+
+}
@@ -0,0 +1,168 @@
+package schematree
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+)
+
+// wikidataSubject holds the truthy properties and types of an item, decoded from its JSON
+type wikidataSubject struct {
+	iri        string
+	properties []string // property IRIs, once per claim
+	types      []string // type IRIs, i.e. the values of the P31 claims
+}
+
+// wikidataSummaryReader is the SubjectSummaryReader of Wikidata JSON dumps. Each item of the dump becomes
+// a SubjectSummary with the same properties and types as the item has in the truthy N-Triples dump,
+// filtered for the schematree: its truthy claims (wdt:P...) as properties, and the values of its P31
+// claims (instance of) as types. Properties and lexemes of the dump are skipped.
+//
+// The JSON is decoded concurrently; only the property map is accessed by a single goroutine. If firstN is
+// set, the entities are decoded by a single goroutine instead, so that the first N items of the dump are
+// read rather than an arbitrary set of the items decoded first.
+func wikidataSummaryReader(
+	fileName string,
+	pMap *propMap,
+	handler func(s *SubjectSummary),
+	firstN uint64,
+	willConvertTypes bool,
+) (subjectCount uint64) {
+	reader, err := rio.NewEntityReader(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer reader.Close()
+
+	concurrency := runtime.NumCPU()
+	lines := make(chan []byte, concurrency)
+	subjects := make(chan *wikidataSubject, concurrency)
+	done := make(chan struct{}) // closed to stop reading when firstN subjects are read
+
+	// read the lines of the dump
+	var readErr error
+	go func() {
+		defer close(lines)
+		for {
+			line, err := reader.NextJSON()
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+			select {
+			case lines <- line:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// decode the entities concurrently, or in the order of the dump for the first N items
+	decoderCount := concurrency
+	if firstN > 0 {
+		decoderCount = 1
+	}
+	var invalid uint64
+	var decoders sync.WaitGroup
+	decoders.Add(decoderCount)
+	for i := 0; i < decoderCount; i++ {
+		go func() {
+			defer decoders.Done()
+			for line := range lines {
+				entity, err := rio.DecodeClaims(line)
+				if err != nil {
+					if atomic.AddUint64(&invalid, 1) <= 10 {
+						fmt.Printf("Skipping invalid entity: %v\n", err)
+					}
+					continue
+				}
+				if entity.Type != "item" {
+					continue
+				}
+				if subject := truthySubject(entity); len(subject.properties) > 0 {
+					select {
+					case subjects <- subject:
+					case <-done: // drop the subject, but drain the lines until the reader stops
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		decoders.Wait()
+		close(subjects)
+	}()
+
+	// set up concurrent handler routines
+	summaries := make(chan *SubjectSummary)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			for s := range summaries {
+				handler(s)
+			}
+			wg.Done()
+		}()
+	}
+
+	// build the summaries, the property map is not safe for concurrent use
+	typeProp := pMap.get(rio.WikidataPropertyPrefix + "P31")
+	for subject := range subjects {
+		summary := &SubjectSummary{Properties: make(map[*IItem]uint32), Str: subject.iri}
+		for _, property := range subject.properties {
+			predicate := pMap.get(property)
+			summary.Properties[predicate]++
+			summary.NumPredicates++
+			if predicate == typeProp {
+				summary.NumTypePredicates++
+			}
+		}
+		if willConvertTypes {
+			for _, typ := range subject.types {
+				summary.Properties[pMap.get("t#"+typ)]++ // prefix t# identifies properties that represent types
+			}
+		}
+
+		summaries <- summary
+		if subjectCount++; firstN > 0 && subjectCount >= firstN {
+			close(done)
+			break
+		}
+	}
+	for range subjects { // let the decoders finish after stopping early
+	}
+	close(summaries)
+	wg.Wait()
+
+	if invalid > 10 {
+		fmt.Printf("Skipped %v invalid entities in total\n", invalid)
+	}
+	if readErr != nil {
+		log.Fatalf("Failed to read the Wikidata JSON dump: %v\n", readErr)
+	}
+	return
+}
+
+// truthySubject collects the properties and types of the truthy claims of the entity
+func truthySubject(entity *rio.Entity) *wikidataSubject {
+	subject := &wikidataSubject{iri: rio.WikidataEntityPrefix + entity.ID}
+	for property := range entity.Claims {
+		for _, claim := range entity.TruthyClaims(property) {
+			subject.properties = append(subject.properties, rio.WikidataPropertyPrefix+property)
+			if property == "P31" {
+				if id := claim.MainSnak.EntityID(); id != "" {
+					subject.types = append(subject.types, rio.WikidataEntityPrefix+id)
+				}
+			}
+		}
+	}
+	return subject
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/lgleim/SchemaTreeRecommender/assessment"
 	"github.com/lgleim/SchemaTreeRecommender/glossary"
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
 	"github.com/lgleim/SchemaTreeRecommender/schematree"
 	"github.com/lgleim/SchemaTreeRecommender/strategy"
 )
@@ -17,11 +18,15 @@ type RecommenderRequest struct {
 	Lang       string   `json:"lang"`
 	Types      []string `json:"types"`
 	Properties []string `json:"properties"`
+	Absent     []string `json:"absent"`  // optional: properties the subject is known not to have
+	Compact    bool     `json:"compact"` // optional: output CURIEs like wdt:P31 instead of full IRIs
+	Explain    bool     `json:"explain"` // optional: include the support evidence of each recommendation
 }
 
 // RecommenderResponse is the data representation of the json.
 type RecommenderResponse struct {
 	Recommendations []RecommendationOutputEntry `json:"recommendations"`
+	Unknown         []string                    `json:"unknown,omitempty"` // input and absent properties and types unknown to the model
 }
 
 // RecommendationOutputEntry is each entry that is return from the server.
@@ -30,6 +35,10 @@ type RecommendationOutputEntry struct {
 	Label       *string `json:"label"`
 	Description *string `json:"description"`
 	Probability float64 `json:"probability"`
+	Support     uint64  `json:"support,omitempty"`    // only with explain: subjects having the input set and this property
+	SetSupport  uint64  `json:"setSupport,omitempty"` // only with explain: subjects having the input set
+	Branches    int     `json:"branches,omitempty"`   // only with explain: tree branches matching the input set
+	Error       float64 `json:"error,omitempty"`      // only with explain: 95% error bound of an approximate probability
 }
 
 // setupRecommender will setup a handler to recommend properties based on the list of properties and types. It
@@ -40,6 +49,8 @@ func setupMappedRecommender(
 	glos *glossary.Glossary,
 	workflow *strategy.Workflow,
 	hardLimit int, // Hard limit of recommendations to output
+	unknown schematree.UnknownHandling, // How to treat input properties and types unknown to the model
+	prefixes *rio.PrefixRegistry, // Prefixes of the CURIEs that may be used in- and output
 ) func(http.ResponseWriter, *http.Request) {
 
 	// // Build the JSON-Schema
@@ -91,8 +102,24 @@ func setupMappedRecommender(
 
 		// TODO: Probably some more input sanitization is required.
 
+		// Match the input strings to build a list of input properties. CURIEs are expanded to full IRIs first.
+		list, unknownInput, err := model.ResolvePropertyList(prefixes.ExpandAll(input.Properties), prefixes.ExpandAll(input.Types), unknown)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Properties known to be absent are resolved the same way, and reported if they are unknown.
+		absent, unknownAbsent, err := model.ResolvePropertyList(prefixes.ExpandAll(input.Absent), nil, unknown)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		unknownInput = append(unknownInput, unknownAbsent...)
+
 		// Make an assessment of the input properties.
-		assessment := assessment.NewInstanceFromInput(input.Properties, input.Types, model, true)
+		assessment := assessment.NewInstance(list, model, true)
+		assessment.Absent = absent
 
 		// Make a recommendation based on the assessed input and chosen strategy.
 		t1 := time.Now()
@@ -110,14 +137,25 @@ func setupMappedRecommender(
 		// Prepare the recommendation list. The structure of the output is flatter than the labeled recommendations.
 		outputRecs := make([]RecommendationOutputEntry, len(labRecs), len(labRecs))
 		for i, rec := range labRecs {
-			outputRecs[i].PropertyStr = rec.Property.Str
+			iri := rec.Property.Str()
+			outputRecs[i].PropertyStr = &iri
+			if input.Compact {
+				curie := prefixes.Compact(iri)
+				outputRecs[i].PropertyStr = &curie
+			}
 			outputRecs[i].Label = &rec.Content.Label
 			outputRecs[i].Description = &rec.Content.Description
 			outputRecs[i].Probability = rec.Probability
+			if input.Explain {
+				outputRecs[i].Support = origRecs[i].Support
+				outputRecs[i].SetSupport = origRecs[i].SetSupport
+				outputRecs[i].Branches = origRecs[i].Branches
+				outputRecs[i].Error = origRecs[i].Error
+			}
 		}
 
 		// Pack everything into the response
-		recResp := RecommenderResponse{Recommendations: outputRecs}
+		recResp := RecommenderResponse{Recommendations: outputRecs, Unknown: unknownInput}
 
 		// Write the recommendations as a JSON array.
 		res.Header().Set("Content-Type", "application/json")
@@ -126,10 +164,175 @@ func setupMappedRecommender(
 
 }
 
+// TypeRecommenderResponse is the data representation of the json returned by the type recommender.
+type TypeRecommenderResponse struct {
+	Recommendations []TypeRecommendationOutputEntry `json:"recommendations"`
+	Unknown         []string                        `json:"unknown,omitempty"` // input properties and types unknown to the model
+}
+
+// TypeRecommendationOutputEntry is each entry that is returned from the type recommender.
+type TypeRecommendationOutputEntry struct {
+	TypeStr     string  `json:"type"`
+	Label       *string `json:"label"`
+	Description *string `json:"description"`
+	Probability float64 `json:"probability"`
+	Support     uint64  `json:"support,omitempty"`    // only with explain: subjects having the input set and this type
+	SetSupport  uint64  `json:"setSupport,omitempty"` // only with explain: subjects having the input set
+	Branches    int     `json:"branches,omitempty"`   // only with explain: tree branches matching the input set
+	Error       float64 `json:"error,omitempty"`      // only with explain: 95% error bound of an approximate probability
+}
+
+// setupTypeRecommender will setup a handler to recommend types based on the list of properties and types. It
+// also receives a language with which additional information is added.
+// It will return an array of type recommendations, with their respective probabilities, labels and descriptions.
+// Only typed SchemaTrees can recommend types.
+func setupTypeRecommender(
+	model *schematree.SchemaTree,
+	glos *glossary.Glossary,
+	workflow *strategy.Workflow,
+	hardLimit int, // Hard limit of recommendations to output
+	unknown schematree.UnknownHandling, // How to treat input properties and types unknown to the model
+	prefixes *rio.PrefixRegistry, // Prefixes of the CURIEs that may be used in- and output
+) func(http.ResponseWriter, *http.Request) {
+	return func(res http.ResponseWriter, req *http.Request) {
+
+		// Decode the JSON input and build a list of input strings
+		var input = RecommenderRequest{}
+		err := json.NewDecoder(req.Body).Decode(&input)
+		if err != nil {
+			res.Write([]byte("Malformed Request.")) // TODO: Json-Schema helps
+			return
+		}
+		fmt.Println(input) // debug: output the request
+
+		// Match the input strings to build a list of input properties. CURIEs are expanded to full IRIs first.
+		list, unknownInput, err := model.ResolvePropertyList(prefixes.ExpandAll(input.Properties), prefixes.ExpandAll(input.Types), unknown)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Make an assessment of the input properties.
+		assessment := assessment.NewInstance(list, model, true)
+
+		// Make a recommendation based on the assessed input and chosen strategy.
+		t1 := time.Now()
+		origRecs := workflow.Recommend(assessment)
+		fmt.Println(time.Since(t1))
+
+		// Put a hard limit on the recommendations returned.
+		if len(origRecs) > hardLimit {
+			origRecs = origRecs[:hardLimit]
+		}
+
+		// For each recommendation, add a mapping from the glossary.
+		labRecs := glossary.TranslateRecommendations(glos, input.Lang, origRecs)
+
+		// Prepare the recommendation list. Types are output without their internal type prefix.
+		outputRecs := make([]TypeRecommendationOutputEntry, len(labRecs), len(labRecs))
+		for i, rec := range labRecs {
+			outputRecs[i].TypeStr = rec.Property.IRI()
+			if input.Compact {
+				outputRecs[i].TypeStr = prefixes.Compact(outputRecs[i].TypeStr)
+			}
+			outputRecs[i].Label = &rec.Content.Label
+			outputRecs[i].Description = &rec.Content.Description
+			outputRecs[i].Probability = rec.Probability
+			if input.Explain {
+				outputRecs[i].Support = origRecs[i].Support
+				outputRecs[i].SetSupport = origRecs[i].SetSupport
+				outputRecs[i].Branches = origRecs[i].Branches
+				outputRecs[i].Error = origRecs[i].Error
+			}
+		}
+
+		// Write the recommendations as a JSON array.
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(TypeRecommenderResponse{Recommendations: outputRecs, Unknown: unknownInput})
+	}
+}
+
+// CompletenessRequest is the data representation of the completeness request input in json.
+type CompletenessRequest struct {
+	Lang       string   `json:"lang"`
+	Types      []string `json:"types"`
+	Properties []string `json:"properties"`
+	Threshold  float64  `json:"threshold"` // optional: probability from which on a property is expected (default 0.5)
+	Compact    bool     `json:"compact"`   // optional: output CURIEs like wdt:P31 instead of full IRIs
+}
+
+// CompletenessResponse is the data representation of the completeness json.
+type CompletenessResponse struct {
+	Score   float64                     `json:"score"`
+	Present int                         `json:"present"`
+	Missing []RecommendationOutputEntry `json:"missing"`
+	Unknown []string                    `json:"unknown,omitempty"` // input properties and types unknown to the model
+}
+
+// setupCompleteness will setup a handler to score the completeness of an entity against its peers. It
+// also receives a language with which additional information is added.
+// It will return the score together with the expected but missing properties, their probabilities, labels and descriptions.
+func setupCompleteness(
+	model *schematree.SchemaTree,
+	glos *glossary.Glossary,
+	unknown schematree.UnknownHandling, // How to treat input properties and types unknown to the model
+	prefixes *rio.PrefixRegistry, // Prefixes of the CURIEs that may be used in- and output
+) func(http.ResponseWriter, *http.Request) {
+	return func(res http.ResponseWriter, req *http.Request) {
+
+		// Decode the JSON input and build a list of input strings
+		var input = CompletenessRequest{}
+		err := json.NewDecoder(req.Body).Decode(&input)
+		if err != nil {
+			res.Write([]byte("Malformed Request.")) // TODO: Json-Schema helps
+			return
+		}
+		fmt.Println(input) // debug: output the request
+
+		// Match the input strings to build a list of input properties. CURIEs are expanded to full IRIs first.
+		list, unknownInput, err := model.ResolvePropertyList(prefixes.ExpandAll(input.Properties), prefixes.ExpandAll(input.Types), unknown)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Score the entity against the subjects that have the same properties and types.
+		t1 := time.Now()
+		completeness := model.Completeness(list, input.Threshold)
+		fmt.Println(time.Since(t1))
+
+		// For each missing property, add a mapping from the glossary.
+		labRecs := glossary.TranslateRecommendations(glos, input.Lang, completeness.Missing)
+
+		// Prepare the list of missing properties.
+		outputRecs := make([]RecommendationOutputEntry, len(labRecs), len(labRecs))
+		for i, rec := range labRecs {
+			iri := rec.Property.Str()
+			outputRecs[i].PropertyStr = &iri
+			if input.Compact {
+				curie := prefixes.Compact(iri)
+				outputRecs[i].PropertyStr = &curie
+			}
+			outputRecs[i].Label = &rec.Content.Label
+			outputRecs[i].Description = &rec.Content.Description
+			outputRecs[i].Probability = rec.Probability
+		}
+
+		// Write the completeness as JSON.
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(CompletenessResponse{
+			Score:   completeness.Score,
+			Present: completeness.Present,
+			Missing: outputRecs,
+			Unknown: unknownInput,
+		})
+	}
+}
+
 // setupRecommender will setup a handler to recommend properties based on the list of properties and types.
 // It will return an array of recommendations with their respective probabilities.
 // No gloassary information is added to the response.
-func setupLeanRecommender(tree *schematree.SchemaTree, workflow *strategy.Workflow) func(http.ResponseWriter, *http.Request) {
+func setupLeanRecommender(tree *schematree.SchemaTree, workflow *strategy.Workflow, prefixes *rio.PrefixRegistry) func(http.ResponseWriter, *http.Request) {
 
 	// Fetch the map of all properties in the SchemaTree
 	pMap := tree.PropMap
@@ -145,10 +348,10 @@ func setupLeanRecommender(tree *schematree.SchemaTree, workflow *strategy.Workfl
 		}
 		fmt.Println(properties)
 
-		// Match the input strings to build a list of input properties.
+		// Match the input strings to build a list of input properties. CURIEs are expanded to full IRIs first.
 		list := []*schematree.IItem{}
-		for _, pString := range properties {
-			p, ok := pMap[pString]
+		for _, pString := range prefixes.ExpandAll(properties) {
+			p, ok := pMap.Lookup(pString)
 			if ok {
 				list = append(list, p)
 			}
@@ -175,7 +378,7 @@ func setupLeanRecommender(tree *schematree.SchemaTree, workflow *strategy.Workfl
 }
 
 // setupSupportComputation will setup a handler that returns the percentage of all training sets that contained the given property combination.
-func setupSupportComputation(tree *schematree.SchemaTree) func(http.ResponseWriter, *http.Request) {
+func setupSupportComputation(tree *schematree.SchemaTree, prefixes *rio.PrefixRegistry) func(http.ResponseWriter, *http.Request) {
 
 	// Fetch the map of all properties in the SchemaTree
 	pMap := tree.PropMap
@@ -193,10 +396,10 @@ func setupSupportComputation(tree *schematree.SchemaTree) func(http.ResponseWrit
 
 		t1 := time.Now()
 
-		// Match the input strings to build a list of input properties.
+		// Match the input strings to build a list of input properties. CURIEs are expanded to full IRIs first.
 		list := []*schematree.IItem{}
-		for _, pString := range properties {
-			p, ok := pMap[pString]
+		for _, pString := range prefixes.ExpandAll(properties) {
+			p, ok := pMap.Lookup(pString)
 			if ok {
 				list = append(list, p)
 			}
@@ -239,7 +442,8 @@ func setupPropTypeRec(
 		outputRecs := make([]RecommendationOutputEntry, len(labRecs), len(labRecs))
 		for i, rec := range labRecs {
 			// if rec.Property.IsType() {
-			outputRecs[i].PropertyStr = rec.Property.Str
+			iri := rec.Property.Str()
+			outputRecs[i].PropertyStr = &iri
 			outputRecs[i].Probability = rec.Probability
 		}
 
@@ -254,11 +458,17 @@ func setupPropTypeRec(
 }
 
 // SetupEndpoints configures a router with all necessary endpoints and their corresponding handlers.
-func SetupEndpoints(model *schematree.SchemaTree, glossary *glossary.Glossary, workflow *strategy.Workflow, hardLimit int) http.Handler {
+// The typeWorkflow is used by the type recommender; it should consist of type recommending procedures.
+// Unknown input properties and types are treated according to the given handling by the recommenders that
+// echo them in their response. All endpoints accept CURIEs with the given prefixes instead of full IRIs.
+func SetupEndpoints(model *schematree.SchemaTree, glossary *glossary.Glossary, workflow *strategy.Workflow, typeWorkflow *strategy.Workflow, hardLimit int, unknown schematree.UnknownHandling, prefixes *rio.PrefixRegistry) http.Handler {
 	router := http.NewServeMux()
-	router.HandleFunc("/lean-recommender", setupLeanRecommender(model, workflow))
-	router.HandleFunc("/recommender", setupMappedRecommender(model, glossary, workflow, hardLimit))
-	router.HandleFunc("/support", setupSupportComputation(model))
+	router.HandleFunc("/lean-recommender", setupLeanRecommender(model, workflow, prefixes))
+	router.HandleFunc("/recommender", setupMappedRecommender(model, glossary, workflow, hardLimit, unknown, prefixes))
+	router.HandleFunc("/types", setupTypeRecommender(model, glossary, typeWorkflow, hardLimit, unknown, prefixes))
+	router.HandleFunc("/completeness", setupCompleteness(model, glossary, unknown, prefixes))
+	router.HandleFunc("/session", setupSession(model, glossary, workflow, hardLimit, unknown, prefixes))
+	router.HandleFunc("/support", setupSupportComputation(model, prefixes))
 	router.HandleFunc("/propType", setupPropTypeRec(model))
 	// router.HandleFunc("/wikiRecommender", wikiRecommender)
 	return router
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lgleim/SchemaTreeRecommender/glossary"
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/lgleim/SchemaTreeRecommender/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappedRecommenderUnknown(t *testing.T) {
+	model, err := schematree.Load(typedTreepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glos := make(glossary.Glossary)
+	workflow := strategy.MakePresetWorkflow("direct", model)
+
+	request := func(unknown schematree.UnknownHandling, input RecommenderRequest) (*httptest.ResponseRecorder, RecommenderResponse) {
+		handler := setupMappedRecommender(model, &glos, workflow, 500, unknown, rio.DefaultPrefixes())
+		data, _ := json.Marshal(input)
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest(http.MethodPost, "/recommender", bytes.NewReader(data)))
+		var output RecommenderResponse
+		if res.Code == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(res.Body).Decode(&output))
+		}
+		return res, output
+	}
+
+	// unknown absent properties are reported like unknown input properties
+	input := RecommenderRequest{Properties: []string{"wdt:P31", "wdt:P0"}, Absent: []string{"wdt:P17", "wdt:P1"}}
+	res, output := request(schematree.UnknownHandling{}, input)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.NotEmpty(t, output.Recommendations)
+	assert.Equal(t, []string{"http://www.wikidata.org/prop/direct/P0", "http://www.wikidata.org/prop/direct/P1"}, output.Unknown)
+
+	// and rejected with the error policy
+	input.Properties = []string{"wdt:P31"}
+	res, _ = request(schematree.UnknownHandling{Policy: schematree.FailOnUnknown}, input)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestTypeRecommender(t *testing.T) {
+	model, err := schematree.Load(typedTreepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glos := make(glossary.Glossary)
+	unknown := schematree.UnknownHandling{}
+	request := func(input RecommenderRequest) (*httptest.ResponseRecorder, map[string]json.RawMessage, TypeRecommenderResponse) {
+		router := SetupEndpoints(model, &glos, strategy.MakePresetWorkflow("direct", model), strategy.MakePresetWorkflow("types", model), 5, unknown, rio.DefaultPrefixes())
+		data, _ := json.Marshal(input)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/types", bytes.NewReader(data)))
+		var fields map[string]json.RawMessage
+		var output TypeRecommenderResponse
+		if res.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &fields))
+			assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &output))
+		}
+		return res, fields, output
+	}
+
+	// types are recommended by their IRIs, without the internal type prefix
+	input := RecommenderRequest{Lang: "de", Properties: []string{"wdt:P17", "wdt:P0"}, Types: []string{}}
+	res, fields, output := request(input)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.Contains(t, fields, "recommendations")
+	assert.Equal(t, []string{"http://www.wikidata.org/prop/direct/P0"}, output.Unknown)
+	assert.NotEmpty(t, output.Recommendations)
+	assert.LessOrEqual(t, len(output.Recommendations), 5)
+	for i, rec := range output.Recommendations {
+		assert.Regexp(t, `^http://www\.wikidata\.org/entity/Q\d+$`, rec.TypeStr)
+		assert.Equal(t, rec.TypeStr, *rec.Label) // no glossary entry
+		assert.Zero(t, rec.Support)              // only with explain
+		if i > 0 {
+			assert.LessOrEqual(t, rec.Probability, output.Recommendations[i-1].Probability)
+		}
+	}
+	var entries []map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(fields["recommendations"], &entries))
+	for _, field := range []string{"type", "label", "description", "probability"} {
+		assert.Contains(t, entries[0], field)
+	}
+
+	// compact CURIEs, labels in the requested language or in English, and the support evidence
+	top := output.Recommendations[0].TypeStr
+	glos[glossary.Key{Property: top, Lang: "en"}] = &glossary.Content{Label: "label", Description: "description"}
+	input.Compact, input.Explain = true, true
+	_, _, output = request(input)
+	assert.Equal(t, "wd:"+top[len("http://www.wikidata.org/entity/"):], output.Recommendations[0].TypeStr)
+	assert.Equal(t, "label", *output.Recommendations[0].Label)
+	assert.Equal(t, "description", *output.Recommendations[0].Description)
+	assert.NotZero(t, output.Recommendations[0].Support)
+	assert.NotZero(t, output.Recommendations[0].SetSupport)
+
+	// unknown properties are rejected with the error policy
+	unknown.Policy = schematree.FailOnUnknown
+	res, _, _ = request(input)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	input.Properties = []string{"wdt:P17"}
+	res, _, _ = request(input)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
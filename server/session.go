@@ -0,0 +1,215 @@
+package server
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lgleim/SchemaTreeRecommender/assessment"
+	"github.com/lgleim/SchemaTreeRecommender/glossary"
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/lgleim/SchemaTreeRecommender/strategy"
+)
+
+// SessionTimeout is the time after which an unused session is discarded.
+const SessionTimeout = 30 * time.Minute
+
+// MaxSessions is the number of sessions that are kept. When a new session would exceed it, the least
+// recently used session is discarded.
+const MaxSessions = 10000
+
+// SessionRequest is the data representation of the session request input in json.
+type SessionRequest struct {
+	Session     string   `json:"session"` // optional: id of an existing session, a new session is started if empty
+	Lang        string   `json:"lang"`
+	Add         []string `json:"add"`         // properties to add to the session
+	Remove      []string `json:"remove"`      // properties to remove from the session
+	AddTypes    []string `json:"addTypes"`    // types to add to the session
+	RemoveTypes []string `json:"removeTypes"` // types to remove from the session
+	Compact     bool     `json:"compact"`     // optional: output CURIEs like wdt:P31 instead of full IRIs
+}
+
+// SessionResponse is the data representation of the session json.
+type SessionResponse struct {
+	Session         string                      `json:"session"`
+	Recommendations []RecommendationOutputEntry `json:"recommendations"`
+	Unknown         []string                    `json:"unknown,omitempty"` // input properties and types unknown to the model
+}
+
+// session is the assessment of an editing session
+type session struct {
+	id       string
+	lock     sync.Mutex
+	asm      *assessment.Instance
+	lastUsed time.Time
+}
+
+// sessionStore keeps the sessions by their id, in the order of their use. Sessions unused for the timeout
+// are discarded, and the least recently used ones if there are more than maxSessions.
+// thread-safe
+type sessionStore struct {
+	lock        sync.Mutex
+	sessions    map[string]*list.Element
+	lru         *list.List // front is most recently used
+	timeout     time.Duration
+	maxSessions int
+}
+
+func newSessionStore(timeout time.Duration, maxSessions int) *sessionStore {
+	return &sessionStore{
+		sessions:    make(map[string]*list.Element),
+		lru:         list.New(),
+		timeout:     timeout,
+		maxSessions: maxSessions,
+	}
+}
+
+// get returns the session with the given id, or starts a new one if the id is empty.
+// Expired sessions are discarded on the way, which are at the back of the list.
+func (s *sessionStore) get(id string, model *schematree.SchemaTree) (string, *session, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for back := s.lru.Back(); back != nil && now.Sub(back.Value.(*session).lastUsed) > s.timeout; back = s.lru.Back() {
+		s.remove(back)
+	}
+
+	if id == "" {
+		if s.lru.Len() >= s.maxSessions {
+			s.remove(s.lru.Back())
+		}
+		id = newSessionID()
+		s.sessions[id] = s.lru.PushFront(&session{id: id, asm: assessment.NewInstance(schematree.IList{}, model, true), lastUsed: now})
+	}
+	elem, ok := s.sessions[id]
+	if !ok {
+		return id, nil, false
+	}
+	sess := elem.Value.(*session)
+	sess.lastUsed = now
+	s.lru.MoveToFront(elem)
+	return id, sess, true
+}
+
+// end discards the session with the given id and returns whether it existed
+func (s *sessionStore) end(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	elem, ok := s.sessions[id]
+	if ok {
+		s.remove(elem)
+	}
+	return ok
+}
+
+func (s *sessionStore) remove(elem *list.Element) {
+	delete(s.sessions, elem.Value.(*session).id)
+	s.lru.Remove(elem)
+}
+
+// newSessionID returns a random session id
+func newSessionID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// setupSession will setup a handler for editing sessions, in which properties and types are added and removed
+// step by step. The assessment of each session is kept between requests, such that the tree branches matching
+// the previous properties can be reused. A DELETE request with the session id as `session` parameter ends the session,
+// or fails with 404 Not Found if there is no such session.
+// It will return the session id and an array of recommendations, with their respective probabilities, labels and descriptions.
+func setupSession(
+	model *schematree.SchemaTree,
+	glos *glossary.Glossary,
+	workflow *strategy.Workflow,
+	hardLimit int, // Hard limit of recommendations to output
+	unknown schematree.UnknownHandling, // How to treat input properties and types unknown to the model
+	prefixes *rio.PrefixRegistry, // Prefixes of the CURIEs that may be used in- and output
+) func(http.ResponseWriter, *http.Request) {
+	store := newSessionStore(SessionTimeout, MaxSessions)
+
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			if id := req.URL.Query().Get("session"); !store.end(id) {
+				http.Error(res, "Unknown or expired session: "+id, http.StatusNotFound)
+			}
+			return
+		}
+
+		// Decode the JSON input and build a list of input strings
+		var input = SessionRequest{}
+		err := json.NewDecoder(req.Body).Decode(&input)
+		if err != nil {
+			res.Write([]byte("Malformed Request.")) // TODO: Json-Schema helps
+			return
+		}
+		fmt.Println(input) // debug: output the request
+
+		// Match the input strings to build the lists of added and removed properties. CURIEs are expanded to full IRIs first.
+		add, unknownAdd, err := model.ResolvePropertyList(prefixes.ExpandAll(input.Add), prefixes.ExpandAll(input.AddTypes), unknown)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		remove, unknownRemove, err := model.ResolvePropertyList(prefixes.ExpandAll(input.Remove), prefixes.ExpandAll(input.RemoveTypes), unknown)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, sess, ok := store.get(input.Session, model)
+		if !ok {
+			http.Error(res, "Unknown or expired session: "+input.Session, http.StatusNotFound)
+			return
+		}
+		sess.lock.Lock()
+		defer sess.lock.Unlock()
+
+		// Update the assessment of the session.
+		sess.asm.Remove(remove)
+		sess.asm.Add(add)
+
+		// Make a recommendation based on the assessed input and chosen strategy.
+		t1 := time.Now()
+		origRecs := workflow.Recommend(sess.asm)
+		fmt.Println(time.Since(t1))
+
+		// Put a hard limit on the recommendations returned.
+		if len(origRecs) > hardLimit {
+			origRecs = origRecs[:hardLimit]
+		}
+
+		// For each recommendation, add a mapping from the glossary.
+		labRecs := glossary.TranslateRecommendations(glos, input.Lang, origRecs)
+
+		// Prepare the recommendation list.
+		outputRecs := make([]RecommendationOutputEntry, len(labRecs), len(labRecs))
+		for i, rec := range labRecs {
+			iri := rec.Property.Str()
+			outputRecs[i].PropertyStr = &iri
+			if input.Compact {
+				curie := prefixes.Compact(iri)
+				outputRecs[i].PropertyStr = &curie
+			}
+			outputRecs[i].Label = &rec.Content.Label
+			outputRecs[i].Description = &rec.Content.Description
+			outputRecs[i].Probability = rec.Probability
+		}
+
+		// Write the recommendations as a JSON array.
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(SessionResponse{
+			Session:         id,
+			Recommendations: outputRecs,
+			Unknown:         append(unknownAdd, unknownRemove...),
+		})
+	}
+}
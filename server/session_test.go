@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lgleim/SchemaTreeRecommender/glossary"
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/lgleim/SchemaTreeRecommender/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+var typedTreepath = "../testdata/10M.nt.gz.schemaTree.typed.bin"
+
+func TestSessionStore(t *testing.T) {
+	store := newSessionStore(time.Hour, 2)
+	first, _, ok := store.get("", nil)
+	assert.True(t, ok)
+	second, _, _ := store.get("", nil)
+	_, _, ok = store.get(first, nil) // first is used more recently than second now
+	assert.True(t, ok)
+
+	// the least recently used session is discarded for a new one
+	third, _, _ := store.get("", nil)
+	_, _, ok = store.get(second, nil)
+	assert.False(t, ok)
+	_, _, ok = store.get(first, nil)
+	assert.True(t, ok)
+
+	assert.True(t, store.end(third))
+	assert.False(t, store.end(third))
+	assert.Equal(t, 1, store.lru.Len())
+
+	// unused sessions expire
+	store.timeout = 0
+	_, _, ok = store.get(first, nil)
+	assert.False(t, ok)
+	assert.Empty(t, store.sessions)
+}
+
+func TestSession(t *testing.T) {
+	model, err := schematree.Load(typedTreepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glos := make(glossary.Glossary)
+	handler := setupSession(model, &glos, strategy.MakePresetWorkflow("direct", model), 1000, schematree.UnknownHandling{}, rio.DefaultPrefixes())
+
+	request := func(method string, url string, body interface{}) (*httptest.ResponseRecorder, SessionResponse) {
+		data, _ := json.Marshal(body)
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest(method, url, bytes.NewReader(data)))
+		var output SessionResponse
+		if res.Code == http.StatusOK && method != http.MethodDelete {
+			assert.NoError(t, json.NewDecoder(res.Body).Decode(&output))
+		}
+		return res, output
+	}
+
+	// a new session
+	res, output := request(http.MethodPost, "/session", SessionRequest{Add: []string{"wdt:P31", "wdt:P0"}, AddTypes: []string{"wd:Q515"}})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.NotEmpty(t, output.Session)
+	assert.NotEmpty(t, output.Recommendations)
+	assert.Equal(t, []string{"http://www.wikidata.org/prop/direct/P0"}, output.Unknown)
+	id := output.Session
+
+	// the recommendations follow the changes of the session
+	_, output = request(http.MethodPost, "/session", SessionRequest{Session: id, Add: []string{"wdt:P17"}, Compact: true})
+	assert.Equal(t, id, output.Session)
+	for _, rec := range output.Recommendations {
+		assert.NotEqual(t, "wdt:P17", *rec.PropertyStr)
+		assert.NotEqual(t, "wdt:P31", *rec.PropertyStr)
+	}
+	_, output = request(http.MethodPost, "/session", SessionRequest{Session: id, Remove: []string{"wdt:P17"}, Compact: true})
+	recommended := false
+	for _, rec := range output.Recommendations {
+		recommended = recommended || *rec.PropertyStr == "wdt:P17"
+	}
+	assert.True(t, recommended, "P17 is recommended again")
+
+	// ending sessions
+	res, _ = request(http.MethodDelete, "/session?session="+id, nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	res, _ = request(http.MethodDelete, "/session?session="+id, nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	res, _ = request(http.MethodPost, "/session", SessionRequest{Session: id})
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
@@ -0,0 +1,284 @@
+package shell
+
+// This package provides an interactive prompt to explore the recommendations of a loaded model.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/lgleim/SchemaTreeRecommender/assessment"
+	"github.com/lgleim/SchemaTreeRecommender/configuration"
+	"github.com/lgleim/SchemaTreeRecommender/glossary"
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/lgleim/SchemaTreeRecommender/strategy"
+	"github.com/pkg/errors"
+)
+
+const help = `Commands:
+  add <property>...        add properties to the current set
+  remove <property>...     remove properties from the current set
+  type <type>...           add types to the current set
+  untype <type>...         remove types from the current set
+  clear                    remove all properties and types
+  show                     show the current set and its support
+  rec [k]                  show the top k (default 10) property recommendations
+  types [k]                show the top k (default 10) type recommendations
+  workflow <preset|file>   switch the property workflow to a preset (e.g. direct) or a config file
+  lang <code>              switch the language of the labels (default en)
+  help                     show this help
+  exit                     leave the shell
+Properties and types can be given as IRIs or as CURIEs like wdt:P31.
+`
+
+// Shell holds the state of an interactive session: the current property set and the active workflow.
+type Shell struct {
+	model        *schematree.SchemaTree
+	glos         *glossary.Glossary
+	prefixes     *rio.PrefixRegistry
+	workflow     *strategy.Workflow
+	workflowName string
+	lang         string
+	properties   []string // full IRIs
+	types        []string // full IRIs, without the type prefix
+}
+
+// New creates a shell for the model. The glossary may be nil, in which case IRIs are shown instead of labels.
+func New(model *schematree.SchemaTree, glos *glossary.Glossary, prefixes *rio.PrefixRegistry) *Shell {
+	if glos == nil {
+		glos = &glossary.Glossary{}
+	}
+	if prefixes == nil {
+		prefixes = rio.NewPrefixRegistry()
+	}
+	return &Shell{
+		model:        model,
+		glos:         glos,
+		prefixes:     prefixes,
+		workflow:     strategy.MakePresetWorkflow("direct", model),
+		workflowName: "direct",
+		lang:         "en",
+	}
+}
+
+// Run reads commands from the terminal until the user exits. The command history is kept in `historyFile`.
+func (sh *Shell) Run(historyFile string) error {
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          "> ",
+		HistoryFile:     historyFile,
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+	})
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+
+	fmt.Fprint(rl.Stdout(), help)
+	for {
+		line, err := rl.Readline()
+		if err == readline.ErrInterrupt {
+			continue
+		} else if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if sh.Execute(line, rl.Stdout()) {
+			return nil
+		}
+	}
+}
+
+// DefaultHistoryFile returns the path of the history file in the home directory of the user.
+func DefaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".schematree_history")
+}
+
+// Execute runs a single command line and writes its output to out. It returns true if the shell should exit.
+func (sh *Shell) Execute(line string, out io.Writer) (exit bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	command, args := fields[0], fields[1:]
+
+	var err error
+	switch command {
+	case "add":
+		sh.properties, err = sh.add(sh.properties, args, false)
+	case "remove":
+		sh.properties = sh.remove(sh.properties, args)
+	case "type":
+		sh.types, err = sh.add(sh.types, args, true)
+	case "untype":
+		sh.types = sh.remove(sh.types, args)
+	case "clear":
+		sh.properties, sh.types = nil, nil
+	case "show":
+		sh.show(out)
+	case "rec":
+		err = sh.recommend(out, args, false)
+	case "types":
+		err = sh.recommend(out, args, true)
+	case "workflow":
+		err = sh.switchWorkflow(args)
+		if err == nil {
+			fmt.Fprintf(out, "Using workflow %v\n", sh.workflowName)
+		}
+	case "lang":
+		if len(args) != 1 {
+			err = errors.New("usage: lang <code>")
+		} else {
+			sh.lang = args[0]
+		}
+	case "help":
+		fmt.Fprint(out, help)
+	case "exit", "quit":
+		return true
+	default:
+		err = errors.Errorf("unknown command %v, type help for a list of commands", command)
+	}
+
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+	}
+	return false
+}
+
+// add expands and appends the given IRIs or CURIEs to the list. IRIs unknown to the model are rejected.
+func (sh *Shell) add(list []string, args []string, isType bool) ([]string, error) {
+	for _, arg := range args {
+		iri := sh.prefixes.Expand(arg)
+		key := iri
+		if isType {
+			key = "t#" + iri
+		}
+		if _, ok := sh.model.PropMap.Lookup(key); !ok {
+			return list, errors.Errorf("%v is unknown to the model", iri)
+		}
+		if !contains(list, iri) {
+			list = append(list, iri)
+		}
+	}
+	return list, nil
+}
+
+// remove removes the given IRIs or CURIEs from the list
+func (sh *Shell) remove(list []string, args []string) []string {
+	for _, arg := range args {
+		iri := sh.prefixes.Expand(arg)
+		for i, entry := range list {
+			if entry == iri {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+	}
+	return list
+}
+
+// show prints the current set with the number of training subjects that have all of it
+func (sh *Shell) show(out io.Writer) {
+	list := sh.model.BuildPropertyList(sh.properties, sh.types)
+	fmt.Fprintf(out, "Workflow: %v, language: %v\n", sh.workflowName, sh.lang)
+	for _, p := range sh.properties {
+		fmt.Fprintf(out, "  property %v\n", sh.prefixes.Compact(p))
+	}
+	for _, t := range sh.types {
+		fmt.Fprintf(out, "  type     %v\n", sh.prefixes.Compact(t))
+	}
+	fmt.Fprintf(out, "Support: %v of %v subjects\n", sh.model.Support(list), sh.model.Root.Support)
+}
+
+// recommend prints the top k property or type recommendations for the current set as a table
+func (sh *Shell) recommend(out io.Writer, args []string, types bool) error {
+	k := 10
+	if len(args) > 0 {
+		var err error
+		if k, err = strconv.Atoi(args[0]); err != nil || k <= 0 {
+			return errors.Errorf("invalid number of recommendations: %v", args[0])
+		}
+	}
+
+	asm := assessment.NewInstance(sh.model.BuildPropertyList(sh.properties, sh.types), sh.model, true)
+	t1 := time.Now()
+	var recs schematree.PropertyRecommendations
+	if types {
+		recs = asm.CalcTypeRecommendations()
+	} else {
+		recs = sh.workflow.Recommend(asm)
+	}
+	duration := time.Since(t1)
+	total := len(recs)
+	if len(recs) > k {
+		recs = recs[:k]
+	}
+
+	labRecs := glossary.TranslateRecommendations(sh.glos, sh.lang, recs)
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tIRI\tLABEL\tPROBABILITY\tSUPPORT")
+	for i, rec := range labRecs {
+		support := ""
+		if recs[i].SetSupport > 0 {
+			support = fmt.Sprintf("%v/%v", recs[i].Support, recs[i].SetSupport)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%.4f\t%v\n", i+1, sh.prefixes.Compact(rec.Property.IRI()), rec.Content.Label, rec.Probability, support)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "%v of %v recommendations (%v)\n", len(recs), total, duration)
+	return nil
+}
+
+// switchWorkflow replaces the property workflow by a preset or by the workflow of a config file
+func (sh *Shell) switchWorkflow(args []string) (err error) {
+	if len(args) != 1 {
+		return errors.New("usage: workflow <preset|file>")
+	}
+	name := args[0]
+
+	if _, statErr := os.Stat(name); statErr == nil {
+		config, err := configuration.ReadConfigFile(&name)
+		if err != nil {
+			return err
+		}
+		if err = config.Test(); err != nil {
+			return err
+		}
+		workflow, err := configuration.ConfigToWorkflow(config, sh.model)
+		if err != nil {
+			return err
+		}
+		sh.workflow, sh.workflowName = workflow, name
+		return nil
+	}
+
+	// presets panic on unknown names
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("neither a file nor a preset: %v", name)
+		}
+	}()
+	sh.workflow, sh.workflowName = strategy.MakePresetWorkflow(name, sh.model), name
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, entry := range list {
+		if entry == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	rio "github.com/lgleim/SchemaTreeRecommender/io"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+	"github.com/stretchr/testify/assert"
+)
+
+var typedTreepath = "../testdata/10M.nt.gz.schemaTree.typed.bin"
+
+func TestExecute(t *testing.T) {
+	model, err := schematree.Load(typedTreepath)
+	assert.NoError(t, err)
+	sh := New(model, nil, rio.DefaultPrefixes())
+
+	run := func(line string) string {
+		var out bytes.Buffer
+		assert.False(t, sh.Execute(line, &out))
+		return out.String()
+	}
+
+	assert.Empty(t, run("add wdt:P31 http://www.wikidata.org/prop/direct/P17"))
+	assert.Empty(t, run("type wd:Q515"))
+	assert.Equal(t, []string{"http://www.wikidata.org/prop/direct/P31", "http://www.wikidata.org/prop/direct/P17"}, sh.properties)
+	assert.Equal(t, []string{"http://www.wikidata.org/entity/Q515"}, sh.types)
+
+	assert.Contains(t, run("add wdt:P0"), "unknown to the model")
+	assert.Len(t, sh.properties, 2)
+
+	run("remove wdt:P17")
+	assert.Equal(t, []string{"http://www.wikidata.org/prop/direct/P31"}, sh.properties)
+
+	rec := run("rec 3")
+	assert.Equal(t, 3+2, strings.Count(rec, "\n")) // header, 3 recommendations, summary
+	assert.Contains(t, run("types"), "wd:Q")
+	assert.Contains(t, run("show"), "Support: ")
+
+	assert.Contains(t, run("workflow nope"), "Error")
+	assert.Equal(t, "direct", sh.workflowName)
+	assert.Contains(t, run("workflow splitproperty"), "Using workflow splitproperty")
+
+	run("clear")
+	assert.Empty(t, sh.properties)
+	assert.Empty(t, sh.types)
+
+	assert.Contains(t, run("frobnicate"), "unknown command")
+	assert.True(t, sh.Execute("exit", &bytes.Buffer{}))
+}
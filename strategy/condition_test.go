@@ -16,8 +16,8 @@ func TestConditions(t *testing.T) {
 	}
 	pMap := schema.PropMap
 	// create properties
-	item1, _ := pMap["http://www.wikidata.org/prop/direct/P31"] // large number (1224) recommendations after executing on the schema tree ../testdata/10M.nt.gz.schemaTree.bin
-	item2, _ := pMap["http://www.wikidata.org/prop/direct/P21"] // small number (487)
+	item1, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P31") // large number (1224) recommendations after executing on the schema tree ../testdata/10M.nt.gz.schemaTree.bin
+	item2, _ := pMap.Lookup("http://www.wikidata.org/prop/direct/P21") // small number (487)
 	// create assessments
 	asm1 := assessment.NewInstance(schematree.IList{item1}, schema, true)
 	asm2 := assessment.NewInstance(schematree.IList{item2}, schema, true)
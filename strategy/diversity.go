@@ -0,0 +1,75 @@
+package strategy
+
+// This file is responsible for re-ranking recommendations to reduce redundancy.
+
+import (
+	"github.com/lgleim/SchemaTreeRecommender/assessment"
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+)
+
+// DefaultDiversityWindow is the number of top recommendations that are re-ranked if no window is given.
+const DefaultDiversityWindow = 20
+
+// Helper method to re-rank the recommendations of another procedure for diversity, see DiversityRerank.
+func MakeDiverseProcedure(tree *schematree.SchemaTree, proc Procedure, penalty float64, window int) Procedure {
+	return func(asm *assessment.Instance) schematree.PropertyRecommendations {
+		return DiversityRerank(tree, proc(asm), penalty, window)
+	}
+}
+
+// DiversityRerank re-orders the top `window` recommendations such that candidates which mostly co-occur
+// with higher ranked candidates (e.g. a row of external identifiers) move down the list.
+//
+// The list is built greedily: each position is taken by the candidate with the highest score
+// `Probability - penalty * redundancy`, where the redundancy of a candidate is its highest co-occurrence
+// with an already ranked candidate, measured as Jaccard index Support({c, r}) / Support(c or r) in [0, 1].
+// Unlike a conditional probability, it does not make everything redundant to ubiquitous properties like labels.
+// Probabilities themselves are not changed and candidates below the window keep their order. Since the
+// pairwise supports are computed on the tree, the cost grows quadratically with the window size.
+func DiversityRerank(tree *schematree.SchemaTree, recs schematree.PropertyRecommendations, penalty float64, window int) schematree.PropertyRecommendations {
+	if window <= 0 {
+		window = DefaultDiversityWindow
+	}
+	if window > len(recs) {
+		window = len(recs)
+	}
+	if penalty <= 0 || window < 2 {
+		return recs
+	}
+
+	remaining := make(schematree.PropertyRecommendations, window)
+	copy(remaining, recs[:window])
+	redundancy := make([]float64, window) // highest co-occurrence of each remaining candidate with a ranked one
+
+	reranked := make(schematree.PropertyRecommendations, 0, len(recs))
+	for len(remaining) > 0 {
+		best := 0
+		for i := range remaining {
+			if remaining[i].Probability-penalty*redundancy[i] > remaining[best].Probability-penalty*redundancy[best] {
+				best = i
+			}
+		}
+		chosen := remaining[best]
+		reranked = append(reranked, chosen)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		redundancy = append(redundancy[:best], redundancy[best+1:]...)
+
+		for i := range remaining {
+			if r := cooccurrence(tree, chosen.Property, remaining[i].Property); r > redundancy[i] {
+				redundancy[i] = r
+			}
+		}
+	}
+
+	return append(reranked, recs[window:]...)
+}
+
+// cooccurrence returns the Jaccard index of the subjects having a and the subjects having b
+func cooccurrence(tree *schematree.SchemaTree, a, b *schematree.IItem) float64 {
+	both := float64(tree.Support(schematree.IList{a, b}))
+	either := float64(a.TotalCount) + float64(b.TotalCount) - both
+	if either <= 0 {
+		return 0
+	}
+	return both / either
+}
@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/lgleim/SchemaTreeRecommender/schematree"
+)
+
+func TestDiversityRerank(t *testing.T) {
+	schema, err := schematree.Load(treePath)
+	if err != nil {
+		t.Fatalf("Schematree could not be loaded")
+	}
+	p31, _ := schema.PropMap.Lookup("http://www.wikidata.org/prop/direct/P31")
+	recs := schema.RecommendProperty(schematree.IList{p31})
+	if len(recs) < 30 {
+		t.Fatalf("Too few recommendations to re-rank: %v", len(recs))
+	}
+
+	// without penalty the ranking is unchanged
+	same := DiversityRerank(schema, recs, 0, 10)
+	for i := range recs {
+		if same[i].Property != recs[i].Property {
+			t.Fatalf("Re-ranking without penalty changed position %v", i)
+		}
+	}
+
+	reranked := DiversityRerank(schema, recs, 0.5, 10)
+	if len(reranked) != len(recs) {
+		t.Fatalf("Re-ranking changed the number of recommendations: %v != %v", len(reranked), len(recs))
+	}
+	if reranked[0].Property != recs[0].Property {
+		t.Errorf("The top recommendation should not be penalized")
+	}
+	top := map[*schematree.IItem]bool{}
+	for i := 0; i < 10; i++ {
+		top[recs[i].Property] = true
+	}
+	for i := 0; i < 10; i++ {
+		if !top[reranked[i].Property] {
+			t.Errorf("Candidate %v moved into the re-ranked window", reranked[i].Property.Str())
+		}
+	}
+	for i := 10; i < len(recs); i++ {
+		if reranked[i].Property != recs[i].Property {
+			t.Errorf("Candidate below the window changed position %v", i)
+		}
+	}
+}
+
+func TestDiversityRerankCooccurring(t *testing.T) {
+	schema, err := schematree.Load(treePath)
+	if err != nil {
+		t.Fatalf("Schematree could not be loaded")
+	}
+	prop := func(id string) *schematree.IItem {
+		item, ok := schema.PropMap.Lookup("http://www.wikidata.org/prop/direct/" + id)
+		if !ok {
+			t.Fatalf("Property %v is not in the schematree", id)
+		}
+		return item
+	}
+	gnd, viaf := prop("P227"), prop("P214")            // external identifiers that mostly occur together
+	pointInTime, follows := prop("P585"), prop("P155") // rarely occur with these identifiers
+	if c := cooccurrence(schema, gnd, viaf); c < 0.5 {
+		t.Fatalf("The identifiers should co-occur, but their Jaccard index is %v", c)
+	}
+	for _, item := range []*schematree.IItem{pointInTime, follows} {
+		if c := cooccurrence(schema, gnd, item) + cooccurrence(schema, viaf, item); c > 0.1 {
+			t.Fatalf("%v should not co-occur with the identifiers, but their Jaccard indexes sum to %v", item.Str(), c)
+		}
+	}
+
+	recs := schematree.PropertyRecommendations{
+		{Property: gnd, Probability: 0.5},
+		{Property: pointInTime, Probability: 0.45},
+		{Property: viaf, Probability: 0.44},
+		{Property: follows, Probability: 0.4},
+	}
+	reranked := DiversityRerank(schema, recs, 0.5, 4)
+
+	// the second identifier moves down by a rank, the candidate above it keeps its place
+	if reranked[1].Property != pointInTime {
+		t.Errorf("The non-co-occurring candidate moved to %v", reranked[1].Property.Str())
+	}
+	if reranked[2].Property != follows || reranked[3].Property != viaf {
+		t.Errorf("The co-occurring identifier did not move down: %v, %v", reranked[2].Property.Str(), reranked[3].Property.Str())
+	}
+}
@@ -79,6 +79,14 @@ func MakeAssessmentAwareDirectProcedure() Procedure {
 	}
 }
 
+// Helper method to create the direct SchemaTree type recommender procedure.
+// The returned recommendations are types instead of properties.
+func MakeAssessmentAwareTypeProcedure() Procedure {
+	return func(asm *assessment.Instance) schematree.PropertyRecommendations {
+		return asm.CalcTypeRecommendations()
+	}
+}
+
 const ePrefix = "t#http://www.wikidata.org/entity/"
 const pPrefix = "http://www.wikidata.org/prop/direct/"
 
@@ -89,11 +97,11 @@ func MakeWikidataRecommender(useTypes, useProperties bool) Procedure {
 		for _, p := range asm.Props {
 			if p.IsType() {
 				// if useTypes {
-				properties = append(properties, strings.TrimPrefix(*p.Str, ePrefix))
+				properties = append(properties, strings.TrimPrefix(p.Str(), ePrefix))
 				// }
 			} else {
 				// if useProperties {
-				properties = append(properties, strings.TrimPrefix(*p.Str, pPrefix))
+				properties = append(properties, strings.TrimPrefix(p.Str(), pPrefix))
 				// }
 
 			}
@@ -102,6 +110,14 @@ func MakeWikidataRecommender(useTypes, useProperties bool) Procedure {
 	}
 }
 
+// Helper method to create the sampling SchemaTree procedure, which approximates the direct procedure by
+// checking at most maxBranches branches of the tree. Properties known to be absent are ignored.
+func MakeSampledProcedure(tree *schematree.SchemaTree, maxBranches int) Procedure {
+	return func(asm *assessment.Instance) schematree.PropertyRecommendations {
+		return tree.RecommendPropertySampled(asm.Props, maxBranches)
+	}
+}
+
 // Helper method to create the 'deletelowfrequency' backoff procedure.
 func MakeDeleteLowFrequencyProcedure(tree *schematree.SchemaTree, parExecs int, stepsize backoff.StepsizeFunc, condition backoff.InternalCondition) Procedure {
 	b := backoff.NewBackoffDeleteLowFrequencyItems(tree, parExecs, stepsize, condition)
@@ -118,6 +134,14 @@ func MakeSplitPropertyProcedure(tree *schematree.SchemaTree, splitter backoff.Sp
 	}
 }
 
+// Helper method to re-estimate the probabilities of the recommendations returned by another procedure,
+// e.g. to smooth overconfident probabilities of rarely observed property sets.
+func MakeEstimatedProcedure(proc Procedure, estimator schematree.Estimator) Procedure {
+	return func(asm *assessment.Instance) schematree.PropertyRecommendations {
+		return proc(asm).Reestimate(estimator)
+	}
+}
+
 // MakePresetWorkflow : Build a preset strategy that is hard-coded.
 func MakePresetWorkflow(name string, tree *schematree.SchemaTree) *Workflow {
 	wf := Workflow{}
@@ -179,6 +203,14 @@ func MakePresetWorkflow(name string, tree *schematree.SchemaTree) *Workflow {
 			"always run direct algorithm",
 		)
 
+	// Calls the schematree type recommender directly. Recommends types instead of properties.
+	case "types":
+		wf.Push(
+			MakeAlwaysCondition(),
+			MakeAssessmentAwareTypeProcedure(),
+			"always run direct type algorithm",
+		)
+
 	case "wikidata-property":
 		wf.Push(
 			MakeAlwaysCondition(),